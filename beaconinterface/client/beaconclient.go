@@ -1,20 +1,39 @@
 package client
 
 import (
+	"net/http"
 	"net/url"
+	"time"
 )
 
+// ClientOptions controls the requests sent to a beacon node
+type ClientOptions struct {
+	// Timeout of a call to the node, retries included. Every attempt gets an
+	// equal share of what is left, so a hung attempt leaves time to retry.
+	Timeout time.Duration
+	// Retries after a network error or a 5xx or 429 response
+	Retries int
+	// Backoff before the first retry, doubled on every further retry
+	Backoff time.Duration
+}
+
 type beaconClient struct {
 	beaconEndpoint *url.URL
+	httpClient     *http.Client
+	options        ClientOptions
 }
 
-func NewBeaconClient(endpoint string) (*beaconClient, error) {
+func NewBeaconClient(endpoint string, options ClientOptions) (*beaconClient, error) {
 	/*
 		Initializes a new beacon client
+		Every node gets its own connection pool, so a hung node
+		can't hold up the requests to the others
 	*/
 	u, err := url.Parse(endpoint)
 	bc := &beaconClient{
 		beaconEndpoint: u,
+		httpClient:     &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+		options:        options,
 	}
 
 	// Client is initialized by multiBeacon client.
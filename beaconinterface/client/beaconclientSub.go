@@ -3,48 +3,62 @@ package client
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/r3labs/sse/v2"
 
 	beaconTypes "github.com/bsn-eng/pon-golang-types/beaconclient"
+	beaconData "github.com/pon-pbs/bbRelay/beaconinterface/data"
 )
 
-func (b *beaconClient) SubscribeToHeadEvents(ctx context.Context, headChannel chan beaconTypes.HeadEventData) {
+const (
+	// Wait before reconnecting to an event stream, doubled after every
+	// connection that fails up to maxReconnectDelay
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 30 * time.Second
+
+	// A chain event stream without events for this long is considered dead,
+	// nodes send a head event every slot
+	chainEventsIdleTimeout = 2 * time.Minute
+)
+
+func (b *beaconClient) SubscribeToChainEvents(ctx context.Context, chainEventsC chan beaconData.ChainEvent) {
 	/*
-		Subscribe to head events from the beacon chain
-		Events are sent to the headChannel
+		Subscribe to head, chain_reorg and finalized_checkpoint events from the beacon chain
+		Events are sent to the chainEventsC channel in the order the node sent them
 	*/
-	log.Info("starting head events subscription", "endpoint", b.beaconEndpoint.String())
-	defer log.Debug("head events subscription ended", "endpoint", b.beaconEndpoint.String())
-
-	for {
-		client := sse.NewClient(fmt.Sprintf("%s/eth/v1/events?topics=head", b.beaconEndpoint.String()))
-		// Use sse client to subscribe to events
-		err := client.SubscribeRawWithContext(ctx, func(msg *sse.Event) {
-			var event beaconTypes.HeadEventData
-			if err := json.Unmarshal(msg.Data, &event); err != nil {
-				log.Warn("head event subscription failed", "error", err)
-				return
-			}
-			headChannel <- event
-		})
+	log.Info("starting chain events subscription", "endpoint", b.beaconEndpoint.String())
+	defer log.Debug("chain events subscription ended", "endpoint", b.beaconEndpoint.String())
 
-		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+	b.subscribe(ctx, "head,chain_reorg,finalized_checkpoint", chainEventsIdleTimeout, func(msg *sse.Event) {
+		event := beaconData.ChainEvent{Node: b.BaseEndpoint()}
+		var err error
+		switch string(msg.Event) {
+		case "head":
+			event.Head = new(beaconTypes.HeadEventData)
+			err = json.Unmarshal(msg.Data, event.Head)
+		case "chain_reorg":
+			event.Reorg = new(beaconData.ReorgEventData)
+			err = json.Unmarshal(msg.Data, event.Reorg)
+		case "finalized_checkpoint":
+			event.Finalized = new(beaconData.FinalizedCheckpointEventData)
+			err = json.Unmarshal(msg.Data, event.Finalized)
+		default:
 			return
 		}
-
 		if err != nil {
-			log.Error("failed to subscribe to head events")
-			time.Sleep(1 * time.Second)
+			log.Warn("chain event subscription failed", "event", string(msg.Event), "error", err)
+			return
 		}
 
-		log.Warn("beaconclient SubscribeRaw ended, reconnecting")
-	}
-
+		select {
+		case chainEventsC <- event:
+		case <-ctx.Done():
+		}
+	})
 }
 
 func (b *beaconClient) SubscribeToPayloadAttributesEvents(ctx context.Context, payloadAttributesC chan beaconTypes.PayloadAttributesEvent) {
@@ -55,27 +69,80 @@ func (b *beaconClient) SubscribeToPayloadAttributesEvents(ctx context.Context, p
 	log.Info("starting payload attributes events subscription", "endpoint", b.beaconEndpoint.String())
 	defer log.Debug("payload attributes events subscription ended", "endpoint", b.beaconEndpoint.String())
 
+	b.subscribe(ctx, "payload_attributes", 0, func(msg *sse.Event) {
+		var event beaconTypes.PayloadAttributesEvent
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			log.Warn("payload event subscription failed", "error", err)
+			return
+		}
+
+		if event.Data == nil {
+			log.Warn("payload event subscription failed", "error", "payload data is nil")
+			return
+		}
+
+		select {
+		case payloadAttributesC <- event:
+		case <-ctx.Done():
+		}
+	})
+}
+
+func (b *beaconClient) subscribe(ctx context.Context, topics string, idleTimeout time.Duration, handler func(msg *sse.Event)) {
+	/*
+		Keep an event stream of the node open until ctx is cancelled.
+		The stream is reconnected when it ends, fails or, if idleTimeout is set,
+		stays silent for idleTimeout.
+	*/
+	delay := minReconnectDelay
 	for {
-		client := sse.NewClient(fmt.Sprintf("%s/eth/v1/events?topics=payload_attributes", b.beaconEndpoint.String()))
-		// Use sse client to subscribe to events
-		err := client.SubscribeRawWithContext(ctx,func(msg *sse.Event) {
-			var event beaconTypes.PayloadAttributesEvent
-			if err := json.Unmarshal(msg.Data, &event); err != nil {
-				log.Warn("payload event subscription failed", "error", err)
-				return
-			}
+		streamCtx, cancel := context.WithCancel(ctx)
+		var received atomic.Bool
+		var idle *time.Timer
+		if idleTimeout > 0 {
+			idle = time.AfterFunc(idleTimeout, cancel)
+		}
 
-			if event.Data == nil {
-				log.Warn("payload event subscription failed", "error", "payload data is nil")
-				return
+		client := sse.NewClient(fmt.Sprintf("%s/eth/v1/events?topics=%s", b.beaconEndpoint.String(), topics))
+		// reconnect here instead, the client would keep retrying after ctx is cancelled
+		client.ReconnectStrategy = stopReconnecting{}
+		err := client.SubscribeRawWithContext(streamCtx, func(msg *sse.Event) {
+			received.Store(true)
+			if idle != nil {
+				idle.Reset(idleTimeout)
+			}
+			if len(msg.Data) > 0 {
+				handler(msg)
 			}
-			payloadAttributesC <- event
-
 		})
-		if err != nil {
-			log.Error("failed to subscribe to payload_attributes events")
-			time.Sleep(1 * time.Second)
+
+		if idle != nil {
+			idle.Stop()
+		}
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if received.Load() {
+			delay = minReconnectDelay
+		}
+		log.Warn("beacon event stream ended, reconnecting", "topics", topics, "endpoint", b.beaconEndpoint.String(), "err", err, "delay", delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
 		}
-		log.Warn("beaconclient SubscribeRaw ended, reconnecting")
 	}
 }
+
+// stopReconnecting makes the sse client return as soon as its stream ends
+type stopReconnecting struct{}
+
+func (stopReconnecting) NextBackOff() time.Duration { return -1 }
+func (stopReconnecting) Reset()                     {}
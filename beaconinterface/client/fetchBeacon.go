@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -10,13 +11,13 @@ import (
 	beaconData "github.com/pon-pbs/bbRelay/beaconinterface/data"
 )
 
-func (b *beaconClient) GetSlotProposerMap(epoch uint64) (beaconData.SlotProposerMap, error) {
+func (b *beaconClient) GetSlotProposerMap(ctx context.Context, epoch uint64) (beaconData.SlotProposerMap, error) {
 	// Get proposer duties for given epoch
 	u := *b.beaconEndpoint
 	u.Path = fmt.Sprintf("/eth/v1/validator/duties/proposer/%d", epoch)
 	resp := new(beaconTypes.GetProposerDutiesResponse)
 
-	err := b.fetchBeacon(&u, resp)
+	err := b.fetchBeacon(ctx, &u, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -29,20 +30,20 @@ func (b *beaconClient) GetSlotProposerMap(epoch uint64) (beaconData.SlotProposer
 
 }
 
-func (b *beaconClient) SyncStatus() (*beaconTypes.SyncStatusData, error) {
+func (b *beaconClient) SyncStatus(ctx context.Context) (*beaconTypes.SyncStatusData, error) {
 	// Get sync status
 	u := *b.beaconEndpoint
 	u.Path = "/eth/v1/node/syncing"
 	resp := new(beaconTypes.GetSyncStatusResponse)
 
-	err := b.fetchBeacon(&u, resp)
+	err := b.fetchBeacon(ctx, &u, resp)
 	if err != nil {
 		return nil, err
 	}
 	return resp.Data, nil
 }
 
-func (b *beaconClient) GetValidatorList(headSlot uint64) ([]*beaconTypes.ValidatorData, error) {
+func (b *beaconClient) GetValidatorList(ctx context.Context, headSlot uint64) ([]*beaconTypes.ValidatorData, error) {
 	// Get validator list for given slot
 	u := *b.beaconEndpoint
 	u.Path = fmt.Sprintf("/eth/v1/beacon/states/%d/validators", headSlot)
@@ -51,13 +52,13 @@ func (b *beaconClient) GetValidatorList(headSlot uint64) ([]*beaconTypes.Validat
 	u.RawQuery = q.Encode()
 
 	var validators beaconTypes.GetValidatorsResponse
-	err := b.fetchBeacon(&u, &validators)
+	err := b.fetchBeacon(ctx, &u, &validators)
 	if err != nil {
 		return nil, err
 	}
 	return validators.Data, nil
 }
-func (b *beaconClient) GetValidatorIndex(validators []string) (*beaconTypes.GetValidatorsResponse, error) {
+func (b *beaconClient) GetValidatorIndex(ctx context.Context, validators []string) (*beaconTypes.GetValidatorsResponse, error) {
 
 	u := *b.beaconEndpoint
 	u.Path = "/eth/v1/beacon/states/head/validators"
@@ -68,7 +69,7 @@ func (b *beaconClient) GetValidatorIndex(validators []string) (*beaconTypes.GetV
 
 	u.RawQuery = q.Encode()
 	resp := new(beaconTypes.GetValidatorsResponse)
-	err := b.fetchBeacon(&u, &resp)
+	err := b.fetchBeacon(ctx, &u, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -76,39 +77,39 @@ func (b *beaconClient) GetValidatorIndex(validators []string) (*beaconTypes.GetV
 	return resp, nil
 }
 
-func (b *beaconClient) Genesis() (*beaconTypes.GenesisData, error) {
+func (b *beaconClient) Genesis(ctx context.Context) (*beaconTypes.GenesisData, error) {
 	// Get genesis data
 	resp := new(beaconTypes.GetGenesisResponse)
 	u := *b.beaconEndpoint
 
 	u.Path = "/eth/v1/beacon/genesis"
-	err := b.fetchBeacon(&u, &resp)
+	err := b.fetchBeacon(ctx, &u, &resp)
 	if err != nil {
 		return nil, err
 	}
 	return resp.Data, err
 }
 
-func (b *beaconClient) GetWithdrawals(slot uint64) (*beaconTypes.Withdrawals, error) {
+func (b *beaconClient) GetWithdrawals(ctx context.Context, slot uint64) (*beaconTypes.Withdrawals, error) {
 	// Get withdrawals for given slot
 	resp := new(beaconTypes.GetWithdrawalsResponse)
 	u := *b.beaconEndpoint
 
 	u.Path = fmt.Sprintf("/eth/v1/builder/states/%d/expected_withdrawals", slot)
-	err := b.fetchBeacon(&u, &resp)
+	err := b.fetchBeacon(ctx, &u, &resp)
 	if err != nil {
 		return nil, err
 	}
 	return resp.Data, err
 }
 
-func (b *beaconClient) Randao(slot uint64) (*common.Hash, error) {
+func (b *beaconClient) Randao(ctx context.Context, slot uint64) (*common.Hash, error) {
 	// Get randao for given slot
 	resp := new(beaconTypes.GetRandaoResponse)
 	u := *b.beaconEndpoint
 	u.Path = fmt.Sprintf("/eth/v1/beacon/states/%d/randao", slot)
 
-	err := b.fetchBeacon(&u, &resp)
+	err := b.fetchBeacon(ctx, &u, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -117,13 +118,13 @@ func (b *beaconClient) Randao(slot uint64) (*common.Hash, error) {
 	return &data.Randao, err
 }
 
-func (b *beaconClient) GetBlockHeader(slot uint64) (*beaconTypes.BlockHeaderData, error) {
+func (b *beaconClient) GetBlockHeader(ctx context.Context, slot uint64) (*beaconTypes.BlockHeaderData, error) {
 	// Get block header for given slot
 	resp := new(beaconTypes.GetBlockHeaderResponse)
 	u := *b.beaconEndpoint
 	u.Path = fmt.Sprintf("/eth/v1/beacon/headers/%d", slot)
 
-	err := b.fetchBeacon(&u, &resp)
+	err := b.fetchBeacon(ctx, &u, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -131,20 +132,44 @@ func (b *beaconClient) GetBlockHeader(slot uint64) (*beaconTypes.BlockHeaderData
 	return resp.Data, err
 }
 
-func (b *beaconClient) GetCurrentBlockHeader() (*beaconTypes.BlockHeaderData, error) {
+func (b *beaconClient) GetCurrentBlockHeader(ctx context.Context) (*beaconTypes.BlockHeaderData, error) {
 	// Get block header for given slot
 	resp := new(beaconTypes.GetBlockHeaderResponse)
 	u := *b.beaconEndpoint
 	u.Path = "/eth/v1/beacon/headers/head"
 
-	err := b.fetchBeacon(&u, &resp)
+	err := b.fetchBeacon(ctx, &u, &resp)
 	if err != nil {
 		return nil, err
 	}
 	return resp.Data, err
 }
 
-func (b *beaconClient) GetForkVersion(slot uint64, head bool) (forkName string, forkVersion string, err error) {
+func (b *beaconClient) GetExecutionBlockHash(ctx context.Context, blockRoot string) (common.Hash, error) {
+	// Get the execution block hash of the block, the blinded block only carries
+	// the header of the execution payload
+	var resp struct {
+		Data struct {
+			Message struct {
+				Body struct {
+					ExecutionPayloadHeader struct {
+						BlockHash common.Hash `json:"block_hash"`
+					} `json:"execution_payload_header"`
+				} `json:"body"`
+			} `json:"message"`
+		} `json:"data"`
+	}
+	u := *b.beaconEndpoint
+	u.Path = fmt.Sprintf("/eth/v1/beacon/blinded_blocks/%s", blockRoot)
+
+	err := b.fetchBeacon(ctx, &u, &resp)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return resp.Data.Message.Body.ExecutionPayloadHeader.BlockHash, nil
+}
+
+func (b *beaconClient) GetForkVersion(ctx context.Context, slot uint64, head bool) (forkName string, forkVersion string, err error) {
 
 	type NodeSpec struct {
 		Data map[string]string `json:"data"`
@@ -168,7 +193,7 @@ func (b *beaconClient) GetForkVersion(slot uint64, head bool) (forkName string,
 
 	u.Path = "/eth/v1/config/spec"
 	specResp := new(NodeSpec)
-	err = b.fetchBeacon(&u, &specResp)
+	err = b.fetchBeacon(ctx, &u, &specResp)
 	if err != nil {
 		return "", "", err
 	}
@@ -189,7 +214,7 @@ func (b *beaconClient) GetForkVersion(slot uint64, head bool) (forkName string,
 		u.Path = fmt.Sprintf("/eth/v2/beacon/states/%d/fork", slot)
 	}
 	currForkResp := new(CurrentFork)
-	err = b.fetchBeacon(&u, &currForkResp)
+	err = b.fetchBeacon(ctx, &u, &currForkResp)
 	if err != nil {
 		return "", "", err
 	}
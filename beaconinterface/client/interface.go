@@ -14,21 +14,22 @@ type BeaconClientInstance interface {
 	BaseEndpoint() string
 
 	// get methods
-	GetSlotProposerMap(uint64) (beaconData.SlotProposerMap, error)
-	SyncStatus() (*beaconTypes.SyncStatusData, error)
-	GetValidatorList(uint64) ([]*beaconTypes.ValidatorData, error)
-	GetValidatorIndex([]string) (*beaconTypes.GetValidatorsResponse, error)
-	Genesis() (*beaconTypes.GenesisData, error)
-	GetWithdrawals(uint64) (*beaconTypes.Withdrawals, error)
-	Randao(uint64) (*common.Hash, error)
-	GetBlockHeader(slot uint64) (*beaconTypes.BlockHeaderData, error)
-	GetCurrentBlockHeader() (*beaconTypes.BlockHeaderData, error)
-	GetForkVersion(slot uint64, head bool) (forkName string, forkVersion string, err error)
+	GetSlotProposerMap(context.Context, uint64) (beaconData.SlotProposerMap, error)
+	SyncStatus(context.Context) (*beaconTypes.SyncStatusData, error)
+	GetValidatorList(context.Context, uint64) ([]*beaconTypes.ValidatorData, error)
+	GetValidatorIndex(context.Context, []string) (*beaconTypes.GetValidatorsResponse, error)
+	Genesis(context.Context) (*beaconTypes.GenesisData, error)
+	GetWithdrawals(context.Context, uint64) (*beaconTypes.Withdrawals, error)
+	Randao(context.Context, uint64) (*common.Hash, error)
+	GetBlockHeader(ctx context.Context, slot uint64) (*beaconTypes.BlockHeaderData, error)
+	GetCurrentBlockHeader(context.Context) (*beaconTypes.BlockHeaderData, error)
+	GetExecutionBlockHash(ctx context.Context, blockRoot string) (common.Hash, error)
+	GetForkVersion(ctx context.Context, slot uint64, head bool) (forkName string, forkVersion string, err error)
 
 	// post methods
 	PublishBlock(context.Context, commonTypes.VersionedSignedBeaconBlock) error
 
 	// subscription methods
-	SubscribeToHeadEvents(context.Context, chan beaconTypes.HeadEventData)
+	SubscribeToChainEvents(context.Context, chan beaconData.ChainEvent)
 	SubscribeToPayloadAttributesEvents(context.Context, chan beaconTypes.PayloadAttributesEvent)
 }
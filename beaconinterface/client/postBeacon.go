@@ -16,6 +16,6 @@ func (b *beaconClient) PublishBlock(ctx context.Context, block commonTypes.Versi
 		return err
 	}
 
-	err = b.postBeacon(&u, block_json)
+	err = b.postBeacon(ctx, &u, block_json)
 	return err
 }
\ No newline at end of file
@@ -4,45 +4,32 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 )
 
-func (b *beaconClient) fetchBeacon(u *url.URL, dst any) error {
+// responseError is a non 2xx response of the beacon node
+type responseError struct {
+	url        string
+	statusCode int
+	message    string
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("error response from beacon node for %s: %d %s", e.url, e.statusCode, e.message)
+}
+
+func (b *beaconClient) fetchBeacon(ctx context.Context, u *url.URL, dst any) error {
 	/*
 		Utility function to fetch data from the beacon node
 	*/
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-	if err != nil {
-		return fmt.Errorf("invalid request for %s: %w", u, err)
-	}
-	req.Header.Set("accept", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("client refused for %s: %w", u, err)
-	}
-	defer resp.Body.Close()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
+	bodyBytes, err := b.do(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return fmt.Errorf("could not read response body for %s: %w", u, err)
-	}
-
-	if resp.StatusCode >= 300 {
-		ec := &struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		}{}
-		if err = json.Unmarshal(bodyBytes, ec); err != nil {
-			return fmt.Errorf("could not unmarshal error response from beacon node for %s from %s: %w", u, string(bodyBytes), err)
-		}
-		return fmt.Errorf("error response from beacon node for %s: %s", u, ec.Message)
+		return err
 	}
 
 	err = json.Unmarshal(bodyBytes, dst)
@@ -53,12 +40,10 @@ func (b *beaconClient) fetchBeacon(u *url.URL, dst any) error {
 	return nil
 }
 
-func (b *beaconClient) postBeacon(u *url.URL, src any) error {
+func (b *beaconClient) postBeacon(ctx context.Context, u *url.URL, src any) error {
 	/*
 		Utility function to post data to the beacon node
 	*/
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 
 	// check if src is bytes or not
 	var src_bytes []byte
@@ -72,15 +57,64 @@ func (b *beaconClient) postBeacon(u *url.URL, src any) error {
 		src_bytes = src.([]byte)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(src_bytes))
+	_, err := b.do(ctx, http.MethodPost, u, src_bytes)
+	return err
+}
+
+func (b *beaconClient) do(ctx context.Context, method string, u *url.URL, body []byte) ([]byte, error) {
+	/*
+		Sends the request until it succeeds, fails for good or the call
+		runs out of time. Network errors, 5xx and 429 responses are retried
+		with exponential backoff, other responses are returned right away.
+	*/
+	if b.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.options.Timeout)
+		defer cancel()
+	}
+
+	backoff := b.options.Backoff
+	for attempt := 0; ; attempt++ {
+		attemptsLeft := b.options.Retries - attempt + 1
+		bodyBytes, err := b.attempt(ctx, method, u, body, attemptsLeft)
+		if err == nil || attemptsLeft <= 1 || !retryable(err) || ctx.Err() != nil {
+			return bodyBytes, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (b *beaconClient) attempt(ctx context.Context, method string, u *url.URL, body []byte, attemptsLeft int) ([]byte, error) {
+	if deadline, ok := ctx.Deadline(); ok && attemptsLeft > 1 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Until(deadline)/time.Duration(attemptsLeft))
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("invalid request for %s: %w", u, err)
+		return nil, fmt.Errorf("invalid request for %s: %w", u, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
+	req.Header.Set("accept", "application/json")
 
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := b.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to post request: %w", err)
+		return nil, fmt.Errorf("client refused for %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response body for %s: %w", u, err)
 	}
 
 	if resp.StatusCode >= 300 {
@@ -88,16 +122,19 @@ func (b *beaconClient) postBeacon(u *url.URL, src any) error {
 			Code    int    `json:"code"`
 			Message string `json:"message"`
 		}{}
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to read error response body: %w", err)
-		}
-
 		if err = json.Unmarshal(bodyBytes, ec); err != nil {
-			return fmt.Errorf("failed to unmarshal error response: %w", err)
+			ec.Message = string(bodyBytes)
 		}
-		return fmt.Errorf("error response from beacon node for %s: %s", u, ec.Message)
+		return nil, &responseError{url: u.String(), statusCode: resp.StatusCode, message: ec.Message}
 	}
 
-	return nil
+	return bodyBytes, nil
+}
+
+func retryable(err error) bool {
+	var respErr *responseError
+	if errors.As(err, &respErr) {
+		return respErr.statusCode >= 500 || respErr.statusCode == http.StatusTooManyRequests
+	}
+	return true
 }
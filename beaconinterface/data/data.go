@@ -13,6 +13,8 @@ type BeaconData struct {
 	CurrentForkVersion string
 
 	CurrentHead beaconTypes.HeadEventData
+	// FinalizedCheckpoint is the latest finalized checkpoint announced by a node
+	FinalizedCheckpoint FinalizedCheckpointEventData
 
 	Mu                       sync.Mutex
 	RandaoMap                RandaoMap
@@ -20,8 +22,10 @@ type BeaconData struct {
 	SlotPayloadAttributesMap SlotPayloadAttributesMap
 	AllValidatorsByPubkey    AllValidatorsByPubkeyMap
 	AllValidatorsByIndex     AllValidatorsByIndexMap
+	// ReplacedHeads are the block roots replaced by a reorg, by slot
+	ReplacedHeads ReplacedHeadsMap
 
-	HeadSlotC          chan beaconTypes.HeadEventData
+	ChainEventC        chan ChainEvent
 	PayloadAttributesC chan beaconTypes.PayloadAttributesEvent
 }
 
@@ -30,3 +34,4 @@ type SlotProposerMap map[uint64]beaconTypes.ProposerDutyData
 type SlotPayloadAttributesMap map[uint64]beaconTypes.PayloadAttributesEventData
 type AllValidatorsByPubkeyMap map[string]beaconTypes.ValidatorData
 type AllValidatorsByIndexMap map[uint64]beaconTypes.ValidatorData
+type ReplacedHeadsMap map[string]uint64
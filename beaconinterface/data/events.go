@@ -0,0 +1,32 @@
+package beaconinterface
+
+import (
+	beaconTypes "github.com/bsn-eng/pon-golang-types/beaconclient"
+)
+
+// ChainEvent is a head, chain_reorg or finalized_checkpoint event of a node,
+// exactly one of the event fields is set
+type ChainEvent struct {
+	// Node is the endpoint of the node that sent the event
+	Node string
+
+	Head      *beaconTypes.HeadEventData
+	Reorg     *ReorgEventData
+	Finalized *FinalizedCheckpointEventData
+}
+
+type ReorgEventData struct {
+	Slot         uint64 `json:"slot,string"`
+	Depth        uint64 `json:"depth,string"`
+	OldHeadBlock string `json:"old_head_block"`
+	NewHeadBlock string `json:"new_head_block"`
+	OldHeadState string `json:"old_head_state"`
+	NewHeadState string `json:"new_head_state"`
+	Epoch        uint64 `json:"epoch,string"`
+}
+
+type FinalizedCheckpointEventData struct {
+	Block string `json:"block"`
+	State string `json:"state"`
+	Epoch uint64 `json:"epoch,string"`
+}
@@ -0,0 +1,101 @@
+package beaconinterface
+
+import (
+	beaconTypes "github.com/bsn-eng/pon-golang-types/beaconclient"
+
+	beaconData "github.com/pon-pbs/bbRelay/beaconinterface/data"
+)
+
+// Reorg is a head replaced by a head of another fork
+type Reorg struct {
+	beaconData.ReorgEventData
+	// Invalidated are the payload attributes built on the replaced head,
+	// the auctions of their slots are void
+	Invalidated []beaconTypes.PayloadAttributesEventData
+}
+
+type beaconListeners struct {
+	head              []func(beaconTypes.HeadEventData)
+	reorg             []func(Reorg)
+	payloadAttributes []func(beaconTypes.PayloadAttributesEventData)
+
+	// payloadAttributesSlot is the latest slot listeners were told about and
+	// payloadAttributesSeen the parents of its attributes, every node sends
+	// the same event so only the first one per parent is passed on
+	payloadAttributesSlot uint64
+	payloadAttributesSeen map[string]struct{}
+}
+
+func (b *MultiBeaconClient) OnHead(listener func(beaconTypes.HeadEventData)) {
+	/*
+		Registers a listener called once for every new head, after the beacon data is updated.
+		Listeners are called from the subscription goroutine and must not block.
+	*/
+	b.listenersMu.Lock()
+	defer b.listenersMu.Unlock()
+	b.listeners.head = append(b.listeners.head, listener)
+}
+
+func (b *MultiBeaconClient) OnReorg(listener func(Reorg)) {
+	/*
+		Registers a listener called once for every head replaced by a reorg, after the beacon data is updated.
+		Listeners are called from the subscription goroutine and must not block.
+	*/
+	b.listenersMu.Lock()
+	defer b.listenersMu.Unlock()
+	b.listeners.reorg = append(b.listeners.reorg, listener)
+}
+
+func (b *MultiBeaconClient) OnPayloadAttributes(listener func(beaconTypes.PayloadAttributesEventData)) {
+	/*
+		Registers a listener called once per proposal slot and parent block when its first payload attributes event arrives,
+		a reorg replacing the parent opens the auction of the slot again.
+		Listeners are called from the subscription goroutine and must not block.
+	*/
+	b.listenersMu.Lock()
+	defer b.listenersMu.Unlock()
+	b.listeners.payloadAttributes = append(b.listeners.payloadAttributes, listener)
+}
+
+func (b *MultiBeaconClient) notifyHead(head beaconTypes.HeadEventData) {
+	b.listenersMu.Lock()
+	listeners := b.listeners.head
+	b.listenersMu.Unlock()
+
+	for _, listener := range listeners {
+		listener(head)
+	}
+}
+
+func (b *MultiBeaconClient) notifyReorg(reorg Reorg) {
+	b.listenersMu.Lock()
+	listeners := b.listeners.reorg
+	b.listenersMu.Unlock()
+
+	for _, listener := range listeners {
+		listener(reorg)
+	}
+}
+
+func (b *MultiBeaconClient) notifyPayloadAttributes(attrs beaconTypes.PayloadAttributesEventData) {
+	b.listenersMu.Lock()
+	if attrs.ProposalSlot < b.listeners.payloadAttributesSlot {
+		b.listenersMu.Unlock()
+		return
+	}
+	if attrs.ProposalSlot > b.listeners.payloadAttributesSlot || b.listeners.payloadAttributesSeen == nil {
+		b.listeners.payloadAttributesSlot = attrs.ProposalSlot
+		b.listeners.payloadAttributesSeen = make(map[string]struct{})
+	}
+	if _, seen := b.listeners.payloadAttributesSeen[attrs.ParentBlockRoot]; seen {
+		b.listenersMu.Unlock()
+		return
+	}
+	b.listeners.payloadAttributesSeen[attrs.ParentBlockRoot] = struct{}{}
+	listeners := b.listeners.payloadAttributes
+	b.listenersMu.Unlock()
+
+	for _, listener := range listeners {
+		listener(attrs)
+	}
+}
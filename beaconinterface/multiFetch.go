@@ -1,6 +1,7 @@
 package beaconinterface
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -13,30 +14,23 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 )
 
-func (b *MultiBeaconClient) GetValidatorList(slot uint64) ([]*beaconTypes.ValidatorData, error) {
+func (b *MultiBeaconClient) GetValidatorList(ctx context.Context, slot uint64) ([]*beaconTypes.ValidatorData, error) {
 	/*
 		Get validators from beacon chain.
 		If any client fails, try the next one.
 		Clients are attempted by best performance first.
-		Performance is also updated in defer function (triggers background update).
+		Every call is recorded in the score of its node.
 	*/
-	defer b.postBeaconCall()
-
-	for _, client := range b.Clients {
-		validatorList, err := client.Node.GetValidatorList(slot)
+	for _, client := range b.ranked() {
+		start := time.Now()
+		validatorList, err := client.Node.GetValidatorList(ctx, slot)
 		if err != nil {
 			log.Error("failed to get validator list", "err", err, "endpoint", client.Node.BaseEndpoint())
-			b.clientUpdate.Lock()
-			client.LastResponseStatus = 500
-			client.LastUsedTime = time.Now()
-			b.clientUpdate.Unlock()
+			b.record(ctx, client, start, err)
 			continue
 		}
 
-		b.clientUpdate.Lock()
-		client.LastResponseStatus = 200
-		client.LastUsedTime = time.Now()
-		b.clientUpdate.Unlock()
+		b.record(ctx, client, start, nil)
 
 		return validatorList, nil
 	}
@@ -45,27 +39,21 @@ func (b *MultiBeaconClient) GetValidatorList(slot uint64) ([]*beaconTypes.Valida
 
 }
 
-func (b *MultiBeaconClient) GetWithdrawals(slot uint64) (withdrawals *beaconTypes.Withdrawals, err error) {
+func (b *MultiBeaconClient) GetWithdrawals(ctx context.Context, slot uint64) (withdrawals *beaconTypes.Withdrawals, err error) {
 	/*
 		Get expected withdrawals for given slot.
 		If any client fails, try the next one.
 		Clients are attempted by best performance first.
-		Performance is also updated in defer function (triggers background update).
+		Every call is recorded in the score of its node.
 	*/
-	defer b.postBeaconCall()
-	for _, client := range b.Clients {
-		if withdrawals, err = client.Node.GetWithdrawals(slot); err != nil {
+	for _, client := range b.ranked() {
+		start := time.Now()
+		if withdrawals, err = client.Node.GetWithdrawals(ctx, slot); err != nil {
 			log.Warn("failed to get withdrawals", "err", err, "endpoint", client.Node.BaseEndpoint())
-			b.clientUpdate.Lock()
-			client.LastResponseStatus = 500
-			client.LastUsedTime = time.Now()
-			b.clientUpdate.Unlock()
+			b.record(ctx, client, start, err)
 			continue
 		}
-		b.clientUpdate.Lock()
-		client.LastResponseStatus = 200
-		client.LastUsedTime = time.Now()
-		b.clientUpdate.Unlock()
+		b.record(ctx, client, start, nil)
 
 		return withdrawals, nil
 	}
@@ -73,30 +61,24 @@ func (b *MultiBeaconClient) GetWithdrawals(slot uint64) (withdrawals *beaconType
 	return nil, err
 }
 
-func (b *MultiBeaconClient) GetSlotProposerMap(epoch uint64) (beaconData.SlotProposerMap, error) {
+func (b *MultiBeaconClient) GetSlotProposerMap(ctx context.Context, epoch uint64) (beaconData.SlotProposerMap, error) {
 	/*
 		Get proposer duties for a given epoch. This is used to create a map of slot to proposer.
 		If any client fails, try the next one.
 		Clients are attempted by best performance first.
-		Performance is also updated in defer function (triggers background update).
+		Every call is recorded in the score of its node.
 	*/
-	defer b.postBeaconCall()
-	for _, client := range b.Clients {
+	for _, client := range b.ranked() {
+		start := time.Now()
 
-		duties, err := client.Node.GetSlotProposerMap(epoch)
+		duties, err := client.Node.GetSlotProposerMap(ctx, epoch)
 		if err != nil {
 			log.Error("beacon client service: failed to get proposer duties", "err", err, "endpoint", client.Node.BaseEndpoint())
-			b.clientUpdate.Lock()
-			client.LastResponseStatus = 500
-			client.LastUsedTime = time.Now()
-			b.clientUpdate.Unlock()
+			b.record(ctx, client, start, err)
 			continue
 		}
 
-		b.clientUpdate.Lock()
-		client.LastResponseStatus = 200
-		client.LastUsedTime = time.Now()
-		b.clientUpdate.Unlock()
+		b.record(ctx, client, start, nil)
 
 		return duties, nil
 	}
@@ -104,28 +86,22 @@ func (b *MultiBeaconClient) GetSlotProposerMap(epoch uint64) (beaconData.SlotPro
 	return nil, errors.New("all beacon nodes failed")
 }
 
-func (b *MultiBeaconClient) Genesis() (genesisData *beaconTypes.GenesisData, err error) {
+func (b *MultiBeaconClient) Genesis(ctx context.Context) (genesisData *beaconTypes.GenesisData, err error) {
 	/*
 		Get chain genesis data.
 		If any client fails, try the next one.
 		Clients are attempted by best performance first.
-		Performance is also updated in defer function (triggers background update).
+		Every call is recorded in the score of its node.
 	*/
-	defer b.postBeaconCall()
-	for _, client := range b.Clients {
-		if genesisData, err = client.Node.Genesis(); err != nil {
+	for _, client := range b.ranked() {
+		start := time.Now()
+		if genesisData, err = client.Node.Genesis(ctx); err != nil {
 			log.Warn("failed to get genesis", "err", err, "endpoint", client.Node.BaseEndpoint())
-			b.clientUpdate.Lock()
-			client.LastResponseStatus = 500
-			client.LastUsedTime = time.Now()
-			b.clientUpdate.Unlock()
+			b.record(ctx, client, start, err)
 			continue
 		}
 
-		b.clientUpdate.Lock()
-		client.LastResponseStatus = 200
-		client.LastUsedTime = time.Now()
-		b.clientUpdate.Unlock()
+		b.record(ctx, client, start, nil)
 
 		return genesisData, nil
 	}
@@ -133,13 +109,13 @@ func (b *MultiBeaconClient) Genesis() (genesisData *beaconTypes.GenesisData, err
 	return nil, err
 }
 
-func (b *MultiBeaconClient) Randao(slot uint64) (randao *common.Hash, err error) {
+func (b *MultiBeaconClient) Randao(ctx context.Context, slot uint64) (randao *common.Hash, err error) {
 	/*
 		Get randao of slot. Attempts to retrieve from known randao map first.
 		If not found, attempts to retrieve from client.
 		If any client fails, try the next one.
 		Clients are attempted by best performance first.
-		Performance is also updated in defer function (triggers background update).
+		Every call is recorded in the score of its node.
 	*/
 	b.BeaconData.Mu.Lock()
 	knownRandao, found := b.BeaconData.RandaoMap[slot]
@@ -148,21 +124,15 @@ func (b *MultiBeaconClient) Randao(slot uint64) (randao *common.Hash, err error)
 		return &knownRandao, nil
 	}
 
-	defer b.postBeaconCall()
-	for _, client := range b.Clients {
-		if randao, err = client.Node.Randao(slot); err != nil {
+	for _, client := range b.ranked() {
+		start := time.Now()
+		if randao, err = client.Node.Randao(ctx, slot); err != nil {
 			// log.Warn("failed to get randao", "err", err, "endpoint", client.Node.BaseEndpoint())
-			b.clientUpdate.Lock()
-			client.LastResponseStatus = 500
-			client.LastUsedTime = time.Now()
-			b.clientUpdate.Unlock()
+			b.record(ctx, client, start, err)
 			continue
 		}
 
-		b.clientUpdate.Lock()
-		client.LastResponseStatus = 200
-		client.LastUsedTime = time.Now()
-		b.clientUpdate.Unlock()
+		b.record(ctx, client, start, nil)
 
 		return randao, err
 	}
@@ -170,28 +140,22 @@ func (b *MultiBeaconClient) Randao(slot uint64) (randao *common.Hash, err error)
 	return nil, err
 }
 
-func (b *MultiBeaconClient) GetBlockHeader(slot uint64) (blockHeader *beaconTypes.BlockHeaderData, err error) {
+func (b *MultiBeaconClient) GetBlockHeader(ctx context.Context, slot uint64) (blockHeader *beaconTypes.BlockHeaderData, err error) {
 	/*
 		Get block header of slot.
 		If any client fails, try the next one.
 		Clients are attempted by best performance first.
-		Performance is also updated in defer function (triggers background update).
+		Every call is recorded in the score of its node.
 	*/
-	defer b.postBeaconCall()
-	for _, client := range b.Clients {
-		if blockHeader, err = client.Node.GetBlockHeader(slot); err != nil {
+	for _, client := range b.ranked() {
+		start := time.Now()
+		if blockHeader, err = client.Node.GetBlockHeader(ctx, slot); err != nil {
 			log.Warn("failed to get block header", "err", err, "endpoint", client.Node.BaseEndpoint())
-			b.clientUpdate.Lock()
-			client.LastResponseStatus = 500
-			client.LastUsedTime = time.Now()
-			b.clientUpdate.Unlock()
+			b.record(ctx, client, start, err)
 			continue
 		}
 
-		b.clientUpdate.Lock()
-		client.LastResponseStatus = 200
-		client.LastUsedTime = time.Now()
-		b.clientUpdate.Unlock()
+		b.record(ctx, client, start, nil)
 
 		return blockHeader, nil
 	}
@@ -199,28 +163,22 @@ func (b *MultiBeaconClient) GetBlockHeader(slot uint64) (blockHeader *beaconType
 	return nil, err
 }
 
-func (b *MultiBeaconClient) GetCurrentBlockHeader() (blockHeader *beaconTypes.BlockHeaderData, err error) {
+func (b *MultiBeaconClient) GetCurrentBlockHeader(ctx context.Context) (blockHeader *beaconTypes.BlockHeaderData, err error) {
 	/*
 		Get current block header.
 		If any client fails, try the next one.
 		Clients are attempted by best performance first.
-		Performance is also updated in defer function (triggers background update).
+		Every call is recorded in the score of its node.
 	*/
-	defer b.postBeaconCall()
-	for _, client := range b.Clients {
-		if blockHeader, err = client.Node.GetCurrentBlockHeader(); err != nil {
+	for _, client := range b.ranked() {
+		start := time.Now()
+		if blockHeader, err = client.Node.GetCurrentBlockHeader(ctx); err != nil {
 			log.Warn("failed to get current block header", "err", err, "endpoint", client.Node.BaseEndpoint())
-			b.clientUpdate.Lock()
-			client.LastResponseStatus = 500
-			client.LastUsedTime = time.Now()
-			b.clientUpdate.Unlock()
+			b.record(ctx, client, start, err)
 			continue
 		}
 
-		b.clientUpdate.Lock()
-		client.LastResponseStatus = 200
-		client.LastUsedTime = time.Now()
-		b.clientUpdate.Unlock()
+		b.record(ctx, client, start, nil)
 
 		return blockHeader, nil
 	}
@@ -228,29 +186,46 @@ func (b *MultiBeaconClient) GetCurrentBlockHeader() (blockHeader *beaconTypes.Bl
 	return nil, err
 }
 
-func (b *MultiBeaconClient) GetForkVersion(slot uint64, head bool) (forkName string, forkVersion string, err error) {
+func (b *MultiBeaconClient) GetExecutionBlockHash(ctx context.Context, blockRoot string) (blockHash common.Hash, err error) {
+	/*
+		Get the execution block hash of the block with the root.
+		If any client fails, try the next one.
+		Clients are attempted by best performance first.
+		Every call is recorded in the score of its node.
+	*/
+	for _, client := range b.ranked() {
+		start := time.Now()
+		if blockHash, err = client.Node.GetExecutionBlockHash(ctx, blockRoot); err != nil {
+			log.Warn("failed to get execution block hash", "err", err, "endpoint", client.Node.BaseEndpoint())
+			b.record(ctx, client, start, err)
+			continue
+		}
+
+		b.record(ctx, client, start, nil)
+
+		return blockHash, nil
+	}
+
+	return common.Hash{}, err
+}
+
+func (b *MultiBeaconClient) GetForkVersion(ctx context.Context, slot uint64, head bool) (forkName string, forkVersion string, err error) {
 	/*
 		Get fork version of chain.
 		If any client fails, try the next one.
 		Clients are attempted by best performance first.
-		Performance is also updated in defer function (triggers background update).
+		Every call is recorded in the score of its node.
 	*/
-	defer b.postBeaconCall()
-	for _, client := range b.Clients {
+	for _, client := range b.ranked() {
+		start := time.Now()
 
-		if forkName, forkVersion, err = client.Node.GetForkVersion(slot, head); err != nil {
+		if forkName, forkVersion, err = client.Node.GetForkVersion(ctx, slot, head); err != nil {
 			log.Warn("failed to get fork version", "err", err, "endpoint", client.Node.BaseEndpoint())
-			b.clientUpdate.Lock()
-			client.LastResponseStatus = 500
-			client.LastUsedTime = time.Now()
-			b.clientUpdate.Unlock()
+			b.record(ctx, client, start, err)
 			continue
 		}
 
-		b.clientUpdate.Lock()
-		client.LastResponseStatus = 200
-		client.LastUsedTime = time.Now()
-		b.clientUpdate.Unlock()
+		b.record(ctx, client, start, nil)
 
 		return forkName, forkVersion, nil
 	}
@@ -258,30 +233,23 @@ func (b *MultiBeaconClient) GetForkVersion(slot uint64, head bool) (forkName str
 	return "", "", err
 }
 
-func (b *MultiBeaconClient) GetValidatorIndex(newValidators []string, validatorIndexes *relayTypes.ValidatorIndexes) {
+func (b *MultiBeaconClient) GetValidatorIndex(ctx context.Context, newValidators []string, validatorIndexes *relayTypes.ValidatorIndexes) {
 	/*
 		Get Validator Public Key.
 		If any client fails, try the next one.
 		Clients are attempted by best performance first.
-		Performance is also updated in defer function (triggers background update).
+		Every call is recorded in the score of its node.
 	*/
-	defer b.postBeaconCall()
-
-	for _, client := range b.Clients {
-		validators, err := client.Node.GetValidatorIndex(newValidators)
+	for _, client := range b.ranked() {
+		start := time.Now()
+		validators, err := client.Node.GetValidatorIndex(ctx, newValidators)
 		if err != nil {
 			log.Warn("failed to get current block header", "err", err, "endpoint", client.Node.BaseEndpoint())
-			b.clientUpdate.Lock()
-			client.LastResponseStatus = 500
-			client.LastUsedTime = time.Now()
-			b.clientUpdate.Unlock()
+			b.record(ctx, client, start, err)
 			continue
 		}
 
-		b.clientUpdate.Lock()
-		client.LastResponseStatus = 200
-		client.LastUsedTime = time.Now()
-		b.clientUpdate.Unlock()
+		b.record(ctx, client, start, nil)
 		validatorIndexes.Mu.Lock()
 		for _, validator := range validators.Data {
 			validatorIndexes.ValidatorPubkeyIndex[validator.Validator.Pubkey] = validator.Index
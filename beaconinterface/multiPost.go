@@ -3,7 +3,6 @@ package beaconinterface
 import (
 	"context"
 	"errors"
-	"sync"
 	"time"
 
 	commonTypes "github.com/bsn-eng/pon-golang-types/common"
@@ -16,13 +15,12 @@ func (b *MultiBeaconClient) PublishBlock(ctx context.Context, block commonTypes.
 		No penalty for multiple submissions
 		Increased reliability in case of some clients being down
 	*/
-	defer b.postBeaconCall()
 	// Create a channel to receive errors from the clients
 	submissionError := make(chan error, len(b.Clients))
 
 	for _, client := range b.Clients {
 		// Have all clients publish the block asynchronously
-		go publishAsync(ctx, &b.clientUpdate, client, block, submissionError)
+		go b.publishAsync(ctx, client, block, submissionError)
 	}
 
 	var responseCount int
@@ -71,19 +69,13 @@ func (b *MultiBeaconClient) PublishBlock(ctx context.Context, block commonTypes.
 	}
 }
 
-func publishAsync(ctx context.Context, clientUpdate *sync.Mutex, client BeaconClient, block commonTypes.VersionedSignedBeaconBlock, submissionError chan<- error) {
+func (b *MultiBeaconClient) publishAsync(ctx context.Context, client BeaconClient, block commonTypes.VersionedSignedBeaconBlock, submissionError chan<- error) {
 
+	start := time.Now()
 	err := client.Node.PublishBlock(ctx, block)
 	if err != nil {
 		log.Warn("failed to publish block", "err", err, "endpoint", client.Node.BaseEndpoint())
-		clientUpdate.Lock()
-		client.LastResponseStatus = 500
-		client.LastUsedTime = time.Now()
-		clientUpdate.Unlock()
 	}
-	clientUpdate.Lock()
-	client.LastResponseStatus = 200
-	client.LastUsedTime = time.Now()
-	clientUpdate.Unlock()
+	b.record(ctx, client, start, err)
 	submissionError <- err
 }
@@ -3,7 +3,6 @@ package beaconinterface
 import (
 	"context"
 	"errors"
-	"sort"
 	"sync"
 	"time"
 	"fmt"
@@ -20,32 +19,44 @@ import (
 const NODE_TIMEOUT = 2 * time.Second
 // If node cant deliver within 2s then it is too slow as need to gaurantee bid window of 2s
 
+// NodeOptions controls the requests to every beacon node, calls take at
+// most NODE_TIMEOUT unless Timeout is set
+type NodeOptions = beaconClient.ClientOptions
+
 
 type BeaconClient struct {
-	Node               beaconClient.BeaconClientInstance
-	NodeSpeed          time.Duration
-	LastUsedTime       time.Time
-	LastResponseStatus int
-	SyncStatus         *beaconTypes.SyncStatusData
+	Node beaconClient.BeaconClientInstance
 }
 
 type MultiBeaconClient struct {
+	// Clients keep the order of the beacon urls, see NodeScores for the
+	// order they are tried in
 	Clients      []BeaconClient
 	clientUpdate sync.Mutex
+	scores       map[string]*NodeScore
 	BeaconData   *beaconData.BeaconData
+	nodeTimeout  time.Duration
+
+	listenersMu sync.Mutex
+	listeners   beaconListeners
 }
 
-func NewMultiBeaconClient(beaconUrls []string) (*MultiBeaconClient, error) {
+func NewMultiBeaconClient(beaconUrls []string, options NodeOptions) (*MultiBeaconClient, error) {
 	/*
 		The multi beacon client is a wrapper around multiple beacon clients.
 		It is used to fetch and post data to multiple clients for best availability.
-		The multi beacon client will also keep track of the last used time and response
-		status of each client, and will use the client with the best status.
+		The multi beacon client will also keep a score of the latency and errors
+		of each client, and will try the synced client with the best score first.
 		Data is kept centralized in the BeaconData struct, which is shared between all clients.
+		Every call to a node is bounded by options.Timeout, retries included.
 	*/
+	if options.Timeout <= 0 {
+		options.Timeout = NODE_TIMEOUT
+	}
+
 	clients := make([]BeaconClient, len(beaconUrls))
 	for i, url := range beaconUrls {
-		client, err := beaconClient.NewBeaconClient(url)
+		client, err := beaconClient.NewBeaconClient(url, options)
 		if err != nil {
 			log.Error("failed to create beacon client", "err", err)
 			return nil, err
@@ -53,31 +64,33 @@ func NewMultiBeaconClient(beaconUrls []string) (*MultiBeaconClient, error) {
 		clients[i] = BeaconClient{Node: client}
 	}
 
-	return &MultiBeaconClient{Clients: clients, BeaconData: &beaconData.BeaconData{
+	return &MultiBeaconClient{Clients: clients, scores: make(map[string]*NodeScore), nodeTimeout: options.Timeout, BeaconData: &beaconData.BeaconData{
 		SlotProposerMap:          make(beaconData.SlotProposerMap),
 		SlotPayloadAttributesMap: make(beaconData.SlotPayloadAttributesMap),
 		RandaoMap:                make(beaconData.RandaoMap),
 		AllValidatorsByPubkey:    make(beaconData.AllValidatorsByPubkeyMap),
 		AllValidatorsByIndex:     make(beaconData.AllValidatorsByIndexMap),
-		HeadSlotC:                make(chan beaconTypes.HeadEventData, 64),
+		ReplacedHeads:            make(beaconData.ReplacedHeadsMap),
+		ChainEventC:              make(chan beaconData.ChainEvent, 64),
 		PayloadAttributesC:       make(chan beaconTypes.PayloadAttributesEvent, 64),
 	}}, nil
 }
 
-func (b *MultiBeaconClient) Start() {
+func (b *MultiBeaconClient) Start(ctx context.Context) {
 	/*
 		This function starts the multi beacon client by waiting for at least one client to be synced,
-		subscribing to head events and payload attributes events, and running them in separate goroutines.
+		subscribing to chain events and payload attributes events, and running them in separate goroutines.
 		The `waitSynced()` function waits for at least one client to be synced by periodically calling the
 		`SyncStatus()` function until a synced client is found. Once a synced client is found, the function
-		subscribes to head events and payload attributes events using the `SubscribeToHeadEvents()` and
+		subscribes to head, reorg and finalized checkpoint events and payload attributes events using the `SubscribeToChainEvents()` and
 		`SubscribeToPayloadAttributesEvents()` functions, respectively. These events are run in separate
 		goroutines to allow for concurrent processing.
+		All of them stop once ctx is cancelled.
 	*/
 	
 	// Ensure all nodes are alive
 	log.Info("Ensuring all clients are alive")
-	alive, err := b.ensureClientsAlive()
+	alive, err := b.ensureClientsAlive(ctx)
 	if err != nil || !alive {
 		log.Error("issue with connected beacon nodes to builder", "err", err)
 		return
@@ -85,14 +98,17 @@ func (b *MultiBeaconClient) Start() {
 	log.Info("All clients are alive")
 
 	log.Info("Waiting for at least one client to be synced")
-	b.waitSynced()
-	log.Info("At least one client is synced, starting head and payload attributes subscriptions")
+	if !b.waitSynced(ctx) {
+		log.Info("Stopped waiting for a synced client, context cancelled")
+		return
+	}
+	log.Info("At least one client is synced, starting chain and payload attributes subscriptions")
 
-	go b.SubscribeToHeadEvents(context.Background(), b.BeaconData.HeadSlotC)
-	go b.SubscribeToPayloadAttributesEvents(context.Background(), b.BeaconData.PayloadAttributesC)
+	go b.SubscribeToChainEvents(ctx, b.BeaconData.ChainEventC)
+	go b.SubscribeToPayloadAttributesEvents(ctx, b.BeaconData.PayloadAttributesC)
 }
 
-func (b *MultiBeaconClient) ensureClientsAlive() (bool, error) {
+func (b *MultiBeaconClient) ensureClientsAlive(ctx context.Context) (bool, error) {
 
 	type clientResponse struct {
 		beaconUrl string
@@ -104,7 +120,9 @@ func (b *MultiBeaconClient) ensureClientsAlive() (bool, error) {
 	// Iterate over the clients and check their genesis data asynchronously.
 	for _, client := range b.Clients {
 		go func(c BeaconClient) {
-			genesis, err := c.Node.Genesis()
+			start := time.Now()
+			genesis, err := c.Node.Genesis(ctx)
+			b.record(ctx, c, start, err)
 			if err != nil {
 				log.Error("failed to get genesis", "err", err, "endpoint", c.Node.BaseEndpoint())
 				results <- nil
@@ -114,10 +132,10 @@ func (b *MultiBeaconClient) ensureClientsAlive() (bool, error) {
 		}(client)
 	}
 
-	// Wait for results with a timeout of 2 seconds.
+	// Wait for results with a timeout of 2 seconds (the node timeout).
 	// If node cant deliver within 2s then it is too slow
 	// for block building as the bid window is 2s
-	timeout := time.After(NODE_TIMEOUT)
+	timeout := time.After(b.nodeTimeout)
 	var receivedGenesisData map[string]*beaconTypes.GenesisData = make(map[string]*beaconTypes.GenesisData)
 
 	for i := 0; i < len(b.Clients); i++ {
@@ -158,24 +176,29 @@ func (b *MultiBeaconClient) ensureClientsAlive() (bool, error) {
 }
 
 
-func (b *MultiBeaconClient) waitSynced() {
+func (b *MultiBeaconClient) waitSynced(ctx context.Context) bool {
 	// wait for at least one client to be synced, call the sync status endpoint periodically till one is synced
+	// returns false if ctx is cancelled before that happens
 	for {
-		syncStatus, _ := b.SyncStatus()
+		syncStatus, _ := b.SyncStatus(ctx)
 		log.Info("sync status", "syncStatus", syncStatus)
 		if syncStatus != nil && !syncStatus.IsSyncing {
-			return
+			return true
 		}
 		log.Info("Waiting for at least one client to be synced")
-		time.Sleep(5 * time.Second)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(5 * time.Second):
+		}
 	}
 
 }
 
-func (b *MultiBeaconClient) UpdateKnownValidators(slot uint64) {
+func (b *MultiBeaconClient) UpdateKnownValidators(ctx context.Context, slot uint64) {
 
 	// Get all ethereum validators as of the current slot
-	validatorList, err := b.GetValidatorList(slot)
+	validatorList, err := b.GetValidatorList(ctx, slot)
 	if err != nil {
 		log.Error("failed to get validator list", "err", err)
 		return
@@ -195,13 +218,13 @@ func (b *MultiBeaconClient) UpdateKnownValidators(slot uint64) {
 
 }
 
-func (b *MultiBeaconClient) UpdateRandaoMap(slot uint64) {
+func (b *MultiBeaconClient) UpdateRandaoMap(ctx context.Context, slot uint64) {
 	/*
 		Update the randao map with the randao for the given slot.
 	*/
 
 	// Get the randao for the slot
-	randao, err := b.Randao(slot)
+	randao, err := b.Randao(ctx, slot)
 	if err != nil {
 		return
 	}
@@ -251,7 +274,7 @@ func (b *MultiBeaconClient) RetrieveValidatorByPubkey(validatorPubkey string) (*
 	return &validator, nil
 }
 
-func (b *MultiBeaconClient) UpdateValidatorMap() {
+func (b *MultiBeaconClient) UpdateValidatorMap(ctx context.Context) {
 	/*
 		Update the proposer duties by getting the available proposers
 		for the current epoch and the next epoch, in case some clients are
@@ -269,20 +292,20 @@ func (b *MultiBeaconClient) UpdateValidatorMap() {
 			b.BeaconData.Mu.Unlock()
 			currentEpoch := currentSlot / 32
 
-			b.updateValidatorMap(client, currentEpoch)
+			b.updateValidatorMap(ctx, client, currentEpoch)
 
 			// update the next epoch as well
-			b.updateValidatorMap(client, currentEpoch+1)
+			b.updateValidatorMap(ctx, client, currentEpoch+1)
 
 		}(client)
 	}
 	wg.Wait()
 }
 
-func (b *MultiBeaconClient) UpdateForkVersion() {
+func (b *MultiBeaconClient) UpdateForkVersion(ctx context.Context) {
 
 	// Get the fork version for the current head
-	forkName, forkVersion, err := b.GetForkVersion(0, true)
+	forkName, forkVersion, err := b.GetForkVersion(ctx, 0, true)
 	if err != nil {
 		log.Warn("failed to get latest fork version", "err", err)
 		return
@@ -298,28 +321,21 @@ func (b *MultiBeaconClient) UpdateForkVersion() {
 
 }
 
-func (b *MultiBeaconClient) updateValidatorMap(client BeaconClient, epoch uint64) {
+func (b *MultiBeaconClient) updateValidatorMap(ctx context.Context, client BeaconClient, epoch uint64) {
 	/*
 		Update the validator map for the given epoch.
 		This gets the proposer duties for the given epoch from the client
 		and updates the slot proposer map with the proposer duties.
 		(slot to proposer)
 	*/
-	validatorMap, err := client.Node.GetSlotProposerMap(epoch)
+	start := time.Now()
+	validatorMap, err := client.Node.GetSlotProposerMap(ctx, epoch)
+	b.record(ctx, client, start, err)
 	if err != nil {
 		log.Warn("failed to get validator map", "err", err, "endpoint", client.Node.BaseEndpoint())
-		b.clientUpdate.Lock()
-		client.LastResponseStatus = 500
-		client.LastUsedTime = time.Now()
-		b.clientUpdate.Unlock()
 		return
 	}
 
-	b.clientUpdate.Lock()
-	client.LastResponseStatus = 200
-	client.LastUsedTime = time.Now()
-	b.clientUpdate.Unlock()
-
 	b.BeaconData.Mu.Lock()
 	for k, v := range validatorMap {
 		b.BeaconData.SlotProposerMap[k] = v
@@ -328,7 +344,7 @@ func (b *MultiBeaconClient) updateValidatorMap(client BeaconClient, epoch uint64
 
 }
 
-func (b *MultiBeaconClient) GetSlotProposer(requestedSlot uint64) (*beaconTypes.ProposerDutyData, error) {
+func (b *MultiBeaconClient) GetSlotProposer(ctx context.Context, requestedSlot uint64) (*beaconTypes.ProposerDutyData, error) {
 	/*
 		Get the proposer for the given slot.
 		This checks the slot proposer map for the given slot and returns
@@ -343,7 +359,7 @@ func (b *MultiBeaconClient) GetSlotProposer(requestedSlot uint64) (*beaconTypes.
 	b.BeaconData.Mu.Unlock()
 	if !found {
 		log.Warn("inconsistent proposer mapping", "requestSlot", requestedSlot)
-		proposerMap, err := b.GetSlotProposerMap(requestedSlot / 32)
+		proposerMap, err := b.GetSlotProposerMap(ctx, requestedSlot / 32)
 		if err != nil {
 			return nil, err
 		}
@@ -378,7 +394,7 @@ func (b *MultiBeaconClient) GetCurrentHead() (beaconTypes.HeadEventData, error)
 	return data, nil
 }
 
-func (b *MultiBeaconClient) GetPayloadAttributesForSlot(requestedSlot uint64) (*beaconTypes.PayloadAttributesEventData, error) {
+func (b *MultiBeaconClient) GetPayloadAttributesForSlot(ctx context.Context, requestedSlot uint64) (*beaconTypes.PayloadAttributesEventData, error) {
 	/*
 		Get the payload attributes for the given slot.
 		This checks the slot payload attributes map for the given slot and
@@ -396,25 +412,25 @@ func (b *MultiBeaconClient) GetPayloadAttributesForSlot(requestedSlot uint64) (*
 		log.Warn("inconsistent payload attributes mapping", "requestSlot", requestedSlot)
 
 		// Get the proposer for the slot
-		proposer, err := b.GetSlotProposer(requestedSlot)
+		proposer, err := b.GetSlotProposer(ctx, requestedSlot)
 		if err != nil {
 			return nil, err
 		}
 
 		// Get the parent block for the slot
-		parentBlockHeader, err := b.GetBlockHeader(requestedSlot - 1)
+		parentBlockHeader, err := b.GetBlockHeader(ctx, requestedSlot - 1)
 		if err != nil {
 			return nil, err
 		}
 
 		// Get the previous randao
-		previousRandao, err := b.Randao(requestedSlot - 1)
+		previousRandao, err := b.Randao(ctx, requestedSlot - 1)
 		if err != nil {
 			return nil, err
 		}
 
 		// Get withdrawals of this slot
-		withdrawals, err := b.GetWithdrawals(requestedSlot)
+		withdrawals, err := b.GetWithdrawals(ctx, requestedSlot)
 		if err != nil {
 			return nil, err
 		}
@@ -451,72 +467,57 @@ func (b *MultiBeaconClient) GetPayloadAttributesForSlot(requestedSlot uint64) (*
 	return &payloadAttributes, nil
 }
 
-func (b *MultiBeaconClient) SyncStatus() (*beaconTypes.SyncStatusData, error) {
+func (b *MultiBeaconClient) SyncStatus(ctx context.Context) (*beaconTypes.SyncStatusData, error) {
 	/*
 		Get the sync status of the best performing client.
-		All clients are checked for sync status and the best performing
-		client is used to get the sync status.
+		All clients are checked for sync status, which also decides which
+		clients are synced and at the head, and the sync status of the
+		client that is tried first is returned.
 	*/
-	var foundSyncedNode bool
-
 	var wg sync.WaitGroup
-	for i, instance := range b.Clients {
+	for _, instance := range b.Clients {
 		wg.Add(1)
-		go func(client BeaconClient, index int) {
+		go func(client BeaconClient) {
 			defer wg.Done()
 
-			startTime := time.Now()
-			syncStatus, err := client.Node.SyncStatus()
-			endTime := time.Now()
+			start := time.Now()
+			syncStatus, err := client.Node.SyncStatus(ctx)
+			b.record(ctx, client, start, err)
 			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
 				log.Error("failed to get sync status", "err", err, "endpoint", client.Node.BaseEndpoint())
-				b.clientUpdate.Lock()
-				client.LastResponseStatus = 500
-				client.LastUsedTime = time.Now()
-				client.NodeSpeed = endTime.Sub(startTime)
-				b.Clients[index] = client
-				b.clientUpdate.Unlock()
-				return
-			}
-
-			b.clientUpdate.Lock()
-			client.LastResponseStatus = 200
-			client.LastUsedTime = time.Now()
-			client.SyncStatus = syncStatus
-			client.NodeSpeed = endTime.Sub(startTime)
-			b.Clients[index] = client
-			b.clientUpdate.Unlock()
-
-			if !syncStatus.IsSyncing && !foundSyncedNode {
-				foundSyncedNode = true
+				syncStatus = nil
 			}
+			b.setSyncStatus(client, syncStatus)
 
-		}(instance, i)
+		}(instance)
 	}
 
 	wg.Wait()
 
-	// Sync status, after `waitSynced`, is a function that is called asynchronously on every head event
-	// This is to ensure that the sync status is always up to date
-	// Since this is the only function that is always called asynchronously, we can use it as the main performance updater
-	// This is the point that node speed is updated, as it is the only functino that has a uniform payload/response across all nodes
-	b.updateClientPerformance()
-
-	if b.Clients[0].SyncStatus == nil {
+	scores := b.NodeScores()
+	if len(scores) == 0 || scores[0].syncStatus == nil {
 		return nil, errors.New("all beacon nodes failed")
 	}
 
-	return b.Clients[0].SyncStatus, nil
+	return scores[0].syncStatus, nil
 }
 
-func (b *MultiBeaconClient) postBeaconCall() {
+func (b *MultiBeaconClient) SyncedClients() (synced []string, unsynced []string) {
 	/*
-		The `postBeaconCall()` function is used to update the performance of each beacon client in
-		the `MultiBeaconClient` struct. It is called after every beacon call to ensure that the
-		performance of each client is up to date. The function acquires a lock on the `clientUpdate`
-		mutex to ensure that the clients are not updated concurrently.
+		Split the node URLs by their last known sync status.
+		Nodes that didn't answer the last sync status request count as unsynced.
 	*/
-	go b.updateClientPerformance()
+	for _, score := range b.NodeScores() {
+		if score.Synced {
+			synced = append(synced, score.Endpoint)
+		} else {
+			unsynced = append(unsynced, score.Endpoint)
+		}
+	}
+	return synced, unsynced
 }
 
 func (b *MultiBeaconClient) ReturnAllNodeURLs() []string {
@@ -528,41 +529,3 @@ func (b *MultiBeaconClient) ReturnAllNodeURLs() []string {
 
 	return urls
 }
-
-func (b *MultiBeaconClient) updateClientPerformance() {
-	/*
-		The `updateClientPerformance()` function is used to update the performance of each beacon client in
-		the `MultiBeaconClient` struct. It sorts the clients based on their sync status, last response
-		status, node speed, and last used time. The function acquires a lock on the `clientUpdate` mutex to
-		ensure that the clients are not updated concurrently. The sorted clients are then stored back in the
-		`MultiBeaconClient` struct.
-	*/
-	clients := b.Clients
-
-	b.clientUpdate.Lock()
-	/*
-		The clients are sorted based on the following criteria:
-		1. Sync status: Syncing clients are prioritized over non-syncing clients
-		2. Head slot: Clients with a higher head slot are prioritized over clients with a lower head slot
-		3. Last response status: Clients with a 200 response status are prioritized over clients with a 500 response status
-		4. Node speed: Clients with a faster response time are prioritized over clients with a slower response time
-		5. Last used time: Clients that have been used more recently are prioritized over clients that have not been used recently
-	*/
-	sort.SliceStable(clients, func(i, j int) bool {
-		if clients[i].SyncStatus.IsSyncing == clients[j].SyncStatus.IsSyncing {
-			if clients[i].SyncStatus.HeadSlot == clients[j].SyncStatus.HeadSlot {
-				if clients[i].LastResponseStatus == clients[j].LastResponseStatus {
-					if clients[i].NodeSpeed == clients[j].NodeSpeed {
-						return clients[i].LastUsedTime.After(clients[j].LastUsedTime)
-					}
-					return clients[i].NodeSpeed < clients[j].NodeSpeed
-				}
-				return clients[i].LastResponseStatus < clients[j].LastResponseStatus
-			}
-			return clients[i].SyncStatus.HeadSlot > clients[j].SyncStatus.HeadSlot
-		}
-		return clients[i].SyncStatus.IsSyncing
-	})
-	b.clientUpdate.Unlock()
-
-}
@@ -2,86 +2,213 @@ package beaconinterface
 
 import (
 	"context"
-	
+
 	beaconTypes "github.com/bsn-eng/pon-golang-types/beaconclient"
 	"github.com/ethereum/go-ethereum/log"
+
+	beaconData "github.com/pon-pbs/bbRelay/beaconinterface/data"
 )
 
-func (b *MultiBeaconClient) SubscribeToHeadEvents(ctx context.Context, headChannel chan beaconTypes.HeadEventData) {
+// Slots the chain events and replaced heads are remembered for
+const chainEventsSlots = 64
+
+// headKey identifies a head every node announces
+type headKey struct {
+	slot uint64
+	root string
+}
+
+func (b *MultiBeaconClient) SubscribeToChainEvents(ctx context.Context, chainEventsC chan beaconData.ChainEvent) {
 	/*
-		Subscribe to head events using all clients
+		Subscribe to head, reorg and finalized checkpoint events using all clients
 		No penalty for multiple subscriptions
 		Increased reliability in case of some clients being down
+		Every node announces the same head, a head is processed once per (slot, block root)
+		Nodes may disagree on the head of a slot, a reorg is only taken from a chain_reorg
+		event or from a node replacing its own head of the slot
 	*/
 	for _, client := range b.Clients {
-		go client.Node.SubscribeToHeadEvents(ctx, headChannel)
+		go client.Node.SubscribeToChainEvents(ctx, chainEventsC)
 	}
 
+	seenHeads := make(map[headKey]struct{})
+	nodeHeads := make(map[string]beaconTypes.HeadEventData)
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case slotHead := <-headChannel:
-			log.Info("Received head event", "slot", slotHead.Slot, "blockHash", slotHead.Block)
-			// check if head is already processed from another client
-			b.BeaconData.Mu.Lock()
-			currentSlot := b.BeaconData.CurrentSlot
-			b.BeaconData.Mu.Unlock()
-
-			if slotHead.Slot <= currentSlot {
-				// head already processed, do not process/contaminate the data
-				continue
-			}
-
-			b.BeaconData.Mu.Lock()
-			b.BeaconData.CurrentHead = slotHead
-			b.BeaconData.CurrentSlot = slotHead.Slot
-			b.BeaconData.CurrentEpoch = slotHead.Slot / 32
-			b.BeaconData.Mu.Unlock()
-			go b.SyncStatus()
+		case event := <-chainEventsC:
+			switch {
+			case event.Head != nil:
+				slotHead := *event.Head
+				nodeHead := nodeHeads[event.Node]
+				nodeHeads[event.Node] = slotHead
+				if nodeHead.Slot == slotHead.Slot && nodeHead.Block != slotHead.Block && nodeHead.Block != "" {
+					// the node switched to another block of the slot
+					b.processReorg(beaconData.ReorgEventData{
+						Slot:         slotHead.Slot,
+						Depth:        1,
+						OldHeadBlock: nodeHead.Block,
+						NewHeadBlock: slotHead.Block,
+						OldHeadState: nodeHead.State,
+						NewHeadState: slotHead.State,
+						Epoch:        slotHead.Slot / 32,
+					})
+				}
 
-			// Attempt to get the randao for this slot, the previous slot and the next slot
-			for i := uint64(0); i < 3; i++ {
-				// current slot -1, current slot, current slot +1
-				go b.UpdateRandaoMap(slotHead.Slot - 1 + i)
-			}
+				key := headKey{slotHead.Slot, slotHead.Block}
+				if _, seen := seenHeads[key]; seen {
+					// a replaced head announced again is head again
+					b.BeaconData.Mu.Lock()
+					_, replaced := b.BeaconData.ReplacedHeads[slotHead.Block]
+					b.BeaconData.Mu.Unlock()
+					if !replaced {
+						continue
+					}
+				}
+				seenHeads[key] = struct{}{}
+				for seenHead := range seenHeads {
+					if seenHead.slot+chainEventsSlots < slotHead.Slot {
+						delete(seenHeads, seenHead)
+					}
+				}
 
-			// update proposer map
-			// check if the current slot is at the edge of an epoch either behind or just infront
-			// if so update the proposer map
-			currentSlot = slotHead.Slot
-			currentEpoch := currentSlot / 32
-
-			if (currentSlot+1)/32 != currentEpoch || (currentSlot-1)/32 != currentEpoch {
-				// We are at the edge of an epoch, update the proposer map
-				// currentSolot+1 is the first slot of the next epoch means head at the end of the current epoch
-				// currentSlot-1 is the last slot of the previous epoch means head at the start of the current epoch
-				go b.UpdateValidatorMap()
-			}
+				b.processHead(ctx, slotHead)
 
-			go b.UpdateForkVersion()
+			case event.Reorg != nil:
+				b.processReorg(*event.Reorg)
 
-			// Clean up the proposer map for slots that are older than 2 epochs
-			// This is to prevent the map from growing too large
-			// We only need to keep the proposer map for the current epoch and the next epoch
-			// as we only need to know the proposers for the current epoch and the next epoch
-			// to be able to verify the signature of the block
-			b.BeaconData.Mu.Lock()
-			for k := range b.BeaconData.SlotProposerMap {
-				if int64(k) < int64(currentSlot)-64 {
-					delete(b.BeaconData.SlotProposerMap, k)
+			case event.Finalized != nil:
+				b.BeaconData.Mu.Lock()
+				newCheckpoint := event.Finalized.Epoch > b.BeaconData.FinalizedCheckpoint.Epoch
+				if newCheckpoint {
+					b.BeaconData.FinalizedCheckpoint = *event.Finalized
 				}
-			}
-			for k := range b.BeaconData.RandaoMap {
-				if int64(k) < int64(currentSlot)-64 {
-					delete(b.BeaconData.RandaoMap, k)
+				b.BeaconData.Mu.Unlock()
+				if newCheckpoint {
+					log.Info("Received finalized checkpoint", "epoch", event.Finalized.Epoch, "block", event.Finalized.Block)
 				}
 			}
-			b.BeaconData.Mu.Unlock()
+		}
+	}
+
+}
+
+func (b *MultiBeaconClient) processHead(ctx context.Context, slotHead beaconTypes.HeadEventData) {
+	/*
+		Update the beacon data with a new head.
+		Heads of older slots come from nodes behind and are skipped,
+		another head of the current slot replaces the current head.
+		A replaced head becoming head again is no longer replaced.
+	*/
+	b.BeaconData.Mu.Lock()
+	currentHead := b.BeaconData.CurrentHead
+	b.BeaconData.Mu.Unlock()
+
+	if slotHead.Slot < currentHead.Slot {
+		// head of a node behind, do not process/contaminate the data
+		return
+	}
+	log.Info("Received head event", "slot", slotHead.Slot, "blockHash", slotHead.Block)
+
+	b.BeaconData.Mu.Lock()
+	delete(b.BeaconData.ReplacedHeads, slotHead.Block)
+	b.BeaconData.CurrentHead = slotHead
+	b.BeaconData.CurrentSlot = slotHead.Slot
+	b.BeaconData.CurrentEpoch = slotHead.Slot / 32
+	b.BeaconData.Mu.Unlock()
+	go b.SyncStatus(ctx)
 
+	// Attempt to get the randao for this slot, the previous slot and the next slot
+	for i := uint64(0); i < 3; i++ {
+		// current slot -1, current slot, current slot +1
+		go b.UpdateRandaoMap(ctx, slotHead.Slot - 1 + i)
+	}
+
+	// update proposer map
+	// check if the current slot is at the edge of an epoch either behind or just infront
+	// if so update the proposer map
+	currentSlot := slotHead.Slot
+	currentEpoch := currentSlot / 32
+
+	if (currentSlot+1)/32 != currentEpoch || (currentSlot-1)/32 != currentEpoch {
+		// We are at the edge of an epoch, update the proposer map
+		// currentSolot+1 is the first slot of the next epoch means head at the end of the current epoch
+		// currentSlot-1 is the last slot of the previous epoch means head at the start of the current epoch
+		go b.UpdateValidatorMap(ctx)
+	}
+
+	go b.UpdateForkVersion(ctx)
+
+	// Clean up the proposer map for slots that are older than 2 epochs
+	// This is to prevent the map from growing too large
+	// We only need to keep the proposer map for the current epoch and the next epoch
+	// as we only need to know the proposers for the current epoch and the next epoch
+	// to be able to verify the signature of the block
+	b.BeaconData.Mu.Lock()
+	for k := range b.BeaconData.SlotProposerMap {
+		if int64(k) < int64(currentSlot)-64 {
+			delete(b.BeaconData.SlotProposerMap, k)
+		}
+	}
+	for k := range b.BeaconData.RandaoMap {
+		if int64(k) < int64(currentSlot)-64 {
+			delete(b.BeaconData.RandaoMap, k)
 		}
 	}
+	for root, slot := range b.BeaconData.ReplacedHeads {
+		if slot+chainEventsSlots < currentSlot {
+			delete(b.BeaconData.ReplacedHeads, root)
+		}
+	}
+	b.BeaconData.Mu.Unlock()
 
+	b.notifyHead(slotHead)
+}
+
+func (b *MultiBeaconClient) processReorg(reorg beaconData.ReorgEventData) {
+	/*
+		Drop the payload attributes built on the replaced head and tell the
+		listeners, so auctions on the replaced head are closed.
+		Every node announces the same reorg, a replaced head is processed once.
+	*/
+	b.BeaconData.Mu.Lock()
+	if _, replaced := b.BeaconData.ReplacedHeads[reorg.OldHeadBlock]; replaced || reorg.OldHeadBlock == "" {
+		b.BeaconData.Mu.Unlock()
+		return
+	}
+	b.BeaconData.ReplacedHeads[reorg.OldHeadBlock] = reorg.Slot
+	// a reorg back to a replaced head
+	delete(b.BeaconData.ReplacedHeads, reorg.NewHeadBlock)
+
+	var invalidated []beaconTypes.PayloadAttributesEventData
+	for slot, attrs := range b.BeaconData.SlotPayloadAttributesMap {
+		if attrs.ParentBlockRoot == reorg.OldHeadBlock {
+			invalidated = append(invalidated, attrs)
+			delete(b.BeaconData.SlotPayloadAttributesMap, slot)
+		}
+	}
+
+	if reorg.Slot >= b.BeaconData.CurrentHead.Slot {
+		b.BeaconData.CurrentHead = beaconTypes.HeadEventData{
+			Slot:  reorg.Slot,
+			Block: reorg.NewHeadBlock,
+			State: reorg.NewHeadState,
+		}
+		b.BeaconData.CurrentSlot = reorg.Slot
+		b.BeaconData.CurrentEpoch = reorg.Slot / 32
+	}
+	b.BeaconData.Mu.Unlock()
+
+	log.Warn("Received chain reorg",
+		"slot", reorg.Slot,
+		"depth", reorg.Depth,
+		"oldHead", reorg.OldHeadBlock,
+		"newHead", reorg.NewHeadBlock,
+		"invalidatedPayloadAttributes", len(invalidated))
+
+	b.notifyReorg(Reorg{ReorgEventData: reorg, Invalidated: invalidated})
 }
 
 func (b *MultiBeaconClient) SubscribeToPayloadAttributesEvents(ctx context.Context, attrsC chan beaconTypes.PayloadAttributesEvent) {
@@ -89,6 +216,7 @@ func (b *MultiBeaconClient) SubscribeToPayloadAttributesEvents(ctx context.Conte
 		Subscribe to payload attributes events using all clients
 		No penalty for multiple subscriptions
 		Increased reliability in case of some clients being down
+		Attributes built on a head replaced by a reorg come from nodes behind and are skipped
 	*/
 	for _, client := range b.Clients {
 		go client.Node.SubscribeToPayloadAttributesEvents(ctx, attrsC)
@@ -96,12 +224,21 @@ func (b *MultiBeaconClient) SubscribeToPayloadAttributesEvents(ctx context.Conte
 
 	for {
 		select {
+		case <-ctx.Done():
+			return
 		case payloadAttrs := <-attrsC:
 
+			b.BeaconData.Mu.Lock()
+			_, replaced := b.BeaconData.ReplacedHeads[payloadAttrs.Data.ParentBlockRoot]
+			b.BeaconData.Mu.Unlock()
+			if replaced {
+				continue
+			}
+
 			log.Info("Received payload attributes event",
-			"forkversion", payloadAttrs.Version, 
-			"slot", payloadAttrs.Data.ProposalSlot, 
-			"withdrawals", len(payloadAttrs.Data.PayloadAttributes.Withdrawals), 
+			"forkversion", payloadAttrs.Version,
+			"slot", payloadAttrs.Data.ProposalSlot,
+			"withdrawals", len(payloadAttrs.Data.PayloadAttributes.Withdrawals),
 			"proposer_index", payloadAttrs.Data.ProposerIndex)
 
 			b.BeaconData.Mu.Lock()
@@ -117,7 +254,8 @@ func (b *MultiBeaconClient) SubscribeToPayloadAttributesEvents(ctx context.Conte
 			}
 			b.BeaconData.Mu.Unlock()
 
+			b.notifyPayloadAttributes(*payloadAttrs.Data)
 		}
 	}
 
-}
\ No newline at end of file
+}
@@ -0,0 +1,189 @@
+package beaconinterface
+
+import (
+	"context"
+	"testing"
+
+	beaconTypes "github.com/bsn-eng/pon-golang-types/beaconclient"
+
+	beaconData "github.com/pon-pbs/bbRelay/beaconinterface/data"
+)
+
+// chainEvents runs the chain events subscription of fake nodes a and b,
+// returning the channel the test sends their events on and the heads and
+// reorgs the listeners got
+type chainEvents struct {
+	b      *MultiBeaconClient
+	events chan beaconData.ChainEvent
+	heads  chan beaconTypes.HeadEventData
+	reorgs chan Reorg
+}
+
+func newChainEvents(t *testing.T) *chainEvents {
+	t.Helper()
+	b := newTestMultiClient(newFakeNode("a", 0, 0, false), newFakeNode("b", 0, 0, false))
+	b.BeaconData = &beaconData.BeaconData{
+		SlotProposerMap:          make(beaconData.SlotProposerMap),
+		SlotPayloadAttributesMap: make(beaconData.SlotPayloadAttributesMap),
+		RandaoMap:                make(beaconData.RandaoMap),
+		ReplacedHeads:            make(beaconData.ReplacedHeadsMap),
+	}
+	chain := &chainEvents{
+		b:      b,
+		events: make(chan beaconData.ChainEvent),
+		heads:  make(chan beaconTypes.HeadEventData, 16),
+		reorgs: make(chan Reorg, 16),
+	}
+	b.OnHead(func(head beaconTypes.HeadEventData) { chain.heads <- head })
+	b.OnReorg(func(reorg Reorg) { chain.reorgs <- reorg })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go b.SubscribeToChainEvents(ctx, chain.events)
+	return chain
+}
+
+func (chain *chainEvents) head(node string, slot uint64, block string) {
+	chain.events <- beaconData.ChainEvent{Node: node, Head: &beaconTypes.HeadEventData{Slot: slot, Block: block}}
+}
+
+// sync waits until every event sent is processed, the second finalized
+// checkpoint is only taken once the first is handled
+func (chain *chainEvents) sync() {
+	chain.events <- beaconData.ChainEvent{Finalized: &beaconData.FinalizedCheckpointEventData{}}
+	chain.events <- beaconData.ChainEvent{Finalized: &beaconData.FinalizedCheckpointEventData{}}
+}
+
+func (chain *chainEvents) replaced(block string) bool {
+	chain.b.BeaconData.Mu.Lock()
+	defer chain.b.BeaconData.Mu.Unlock()
+	_, replaced := chain.b.BeaconData.ReplacedHeads[block]
+	return replaced
+}
+
+func drain[T any](ch chan T) []T {
+	var values []T
+	for {
+		select {
+		case value := <-ch:
+			values = append(values, value)
+		default:
+			return values
+		}
+	}
+}
+
+func TestChainEventsDedupHeads(t *testing.T) {
+	chain := newChainEvents(t)
+
+	chain.head("a", 10, "0x10")
+	chain.head("b", 10, "0x10")
+	chain.head("a", 11, "0x11")
+	chain.head("b", 11, "0x11")
+	// a node behind
+	chain.head("b", 9, "0x09")
+	chain.sync()
+
+	heads := drain(chain.heads)
+	if len(heads) != 2 || heads[0].Block != "0x10" || heads[1].Block != "0x11" {
+		t.Fatalf("heads %+v, want each head once", heads)
+	}
+	if reorgs := drain(chain.reorgs); len(reorgs) != 0 {
+		t.Fatalf("reorgs %+v", reorgs)
+	}
+}
+
+func TestChainEventsNodesDisagree(t *testing.T) {
+	chain := newChainEvents(t)
+	chain.b.BeaconData.SlotPayloadAttributesMap[11] = beaconTypes.PayloadAttributesEventData{ProposalSlot: 11, ParentBlockRoot: "0x10a"}
+
+	// b hasn't seen a's block of the slot, that is no reorg
+	chain.head("a", 10, "0x10a")
+	chain.head("b", 10, "0x10b")
+	chain.sync()
+
+	if reorgs := drain(chain.reorgs); len(reorgs) != 0 {
+		t.Fatalf("reorgs %+v for nodes disagreeing", reorgs)
+	}
+	if chain.replaced("0x10a") {
+		t.Fatal("head of a replaced")
+	}
+	chain.b.BeaconData.Mu.Lock()
+	_, kept := chain.b.BeaconData.SlotPayloadAttributesMap[11]
+	chain.b.BeaconData.Mu.Unlock()
+	if !kept {
+		t.Fatal("payload attributes on the head of a dropped")
+	}
+}
+
+func TestChainEventsNodeSwitchesHead(t *testing.T) {
+	chain := newChainEvents(t)
+	chain.b.BeaconData.SlotPayloadAttributesMap[11] = beaconTypes.PayloadAttributesEventData{ProposalSlot: 11, ParentBlockRoot: "0x10a"}
+
+	chain.head("a", 10, "0x10a")
+	chain.head("b", 10, "0x10a")
+	chain.head("a", 10, "0x10b")
+	// b switches as well, the reorg is processed once
+	chain.head("b", 10, "0x10b")
+	chain.sync()
+
+	reorgs := drain(chain.reorgs)
+	if len(reorgs) != 1 || reorgs[0].OldHeadBlock != "0x10a" || reorgs[0].NewHeadBlock != "0x10b" {
+		t.Fatalf("reorgs %+v, want 0x10a replaced by 0x10b once", reorgs)
+	}
+	if len(reorgs[0].Invalidated) != 1 || reorgs[0].Invalidated[0].ProposalSlot != 11 {
+		t.Fatalf("invalidated %+v, want the attributes of slot 11", reorgs[0].Invalidated)
+	}
+	if !chain.replaced("0x10a") {
+		t.Fatal("replaced head not remembered")
+	}
+	if head, _ := chain.b.GetCurrentHead(); head.Block != "0x10b" {
+		t.Fatalf("current head %s, want 0x10b", head.Block)
+	}
+
+	// the replaced head comes back
+	chain.head("a", 10, "0x10a")
+	chain.sync()
+	if reorgs := drain(chain.reorgs); len(reorgs) != 1 || reorgs[0].OldHeadBlock != "0x10b" || reorgs[0].NewHeadBlock != "0x10a" {
+		t.Fatalf("reorgs %+v, want 0x10b replaced by 0x10a", reorgs)
+	}
+	if chain.replaced("0x10a") {
+		t.Fatal("head still replaced after becoming head again")
+	}
+}
+
+func TestChainEventsReorgEvent(t *testing.T) {
+	chain := newChainEvents(t)
+	chain.head("a", 10, "0x10a")
+
+	reorg := beaconData.ReorgEventData{Slot: 10, Depth: 1, OldHeadBlock: "0x10a", NewHeadBlock: "0x10b"}
+	for _, node := range []string{"a", "b"} {
+		event := reorg
+		chain.events <- beaconData.ChainEvent{Node: node, Reorg: &event}
+	}
+	// the head event following the reorg event
+	chain.head("a", 10, "0x10b")
+	chain.sync()
+
+	if reorgs := drain(chain.reorgs); len(reorgs) != 1 || reorgs[0].NewHeadBlock != "0x10b" {
+		t.Fatalf("reorgs %+v, want the reorg once", reorgs)
+	}
+	if !chain.replaced("0x10a") {
+		t.Fatal("replaced head not remembered")
+	}
+
+	// a head announced again by a node clears it
+	chain.head("b", 10, "0x10a")
+	chain.sync()
+	if chain.replaced("0x10a") {
+		t.Fatal("head still replaced after becoming head again")
+	}
+
+	// replaced heads are forgotten after chainEventsSlots
+	chain.events <- beaconData.ChainEvent{Node: "a", Reorg: &beaconData.ReorgEventData{Slot: 11, OldHeadBlock: "0x11a", NewHeadBlock: "0x11b"}}
+	chain.head("a", 12+chainEventsSlots, "0x12")
+	chain.sync()
+	if chain.replaced("0x11a") {
+		t.Fatal("old replaced head kept")
+	}
+}
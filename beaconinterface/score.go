@@ -0,0 +1,150 @@
+package beaconinterface
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	beaconTypes "github.com/bsn-eng/pon-golang-types/beaconclient"
+)
+
+// scoreWeight is the weight of the newest call in the moving averages of a node
+const scoreWeight = 0.2
+
+// headSlotTolerance is how many slots a synced node may trail the highest
+// known head before the nodes at the head are tried first
+const headSlotTolerance = 1
+
+// NodeScore tracks how a beacon node answered. Latency and ErrorRate are
+// exponentially weighted moving averages of its calls, Score is the expected
+// cost of a call: the latency plus the node timeout weighted by the error
+// rate. Synced nodes at the head are tried first, by lowest Score.
+type NodeScore struct {
+	Endpoint  string        `json:"endpoint"`
+	Score     time.Duration `json:"score"`
+	Latency   time.Duration `json:"latency"`
+	ErrorRate float64       `json:"error_rate"`
+	Calls     uint64        `json:"calls"`
+	Failures  uint64        `json:"failures"`
+	LastCall  time.Time     `json:"last_call"`
+	LastError string        `json:"last_error,omitempty"`
+	Synced    bool          `json:"synced"`
+	HeadSlot  uint64        `json:"head_slot"`
+	Behind    bool          `json:"behind"`
+
+	// syncStatus is the last answer to a sync status call, nil when the
+	// node didn't answer the last one
+	syncStatus *beaconTypes.SyncStatusData
+}
+
+// NodeScores returns the score of every node in the order they are tried
+func (b *MultiBeaconClient) NodeScores() []NodeScore {
+	_, scores := b.rank()
+	return scores
+}
+
+// ranked returns the clients in the order they are tried
+func (b *MultiBeaconClient) ranked() []BeaconClient {
+	clients, _ := b.rank()
+	return clients
+}
+
+func (b *MultiBeaconClient) rank() ([]BeaconClient, []NodeScore) {
+	b.clientUpdate.Lock()
+	scores := make([]NodeScore, len(b.Clients))
+	for i, client := range b.Clients {
+		scores[i] = *b.nodeScore(client)
+	}
+	b.clientUpdate.Unlock()
+
+	var headSlot uint64
+	for i := range scores {
+		score := &scores[i]
+		score.Score = score.Latency + time.Duration(score.ErrorRate*float64(b.nodeTimeout))
+		if score.Synced && score.HeadSlot > headSlot {
+			headSlot = score.HeadSlot
+		}
+	}
+	for i := range scores {
+		scores[i].Behind = scores[i].HeadSlot+headSlotTolerance < headSlot
+	}
+
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return preferred(scores[order[i]], scores[order[j]])
+	})
+
+	rankedClients := make([]BeaconClient, len(order))
+	rankedScores := make([]NodeScore, len(order))
+	for i, index := range order {
+		rankedClients[i] = b.Clients[index]
+		rankedScores[i] = scores[index]
+	}
+	return rankedClients, rankedScores
+}
+
+// preferred reports whether the node of a is tried before the node of b
+func preferred(a, b NodeScore) bool {
+	if a.Synced != b.Synced {
+		return a.Synced
+	}
+	if a.Behind != b.Behind {
+		return !a.Behind
+	}
+	return a.Score < b.Score
+}
+
+// record adds a call to the score of the node. Calls ended by the caller's
+// context don't count against the node.
+func (b *MultiBeaconClient) record(ctx context.Context, client BeaconClient, start time.Time, err error) {
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	latency := time.Since(start)
+
+	b.clientUpdate.Lock()
+	defer b.clientUpdate.Unlock()
+
+	score := b.nodeScore(client)
+	score.Calls++
+	score.LastCall = time.Now()
+	if err != nil {
+		score.Failures++
+		score.LastError = err.Error()
+		score.ErrorRate = scoreWeight + (1-scoreWeight)*score.ErrorRate
+		return
+	}
+	score.ErrorRate = (1 - scoreWeight) * score.ErrorRate
+	if score.Latency == 0 {
+		score.Latency = latency
+	} else {
+		score.Latency = time.Duration(scoreWeight*float64(latency) + (1-scoreWeight)*float64(score.Latency))
+	}
+}
+
+// setSyncStatus keeps the sync status the node answered, nil if it didn't
+func (b *MultiBeaconClient) setSyncStatus(client BeaconClient, syncStatus *beaconTypes.SyncStatusData) {
+	b.clientUpdate.Lock()
+	defer b.clientUpdate.Unlock()
+
+	score := b.nodeScore(client)
+	score.syncStatus = syncStatus
+	score.Synced = syncStatus != nil && !syncStatus.IsSyncing
+	if syncStatus != nil {
+		score.HeadSlot = syncStatus.HeadSlot
+	}
+}
+
+// nodeScore returns the score of the client, clientUpdate must be held
+func (b *MultiBeaconClient) nodeScore(client BeaconClient) *NodeScore {
+	endpoint := client.Node.BaseEndpoint()
+	score, ok := b.scores[endpoint]
+	if !ok {
+		score = &NodeScore{Endpoint: endpoint}
+		b.scores[endpoint] = score
+	}
+	return score
+}
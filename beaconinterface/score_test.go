@@ -0,0 +1,405 @@
+package beaconinterface
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	beaconTypes "github.com/bsn-eng/pon-golang-types/beaconclient"
+	commonTypes "github.com/bsn-eng/pon-golang-types/common"
+	"github.com/ethereum/go-ethereum/common"
+
+	beaconData "github.com/pon-pbs/bbRelay/beaconinterface/data"
+)
+
+var errFakeNode = errors.New("fake node failed")
+
+// fakeNode answers sync status and validator list calls after latency
+type fakeNode struct {
+	endpoint string
+	latency  time.Duration
+
+	mu         sync.Mutex
+	syncStatus *beaconTypes.SyncStatusData
+	err        error
+	calls      int
+}
+
+func (node *fakeNode) answer(ctx context.Context) error {
+	node.mu.Lock()
+	node.calls++
+	err := node.err
+	node.mu.Unlock()
+
+	select {
+	case <-time.After(node.latency):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return err
+}
+
+func (node *fakeNode) setErr(err error) {
+	node.mu.Lock()
+	node.err = err
+	node.mu.Unlock()
+}
+
+func (node *fakeNode) callCount() int {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.calls
+}
+
+func (node *fakeNode) BaseEndpoint() string { return node.endpoint }
+
+func (node *fakeNode) SyncStatus(ctx context.Context) (*beaconTypes.SyncStatusData, error) {
+	if err := node.answer(ctx); err != nil {
+		return nil, err
+	}
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	syncStatus := *node.syncStatus
+	return &syncStatus, nil
+}
+
+func (node *fakeNode) GetValidatorList(ctx context.Context, slot uint64) ([]*beaconTypes.ValidatorData, error) {
+	if err := node.answer(ctx); err != nil {
+		return nil, err
+	}
+	return []*beaconTypes.ValidatorData{}, nil
+}
+
+func (node *fakeNode) GetSlotProposerMap(context.Context, uint64) (beaconData.SlotProposerMap, error) {
+	return nil, errFakeNode
+}
+
+func (node *fakeNode) GetValidatorIndex(context.Context, []string) (*beaconTypes.GetValidatorsResponse, error) {
+	return nil, errFakeNode
+}
+
+func (node *fakeNode) Genesis(context.Context) (*beaconTypes.GenesisData, error) {
+	return nil, errFakeNode
+}
+
+func (node *fakeNode) GetWithdrawals(context.Context, uint64) (*beaconTypes.Withdrawals, error) {
+	return nil, errFakeNode
+}
+
+func (node *fakeNode) Randao(context.Context, uint64) (*common.Hash, error) {
+	return nil, errFakeNode
+}
+
+func (node *fakeNode) GetBlockHeader(context.Context, uint64) (*beaconTypes.BlockHeaderData, error) {
+	return nil, errFakeNode
+}
+
+func (node *fakeNode) GetCurrentBlockHeader(context.Context) (*beaconTypes.BlockHeaderData, error) {
+	return nil, errFakeNode
+}
+
+func (node *fakeNode) GetExecutionBlockHash(context.Context, string) (common.Hash, error) {
+	return common.Hash{}, errFakeNode
+}
+
+func (node *fakeNode) GetForkVersion(context.Context, uint64, bool) (string, string, error) {
+	return "", "", errFakeNode
+}
+
+func (node *fakeNode) PublishBlock(context.Context, commonTypes.VersionedSignedBeaconBlock) error {
+	return errFakeNode
+}
+
+func (node *fakeNode) SubscribeToChainEvents(ctx context.Context, _ chan beaconData.ChainEvent) {
+	<-ctx.Done()
+}
+
+func (node *fakeNode) SubscribeToPayloadAttributesEvents(ctx context.Context, _ chan beaconTypes.PayloadAttributesEvent) {
+	<-ctx.Done()
+}
+
+func newFakeNode(endpoint string, latency time.Duration, headSlot uint64, syncing bool) *fakeNode {
+	return &fakeNode{
+		endpoint:   endpoint,
+		latency:    latency,
+		syncStatus: &beaconTypes.SyncStatusData{HeadSlot: headSlot, IsSyncing: syncing},
+	}
+}
+
+func newTestMultiClient(nodes ...*fakeNode) *MultiBeaconClient {
+	clients := make([]BeaconClient, len(nodes))
+	for i, node := range nodes {
+		clients[i] = BeaconClient{Node: node}
+	}
+	return &MultiBeaconClient{
+		Clients:     clients,
+		scores:      make(map[string]*NodeScore),
+		nodeTimeout: time.Second,
+		BeaconData:  &beaconData.BeaconData{},
+	}
+}
+
+func scoreOf(t *testing.T, b *MultiBeaconClient, endpoint string) NodeScore {
+	t.Helper()
+	for _, score := range b.NodeScores() {
+		if score.Endpoint == endpoint {
+			return score
+		}
+	}
+	t.Fatalf("no score for %s", endpoint)
+	return NodeScore{}
+}
+
+func endpoints(scores []NodeScore) []string {
+	var order []string
+	for _, score := range scores {
+		order = append(order, score.Endpoint)
+	}
+	return order
+}
+
+func assertDuration(t *testing.T, name string, got, want time.Duration) {
+	t.Helper()
+	if diff := got - want; diff < -5*time.Millisecond || diff > 5*time.Millisecond {
+		t.Fatalf("%s = %s, want about %s", name, got, want)
+	}
+}
+
+func TestRecordLatencyAverage(t *testing.T) {
+	node := newFakeNode("a", 0, 0, false)
+	b := newTestMultiClient(node)
+	client := b.Clients[0]
+	ctx := context.Background()
+
+	b.record(ctx, client, time.Now().Add(-100*time.Millisecond), nil)
+	assertDuration(t, "first latency", scoreOf(t, b, "a").Latency, 100*time.Millisecond)
+
+	// the newest call weighs scoreWeight
+	b.record(ctx, client, time.Now().Add(-200*time.Millisecond), nil)
+	assertDuration(t, "average latency", scoreOf(t, b, "a").Latency, 120*time.Millisecond)
+
+	// failures don't move the latency
+	b.record(ctx, client, time.Now().Add(-time.Second), errFakeNode)
+	score := scoreOf(t, b, "a")
+	assertDuration(t, "latency after failure", score.Latency, 120*time.Millisecond)
+	if score.Calls != 3 || score.Failures != 1 {
+		t.Fatalf("calls %d failures %d, want 3 and 1", score.Calls, score.Failures)
+	}
+}
+
+func TestRecordErrorRate(t *testing.T) {
+	node := newFakeNode("a", 0, 0, false)
+	b := newTestMultiClient(node)
+	client := b.Clients[0]
+	ctx := context.Background()
+
+	b.record(ctx, client, time.Now(), errFakeNode)
+	score := scoreOf(t, b, "a")
+	if score.ErrorRate != scoreWeight {
+		t.Fatalf("error rate %f after a failure, want %f", score.ErrorRate, scoreWeight)
+	}
+	if score.LastError != errFakeNode.Error() {
+		t.Fatalf("last error %q, want %q", score.LastError, errFakeNode.Error())
+	}
+	// a failing node costs the node timeout weighted by its error rate
+	assertDuration(t, "score", score.Score, time.Duration(scoreWeight*float64(time.Second)))
+
+	b.record(ctx, client, time.Now(), nil)
+	if rate := scoreOf(t, b, "a").ErrorRate; math.Abs(rate-(1-scoreWeight)*scoreWeight) > 1e-9 {
+		t.Fatalf("error rate %f after a success, want %f", rate, (1-scoreWeight)*scoreWeight)
+	}
+}
+
+func TestRecordSkipsCancelledCalls(t *testing.T) {
+	node := newFakeNode("a", 0, 0, false)
+	b := newTestMultiClient(node)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.record(ctx, b.Clients[0], time.Now(), context.Canceled)
+
+	if score := scoreOf(t, b, "a"); score.Calls != 0 || score.ErrorRate != 0 {
+		t.Fatalf("cancelled call counted against the node: %+v", score)
+	}
+}
+
+func TestRankSyncedLaggingUnsynced(t *testing.T) {
+	slowSynced := newFakeNode("slow-synced", 20*time.Millisecond, 100, false)
+	fastSynced := newFakeNode("fast-synced", time.Millisecond, 100, false)
+	lagging := newFakeNode("lagging", time.Millisecond, 90, false)
+	syncing := newFakeNode("syncing", time.Millisecond, 100, true)
+	failing := newFakeNode("failing", time.Millisecond, 100, false)
+	failing.setErr(errFakeNode)
+
+	b := newTestMultiClient(failing, syncing, lagging, slowSynced, fastSynced)
+	syncStatus, err := b.SyncStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if syncStatus.HeadSlot != 100 || syncStatus.IsSyncing {
+		t.Fatalf("sync status %+v, want the synced head", syncStatus)
+	}
+
+	want := []string{"fast-synced", "slow-synced", "lagging", "syncing", "failing"}
+	scores := b.NodeScores()
+	for i, endpoint := range endpoints(scores) {
+		if endpoint != want[i] {
+			t.Fatalf("order %v, want %v", endpoints(scores), want)
+		}
+	}
+	if !scores[2].Behind || scores[0].Behind {
+		t.Fatalf("lagging node not behind the head: %+v", scores)
+	}
+
+	synced, unsynced := b.SyncedClients()
+	if len(synced) != 3 || len(unsynced) != 2 {
+		t.Fatalf("synced %v unsynced %v", synced, unsynced)
+	}
+
+	// calls go to the best node first
+	if _, err := b.GetValidatorList(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if fastSynced.callCount() != 2 || slowSynced.callCount() != 1 {
+		t.Fatalf("validator list not asked from the best node first")
+	}
+}
+
+func TestRankFailingNodeFallsBehind(t *testing.T) {
+	first := newFakeNode("first", time.Millisecond, 100, false)
+	second := newFakeNode("second", 5*time.Millisecond, 100, false)
+	b := newTestMultiClient(first, second)
+	ctx := context.Background()
+
+	if _, err := b.SyncStatus(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if order := endpoints(b.NodeScores()); order[0] != "first" {
+		t.Fatalf("order %v, want the fastest node first", order)
+	}
+
+	// failures are retried on the next node and push the node back
+	first.setErr(errFakeNode)
+	for i := 0; i < 3; i++ {
+		if _, err := b.GetValidatorList(ctx, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if order := endpoints(b.NodeScores()); order[0] != "second" {
+		t.Fatalf("order %v, want the failing node last", order)
+	}
+
+	// and it keeps being synced by its last sync status, unsynced once it fails that
+	if _, err := b.SyncStatus(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if score := scoreOf(t, b, "first"); score.Synced {
+		t.Fatalf("node failing its sync status still synced: %+v", score)
+	}
+}
+
+func TestSyncStatusAllNodesFailed(t *testing.T) {
+	node := newFakeNode("a", 0, 100, false)
+	node.setErr(errFakeNode)
+	b := newTestMultiClient(node)
+
+	if _, err := b.SyncStatus(context.Background()); err == nil {
+		t.Fatal("sync status without any node answering")
+	}
+	if _, err := newTestMultiClient().SyncStatus(context.Background()); err == nil {
+		t.Fatal("sync status without nodes")
+	}
+}
+
+func TestScoresConcurrentAccess(t *testing.T) {
+	nodes := []*fakeNode{
+		newFakeNode("a", 0, 100, false),
+		newFakeNode("b", 0, 99, false),
+		newFakeNode("c", 0, 100, true),
+	}
+	nodes[1].setErr(errFakeNode)
+	b := newTestMultiClient(nodes...)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				b.record(ctx, b.Clients[j%len(b.Clients)], time.Now(), nil)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				b.SyncStatus(ctx)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				b.GetValidatorList(ctx, 0)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				b.NodeScores()
+				b.SyncedClients()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var calls uint64
+	for _, score := range b.NodeScores() {
+		calls += score.Calls
+	}
+	if calls == 0 {
+		t.Fatal("no calls recorded")
+	}
+}
+
+func TestNodeScoresSnapshot(t *testing.T) {
+	node := newFakeNode("a", 0, 100, false)
+	b := newTestMultiClient(node)
+	if _, err := b.SyncStatus(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	scores := b.NodeScores()
+	scores[0].Calls = 1000
+	scores[0].Synced = false
+	if score := scoreOf(t, b, "a"); score.Calls != 1 || !score.Synced {
+		t.Fatalf("changing a snapshot changed the node score: %+v", score)
+	}
+
+	// GET /admin/beacon-nodes answers the snapshot as json
+	encoded, err := json.Marshal(b.NodeScores())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("%d nodes in the snapshot, want 1", len(decoded))
+	}
+	for _, field := range []string{"endpoint", "score", "latency", "error_rate", "calls", "failures", "last_call", "synced", "head_slot", "behind"} {
+		if _, ok := decoded[0][field]; !ok {
+			t.Fatalf("snapshot without %s: %s", field, encoded)
+		}
+	}
+	if _, ok := decoded[0]["last_error"]; ok {
+		t.Fatalf("snapshot of a healthy node with last_error: %s", encoded)
+	}
+	if decoded[0]["endpoint"] != "a" || decoded[0]["head_slot"] != float64(100) || decoded[0]["synced"] != true {
+		t.Fatalf("unexpected snapshot %s", encoded)
+	}
+}
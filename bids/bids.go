@@ -10,7 +10,7 @@ import (
 	"sync"
 	"time"
 
-	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/go-redis/redis/v9"
 	"github.com/sirupsen/logrus"
 
@@ -19,7 +19,7 @@ import (
 	"github.com/pon-pbs/bbRelay/utils"
 )
 
-func NewBidBoard(redis redisPackage.RedisInterface, bulletin bulletinboard.RelayMQTT, timeout time.Duration) *BidBoard {
+func NewBidBoard(redis redisPackage.RedisInterface, bulletin BulletinBoard, timeout time.Duration) *BidBoard {
 	return &BidBoard{
 		redisInterface: redis,
 		bulletinBoard:  bulletin,
@@ -91,7 +91,18 @@ func (r *BidBoard) SaveBuilderBid(slot uint64, builderPubkey string, proposerPub
 	if err != nil {
 		return err
 	}
-	return r.redisInterface.Client.Expire(context.Background(), bidValueKey, r.bidTimeout).Err()
+	err = r.redisInterface.Client.Expire(context.Background(), bidValueKey, r.bidTimeout).Err()
+	if err != nil {
+		return err
+	}
+
+	r.bulletinBoard.SendTimeline(bulletinboard.BidAccepted{
+		Slot:      slot,
+		Builder:   builderPubkey,
+		Value:     builderBlockBid.Value,
+		BlockHash: headerBlockHash(builderHeader),
+	})
+	return nil
 }
 
 func (b *BidBoard) SavePayloadUtils(slot uint64, proposer string, blockhash string, payloadUtils *utils.GetPayloadUtils) error {
@@ -170,12 +181,13 @@ func (b *BidBoard) AuctionBid(slot uint64) (builder string, value big.Int, err e
 		return "", *big.NewInt(0), err
 	}
 
-	highestBid := bulletinBoardTypes.RelayHighestBid{
-		Slot:             slot,
-		BuilderPublicKey: topBidBuilderPubkey,
-		Amount:           fmt.Sprintf("%d", topBidValue),
+	highestBid := bulletinboard.HighestBid{
+		Slot:      slot,
+		Builder:   topBidBuilderPubkey,
+		Value:     topBidValue,
+		BlockHash: bidBlockHash(bidStr),
 	}
-	b.bulletinBoard.Channel.HighestBidChannel <- highestBid
+	b.bulletinBoard.SendHighestBid(highestBid)
 
 	return topBidBuilderPubkey, *topBidValue, nil
 }
@@ -267,7 +279,7 @@ func (b *BidBoard) GetOpenAuctionHighestBid(slot uint64) (value *big.Int, err er
 }
 
 // @dev Sets The Bounty Bid Winner
-func (b *BidBoard) SetBountyBidForSlot(slot uint64, builder string) (bountyBidWin bool, err error) {
+func (b *BidBoard) SetBountyBidForSlot(slot uint64, builder string, blockHash phase0.Hash32) (bountyBidWin bool, err error) {
 
 	bountyBidWinner, err := b.GetBountyBidForSlot(slot)
 	if err != nil {
@@ -296,11 +308,127 @@ func (b *BidBoard) SetBountyBidForSlot(slot uint64, builder string) (bountyBidWi
 		"builder": builder,
 	}).Info("Bounty Bid Won")
 
-	// bountyBid := bulletinBoardTypes.BountyBidWon{
-	// 	Slot:    slot,
-	// 	Builder: builder,
-	// }
-	// b.bulletinBoard.Channel.BountyBidChannel <- bountyBid
+	b.bulletinBoard.SendBountyBid(bulletinboard.BountyBid{
+		Slot:      slot,
+		Builder:   builder,
+		BlockHash: blockHash,
+	})
 
 	return true, nil
 }
+
+// @dev Gives everything the relay knows about the auction of a slot
+func (b *BidBoard) AuctionState(slot uint64) (*AuctionState, error) {
+	bidValueKey := fmt.Sprintf("%s-%d", builderValueKeyBid, slot)
+	bidValues, err := b.redisInterface.Client.HGetAll(context.Background(), bidValueKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &AuctionState{
+		Slot: slot,
+		Bids: bidValues,
+	}
+
+	state.WinningBid, err = b.WinningBid(slot)
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	state.BountyBidWinner, err = b.GetBountyBidForSlot(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	state.PayloadDelivered, err = b.GetPayloadDelivered(slot)
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// @dev Drops the bids of a slot built on parentHash, a parent replaced by a
+// reorg, and elects the highest bid again from the bids left
+func (b *BidBoard) InvalidateAuction(slot uint64, parentHash phase0.Hash32) (dropped []string, err error) {
+	bidKey := fmt.Sprintf("%s-%d", builderKeyBid, slot)
+	bids, err := b.redisInterface.Client.HGetAll(context.Background(), bidKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for builderPubkey, bidStr := range bids {
+		if bidParentHash(bidStr) == parentHash {
+			dropped = append(dropped, builderPubkey)
+		}
+	}
+	if len(dropped) == 0 {
+		return nil, nil
+	}
+
+	for _, key := range []string{builderKeyBid, builderTimeKeyBid, builderValueKeyBid} {
+		err = b.redisInterface.Client.HDel(context.Background(), fmt.Sprintf("%s-%d", key, slot), dropped...).Err()
+		if err != nil {
+			return dropped, err
+		}
+	}
+
+	bidHighestKey := fmt.Sprintf("%s-%d", builderHighestKeyBid, slot)
+	highestBid, err := b.redisInterface.Client.Get(context.Background(), bidHighestKey).Result()
+	if err == redis.Nil {
+		err = nil
+	} else if err == nil && bidParentHash(highestBid) == parentHash {
+		err = b.redisInterface.Client.Del(context.Background(), bidHighestKey).Err()
+		if err == nil && len(dropped) < len(bids) {
+			_, _, err = b.AuctionBid(slot)
+		}
+	}
+
+	b.log.WithFields(logrus.Fields{
+		"slot":       slot,
+		"parentHash": parentHash.String(),
+		"builders":   dropped,
+	}).Warn("Bids Dropped, Parent Replaced By Reorg")
+
+	return dropped, err
+}
+
+func (b *BidBoard) Logger() *logrus.Logger {
+	return b.log.Logger
+}
+
+// bidBlockHash returns the block hash of a bid stored as json, zero if it can't be decoded
+func bidBlockHash(bidStr string) phase0.Hash32 {
+	bid := new(utils.ProposerHeaderResponse)
+	if err := json.Unmarshal([]byte(bidStr), bid); err != nil {
+		return phase0.Hash32{}
+	}
+	return headerBlockHash(&bid.Bid)
+}
+
+func headerBlockHash(bid *utils.GetHeaderResponse) phase0.Hash32 {
+	if bid.Data == nil || bid.Data.Message == nil || bid.Data.Message.ExecutionPayloadHeader == nil {
+		return phase0.Hash32{}
+	}
+	header, err := bid.Data.Message.ExecutionPayloadHeader.ToBaseExecutionPayloadHeader()
+	if err != nil {
+		return phase0.Hash32{}
+	}
+	return header.BlockHash
+}
+
+// bidParentHash returns the parent hash of a bid stored as json, zero if it can't be decoded
+func bidParentHash(bidStr string) phase0.Hash32 {
+	bid := new(utils.ProposerHeaderResponse)
+	if err := json.Unmarshal([]byte(bidStr), bid); err != nil {
+		return phase0.Hash32{}
+	}
+	if bid.Bid.Data == nil || bid.Bid.Data.Message == nil || bid.Bid.Data.Message.ExecutionPayloadHeader == nil {
+		return phase0.Hash32{}
+	}
+	header, err := bid.Bid.Data.Message.ExecutionPayloadHeader.ToBaseExecutionPayloadHeader()
+	if err != nil {
+		return phase0.Hash32{}
+	}
+	return header.ParentHash
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/pon-pbs/bbRelay/bulletinboard"
 	"github.com/pon-pbs/bbRelay/redisPackage"
+	"github.com/pon-pbs/bbRelay/utils"
 )
 
 var (
@@ -23,10 +24,25 @@ var (
 	slotBountyBidWinnerKey   = "slot-bounty-bid-winner"
 )
 
+// BulletinBoard announces the bids, implemented by *bulletinboard.BulletinBoard
+type BulletinBoard interface {
+	SendHighestBid(bid bulletinboard.HighestBid)
+	SendBountyBid(bid bulletinboard.BountyBid)
+	SendTimeline(event bulletinboard.TimelineEvent)
+}
+
 type BidBoard struct {
 	redisInterface redisPackage.RedisInterface
 	log            *logrus.Entry
-	bulletinBoard  bulletinboard.RelayMQTT
+	bulletinBoard  BulletinBoard
 	bidTimeout     time.Duration
 	bidMutex       sync.Mutex
 }
+
+type AuctionState struct {
+	Slot             uint64                        `json:"slot"`
+	Bids             map[string]string             `json:"bids"`
+	WinningBid       *utils.ProposerHeaderResponse `json:"winning_bid"`
+	BountyBidWinner  string                        `json:"bounty_bid_winner"`
+	PayloadDelivered string                        `json:"payload_delivered"`
+}
@@ -0,0 +1,260 @@
+package bls
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+// EIP-2335 keystore parameters used when encrypting new keys
+const (
+	keystoreVersion = 4
+	scryptN         = 262144
+	scryptR         = 8
+	scryptP         = 1
+	keystoreDKLen   = 32
+)
+
+type Keystore struct {
+	Crypto      KeystoreCrypto `json:"crypto"`
+	Description string         `json:"description"`
+	PubKey      string         `json:"pubkey"`
+	Path        string         `json:"path"`
+	UUID        string         `json:"uuid"`
+	Version     uint           `json:"version"`
+}
+
+type KeystoreCrypto struct {
+	KDF      KeystoreModule `json:"kdf"`
+	Checksum KeystoreModule `json:"checksum"`
+	Cipher   KeystoreModule `json:"cipher"`
+}
+
+type KeystoreModule struct {
+	Function string                 `json:"function"`
+	Params   map[string]interface{} `json:"params"`
+	Message  string                 `json:"message"`
+}
+
+// LoadKeystore reads an EIP-2335 keystore and decrypts it with the password
+// stored in passwordFile
+func LoadKeystore(keystoreFile string, passwordFile string) (*SecretKey, error) {
+	keystoreJSON, err := os.ReadFile(keystoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read keystore: %w", err)
+	}
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read keystore password: %w", err)
+	}
+	return DecryptKeystore(keystoreJSON, strings.TrimRight(string(password), "\r\n"))
+}
+
+// DecryptKeystore decrypts an EIP-2335 keystore using scrypt or pbkdf2 and aes-128-ctr
+func DecryptKeystore(keystoreJSON []byte, password string) (*SecretKey, error) {
+	keystore := new(Keystore)
+	if err := json.Unmarshal(keystoreJSON, keystore); err != nil {
+		return nil, fmt.Errorf("could not decode keystore: %w", err)
+	}
+	if keystore.Version != keystoreVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedKeystore, keystore.Version)
+	}
+
+	decryptionKey, err := keystore.Crypto.deriveKey(processPassword(password))
+	if err != nil {
+		return nil, err
+	}
+
+	cipherMessage, err := hex.DecodeString(keystore.Crypto.Cipher.Message)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode cipher message: %w", err)
+	}
+
+	if keystore.Crypto.Checksum.Function != "sha256" {
+		return nil, fmt.Errorf("%w: checksum %s", ErrUnsupportedKeystore, keystore.Crypto.Checksum.Function)
+	}
+	expectedChecksum, err := hex.DecodeString(keystore.Crypto.Checksum.Message)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode checksum: %w", err)
+	}
+	checksum := sha256.Sum256(append(decryptionKey[16:32:32], cipherMessage...))
+	if !bytes.Equal(checksum[:], expectedChecksum) {
+		return nil, ErrKeystorePassword
+	}
+
+	if keystore.Crypto.Cipher.Function != "aes-128-ctr" {
+		return nil, fmt.Errorf("%w: cipher %s", ErrUnsupportedKeystore, keystore.Crypto.Cipher.Function)
+	}
+	iv, err := hexParam(keystore.Crypto.Cipher.Params, "iv")
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(decryptionKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	skBytes := make([]byte, len(cipherMessage))
+	cipher.NewCTR(block, iv).XORKeyStream(skBytes, cipherMessage)
+
+	sk, err := SecretKeyFromBytes(skBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if keystore.PubKey != "" {
+		pubkey := hex.EncodeToString(PublicKeyFromSecretKey(sk).Compress())
+		if pubkey != strings.TrimPrefix(keystore.PubKey, "0x") {
+			return nil, fmt.Errorf("keystore pubkey %s does not match decrypted key %s", keystore.PubKey, pubkey)
+		}
+	}
+
+	return sk, nil
+}
+
+// EncryptKeystore encrypts the secret key into an EIP-2335 keystore using scrypt
+func EncryptKeystore(sk *SecretKey, password string) ([]byte, error) {
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	uuid := make([]byte, 16)
+	for _, random := range [][]byte{salt, iv, uuid} {
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+	}
+
+	decryptionKey, err := scrypt.Key(processPassword(password), salt, scryptN, scryptR, scryptP, keystoreDKLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(decryptionKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	skBytes := sk.Serialize()
+	cipherMessage := make([]byte, len(skBytes))
+	cipher.NewCTR(block, iv).XORKeyStream(cipherMessage, skBytes)
+
+	checksum := sha256.Sum256(append(decryptionKey[16:32:32], cipherMessage...))
+
+	// version 4 uuid
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+
+	keystore := Keystore{
+		Crypto: KeystoreCrypto{
+			KDF: KeystoreModule{
+				Function: "scrypt",
+				Params: map[string]interface{}{
+					"dklen": keystoreDKLen,
+					"n":     scryptN,
+					"r":     scryptR,
+					"p":     scryptP,
+					"salt":  hex.EncodeToString(salt),
+				},
+			},
+			Checksum: KeystoreModule{
+				Function: "sha256",
+				Params:   map[string]interface{}{},
+				Message:  hex.EncodeToString(checksum[:]),
+			},
+			Cipher: KeystoreModule{
+				Function: "aes-128-ctr",
+				Params: map[string]interface{}{
+					"iv": hex.EncodeToString(iv),
+				},
+				Message: hex.EncodeToString(cipherMessage),
+			},
+		},
+		Description: "PoN relay signing key",
+		PubKey:      hex.EncodeToString(PublicKeyFromSecretKey(sk).Compress()),
+		UUID:        fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]),
+		Version:     keystoreVersion,
+	}
+
+	return json.MarshalIndent(keystore, "", "  ")
+}
+
+func (c *KeystoreCrypto) deriveKey(password []byte) ([]byte, error) {
+	salt, err := hexParam(c.KDF.Params, "salt")
+	if err != nil {
+		return nil, err
+	}
+	dkLen, err := intParam(c.KDF.Params, "dklen")
+	if err != nil {
+		return nil, err
+	}
+	if dkLen < keystoreDKLen {
+		return nil, fmt.Errorf("%w: dklen %d", ErrUnsupportedKeystore, dkLen)
+	}
+
+	switch c.KDF.Function {
+	case "scrypt":
+		n, err := intParam(c.KDF.Params, "n")
+		if err != nil {
+			return nil, err
+		}
+		r, err := intParam(c.KDF.Params, "r")
+		if err != nil {
+			return nil, err
+		}
+		p, err := intParam(c.KDF.Params, "p")
+		if err != nil {
+			return nil, err
+		}
+		return scrypt.Key(password, salt, n, r, p, dkLen)
+	case "pbkdf2":
+		if prf, _ := c.KDF.Params["prf"].(string); prf != "hmac-sha256" {
+			return nil, fmt.Errorf("%w: pbkdf2 prf %s", ErrUnsupportedKeystore, prf)
+		}
+		iterations, err := intParam(c.KDF.Params, "c")
+		if err != nil {
+			return nil, err
+		}
+		return pbkdf2.Key(password, salt, iterations, dkLen, sha256.New), nil
+	default:
+		return nil, fmt.Errorf("%w: kdf %s", ErrUnsupportedKeystore, c.KDF.Function)
+	}
+}
+
+// processPassword NFKD normalizes the password and strips the C0, C1 and
+// Delete control codes as EIP-2335 requires
+func processPassword(password string) []byte {
+	return []byte(strings.Map(func(r rune) rune {
+		if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+			return -1
+		}
+		return r
+	}, norm.NFKD.String(password)))
+}
+
+func hexParam(params map[string]interface{}, name string) ([]byte, error) {
+	value, ok := params[name].(string)
+	if !ok {
+		return nil, fmt.Errorf("keystore param %s missing", name)
+	}
+	decoded, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode keystore param %s: %w", name, err)
+	}
+	return decoded, nil
+}
+
+func intParam(params map[string]interface{}, name string) (int, error) {
+	value, ok := params[name].(float64)
+	if !ok {
+		return 0, fmt.Errorf("keystore param %s missing", name)
+	}
+	return int(value), nil
+}
@@ -27,6 +27,8 @@ var (
 	ErrInvalidSecretKeyLength = errors.New("invalid secret key length")
 	ErrInvalidSignature       = errors.New("invalid signature")
 	ErrInvalidSignatureLength = errors.New("invalid signature length")
+	ErrKeystorePassword       = errors.New("invalid keystore password")
 	ErrUncompressPubkey       = errors.New("could not uncompress public key from bytes")
 	ErrUncompressSignature    = errors.New("could not uncompress signature from bytes")
+	ErrUnsupportedKeystore    = errors.New("unsupported keystore")
 )
@@ -0,0 +1,320 @@
+package builderclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const retryBackoff = 50 * time.Millisecond
+
+func NewClient(params Params) (*Client, error) {
+	tlsConfig, err := newTLSConfig(params)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{
+		params:  params,
+		clients: make(map[string]*http.Client),
+		stats:   make(map[string]*BuilderStats),
+		log: logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+			"package": "BuilderClient",
+		}),
+	}
+	client.transport = func() *http.Transport {
+		transport := &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   params.Timeout,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        params.MaxIdleConnsPerBuilder,
+			MaxIdleConnsPerHost: params.MaxIdleConnsPerBuilder,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: params.Timeout,
+		}
+		if tlsConfig != nil {
+			transport.TLSClientConfig = tlsConfig.Clone()
+		}
+		return transport
+	}
+	return client, nil
+}
+
+func newTLSConfig(params Params) (*tls.Config, error) {
+	if params.TLSCertFile == "" && params.TLSKeyFile == "" && params.TLSCAFile == "" {
+		return nil, nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if params.TLSCertFile != "" || params.TLSKeyFile != "" {
+		if params.TLSCertFile == "" || params.TLSKeyFile == "" {
+			return nil, fmt.Errorf("builder client certificate needs both a certificate and a key file")
+		}
+		certificate, err := tls.LoadX509KeyPair(params.TLSCertFile, params.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load builder client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	if params.TLSCAFile != "" {
+		ca, err := os.ReadFile(params.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read builder CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in builder CA %s", params.TLSCAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// Endpoints splits the endpoint declared in a bid into the primary endpoint
+// followed by the builder's comma separated fallbacks
+func Endpoints(declared string) []string {
+	endpoints := []string{}
+	for _, endpoint := range strings.Split(declared, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// GetPayload posts request to the builder's endpoints and decodes the first
+// good answer into response. The primary endpoint is asked first, every
+// HedgeDelay without an answer, or as soon as an endpoint fails, the next
+// fallback is asked as well. Once all endpoints failed the round is retried
+// up to Retries times, all within the deadline of ctx. response must be a
+// pointer, it is only written once an answer decoded completely.
+func (c *Client) GetPayload(ctx context.Context, builder string, endpoints []string, request any, response any) error {
+	if len(endpoints) == 0 {
+		return ErrNoEndpoint
+	}
+	target := reflect.ValueOf(response)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("builder payload response must be a non-nil pointer, got %T", response)
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	client := c.client(builder)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan attemptResult, len(endpoints)*(c.params.Retries+1))
+	var lastErr error
+	for round := 0; round <= c.params.Retries; round++ {
+		if round > 0 {
+			c.log.WithError(lastErr).WithFields(logrus.Fields{
+				"builder": builder,
+				"round":   round,
+			}).Warn("Retrying Builder Payload Request")
+			select {
+			case <-ctx.Done():
+				return c.deadlineExceeded(builder, lastErr)
+			case <-time.After(retryBackoff):
+			}
+		}
+
+		next, inflight := 0, 0
+		hedge := time.After(0)
+		for next < len(endpoints) || inflight > 0 {
+			select {
+			case <-hedge:
+				if next > 0 {
+					c.recordHedge(builder)
+				}
+				go c.attempt(ctx, client, endpoints[next], body, results)
+				next++
+				inflight++
+				hedge = nil
+				if next < len(endpoints) {
+					hedge = time.After(c.params.HedgeDelay)
+				}
+			case result := <-results:
+				inflight--
+				// every answer decodes into a fresh value, fields of an
+				// answer failing half way must not leak into the next one
+				decoded := reflect.New(target.Type().Elem())
+				err := result.err
+				if err == nil {
+					err = json.Unmarshal(result.body, decoded.Interface())
+				}
+				c.record(builder, result.latency, err)
+				if err == nil {
+					target.Elem().Set(decoded.Elem())
+					return nil
+				}
+				lastErr = fmt.Errorf("%s: %w", result.endpoint, err)
+				if next < len(endpoints) {
+					hedge = time.After(0)
+				}
+			case <-ctx.Done():
+				return c.deadlineExceeded(builder, lastErr)
+			}
+		}
+	}
+	return lastErr
+}
+
+func (c *Client) deadlineExceeded(builder string, lastErr error) error {
+	c.record(builder, 0, ErrDeadline)
+	if lastErr == nil {
+		return ErrDeadline
+	}
+	return fmt.Errorf("%w, last error %v", ErrDeadline, lastErr)
+}
+
+func (c *Client) attempt(ctx context.Context, client *http.Client, endpoint string, body []byte, results chan<- attemptResult) {
+	start := time.Now()
+	respBody, err := c.post(ctx, client, endpoint, body)
+	results <- attemptResult{
+		endpoint: endpoint,
+		body:     respBody,
+		latency:  time.Since(start),
+		err:      err,
+	}
+}
+
+func (c *Client) post(ctx context.Context, client *http.Client, endpoint string, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.params.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("builder responded %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+// Probe checks the endpoint answers at all, any response below 500 counts as
+// reachable since payload endpoints only serve POST requests with a block
+func (c *Client) Probe(ctx context.Context, builder string, endpoint string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.params.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client(builder).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("builder endpoint responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// client returns the builder's HTTP client, each builder gets its own
+// connection pool so a slow builder can't hold connections of others
+func (c *Client) client(builder string) *http.Client {
+	builder = strings.ToLower(builder)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	client, ok := c.clients[builder]
+	if !ok {
+		client = &http.Client{Transport: c.transport()}
+		c.clients[builder] = client
+	}
+	return client
+}
+
+func (c *Client) record(builder string, latency time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.builderStats(builder)
+	stats.Requests++
+	stats.LastRequest = time.Now()
+	if err != nil {
+		stats.Failures++
+		stats.LastError = err.Error()
+		return
+	}
+	stats.LastLatency = latency
+	if stats.AverageLatency == 0 {
+		stats.AverageLatency = latency
+	} else {
+		stats.AverageLatency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(stats.AverageLatency))
+	}
+}
+
+func (c *Client) recordHedge(builder string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.builderStats(builder).Hedged++
+}
+
+func (c *Client) builderStats(builder string) *BuilderStats {
+	builder = strings.ToLower(builder)
+	stats, ok := c.stats[builder]
+	if !ok {
+		stats = new(BuilderStats)
+		c.stats[builder] = stats
+	}
+	return stats
+}
+
+// Stats returns the payload request stats of every builder called so far
+func (c *Client) Stats() map[string]BuilderStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make(map[string]BuilderStats, len(c.stats))
+	for builder, builderStats := range c.stats {
+		stats[builder] = *builderStats
+	}
+	return stats
+}
+
+// Timeout of a single request to a builder endpoint
+func (c *Client) Timeout() time.Duration {
+	return c.params.Timeout
+}
+
+func (c *Client) Logger() *logrus.Logger {
+	return c.log.Logger
+}
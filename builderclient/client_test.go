@@ -0,0 +1,205 @@
+package builderclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testPayload struct {
+	BlockHash string `json:"block_hash"`
+	Number    uint64 `json:"number"`
+}
+
+// builderEndpoint answers every payload request with status and body once
+// delay passed, counting the requests
+type builderEndpoint struct {
+	requests atomic.Int32
+	url      string
+}
+
+func newBuilderEndpoint(t *testing.T, delay time.Duration, status int, body string) *builderEndpoint {
+	t.Helper()
+	endpoint := new(builderEndpoint)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint.requests.Add(1)
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	endpoint.url = server.URL
+	return endpoint
+}
+
+func testClient(t *testing.T, params Params) *Client {
+	t.Helper()
+	if params.Timeout == 0 {
+		params.Timeout = 5 * time.Second
+	}
+	client, err := NewClient(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.log.Logger.SetOutput(io.Discard)
+	return client
+}
+
+func TestGetPayloadPrimary(t *testing.T) {
+	var request map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&request) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"block_hash":"0x01","number":1}`)
+	}))
+	defer server.Close()
+	fallback := newBuilderEndpoint(t, 0, http.StatusOK, `{"block_hash":"0x02","number":2}`)
+
+	client := testClient(t, Params{HedgeDelay: time.Second})
+	response := new(testPayload)
+	if err := client.GetPayload(context.Background(), "0xBB", []string{server.URL, fallback.url}, map[string]string{"slot": "1"}, response); err != nil {
+		t.Fatal(err)
+	}
+	if response.BlockHash != "0x01" || request["slot"] != "1" {
+		t.Fatalf("payload %+v for request %v, want the primary's", response, request)
+	}
+	if fallback.requests.Load() != 0 {
+		t.Fatal("fallback asked although the primary answered")
+	}
+	if stats := client.Stats()["0xbb"]; stats.Requests != 1 || stats.Failures != 0 || stats.Hedged != 0 {
+		t.Fatalf("stats %+v", stats)
+	}
+}
+
+func TestGetPayloadPrimarySlow(t *testing.T) {
+	primary := newBuilderEndpoint(t, 5*time.Second, http.StatusOK, `{"block_hash":"0x01"}`)
+	fallback := newBuilderEndpoint(t, 0, http.StatusOK, `{"block_hash":"0x02"}`)
+
+	client := testClient(t, Params{HedgeDelay: 50 * time.Millisecond})
+	response := new(testPayload)
+	start := time.Now()
+	if err := client.GetPayload(context.Background(), "0xbb", []string{primary.url, fallback.url}, nil, response); err != nil {
+		t.Fatal(err)
+	}
+	if response.BlockHash != "0x02" || time.Since(start) > time.Second {
+		t.Fatalf("payload %+v after %s, want the fallback's without waiting for the primary", response, time.Since(start))
+	}
+	if stats := client.Stats()["0xbb"]; stats.Hedged != 1 {
+		t.Fatalf("hedged %d times, want once", stats.Hedged)
+	}
+}
+
+func TestGetPayloadPrimaryFails(t *testing.T) {
+	primary := newBuilderEndpoint(t, 0, http.StatusInternalServerError, "no payload")
+	fallback := newBuilderEndpoint(t, 0, http.StatusOK, `{"block_hash":"0x02"}`)
+
+	// the fallback is asked as soon as the primary failed, not after the hedge delay
+	client := testClient(t, Params{HedgeDelay: time.Minute})
+	response := new(testPayload)
+	start := time.Now()
+	if err := client.GetPayload(context.Background(), "0xbb", []string{primary.url, fallback.url}, nil, response); err != nil {
+		t.Fatal(err)
+	}
+	if response.BlockHash != "0x02" || time.Since(start) > time.Second {
+		t.Fatalf("payload %+v after %s, want the fallback's right away", response, time.Since(start))
+	}
+	if stats := client.Stats()["0xbb"]; stats.Requests != 2 || stats.Failures != 1 || !strings.Contains(stats.LastError, "500") {
+		t.Fatalf("stats %+v, want the primary's failure recorded", stats)
+	}
+}
+
+func TestGetPayloadAllFail(t *testing.T) {
+	primary := newBuilderEndpoint(t, 0, http.StatusInternalServerError, "no payload")
+	fallback := newBuilderEndpoint(t, 0, http.StatusBadGateway, "no payload either")
+
+	client := testClient(t, Params{HedgeDelay: time.Minute, Retries: 2})
+	err := client.GetPayload(context.Background(), "0xbb", []string{primary.url, fallback.url}, nil, new(testPayload))
+	if err == nil || errors.Is(err, ErrDeadline) {
+		t.Fatalf("error %v, want the last endpoint's", err)
+	}
+	for _, endpoint := range []*builderEndpoint{primary, fallback} {
+		if requests := endpoint.requests.Load(); requests != 3 {
+			t.Fatalf("endpoint asked %d times, want once and 2 retries", requests)
+		}
+	}
+}
+
+func TestGetPayloadDeadline(t *testing.T) {
+	hanging := newBuilderEndpoint(t, time.Minute, http.StatusOK, `{}`)
+	failing := newBuilderEndpoint(t, 0, http.StatusInternalServerError, "no payload")
+
+	client := testClient(t, Params{HedgeDelay: 10 * time.Millisecond, Retries: 1000})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.GetPayload(ctx, "0xbb", []string{hanging.url, failing.url}, nil, new(testPayload))
+	if !errors.Is(err, ErrDeadline) || !strings.Contains(err.Error(), "500") {
+		t.Fatalf("error %v, want the deadline with the last failure", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("returned %s after the deadline", elapsed)
+	}
+
+	// nothing answered at all
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := client.GetPayload(ctx, "0xbb", []string{hanging.url}, nil, new(testPayload)); !errors.Is(err, ErrDeadline) {
+		t.Fatalf("error %v, want %v", err, ErrDeadline)
+	}
+}
+
+func TestGetPayloadDiscardsPartialAnswers(t *testing.T) {
+	// the block hash decodes before the number fails to
+	primary := newBuilderEndpoint(t, 0, http.StatusOK, `{"block_hash":"0xstale","number":"one"}`)
+	fallback := newBuilderEndpoint(t, 0, http.StatusOK, `{"number":2}`)
+
+	client := testClient(t, Params{HedgeDelay: time.Minute})
+	response := &testPayload{}
+	if err := client.GetPayload(context.Background(), "0xbb", []string{primary.url, fallback.url}, nil, response); err != nil {
+		t.Fatal(err)
+	}
+	if *response != (testPayload{Number: 2}) {
+		t.Fatalf("payload %+v, want only the fallback's answer", response)
+	}
+
+	// a failed request leaves the response alone
+	response = &testPayload{BlockHash: "0xkept"}
+	if err := client.GetPayload(context.Background(), "0xbb", []string{primary.url}, nil, response); err == nil || response.BlockHash != "0xkept" {
+		t.Fatalf("error %v, payload %+v", err, response)
+	}
+}
+
+func TestGetPayloadInvalidArguments(t *testing.T) {
+	client := testClient(t, Params{})
+	if err := client.GetPayload(context.Background(), "0xbb", nil, nil, new(testPayload)); !errors.Is(err, ErrNoEndpoint) {
+		t.Fatalf("error %v, want %v", err, ErrNoEndpoint)
+	}
+	if err := client.GetPayload(context.Background(), "0xbb", []string{"http://127.0.0.1:1"}, nil, testPayload{}); err == nil {
+		t.Fatal("decoded into a value")
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	endpoints := Endpoints(" https://primary , ,https://fallback,")
+	if len(endpoints) != 2 || endpoints[0] != "https://primary" || endpoints[1] != "https://fallback" {
+		t.Fatalf("endpoints %q", endpoints)
+	}
+	if endpoints := Endpoints(""); len(endpoints) != 0 {
+		t.Fatalf("endpoints %q of an empty declaration", endpoints)
+	}
+}
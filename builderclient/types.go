@@ -0,0 +1,64 @@
+package builderclient
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrNoEndpoint = errors.New("builder has no payload endpoint")
+	ErrDeadline   = errors.New("builder did not return payload before the deadline")
+)
+
+// latencyWeight is the weight of the newest sample in the latency average
+const latencyWeight = 0.2
+
+type Params struct {
+	// Timeout of a single request to a builder endpoint
+	Timeout time.Duration
+	// Retries after every endpoint of the builder failed
+	Retries int
+	// HedgeDelay is how long to wait for an endpoint before also asking the next one
+	HedgeDelay time.Duration
+
+	// Client certificate presented to builders and CA verifying them, all optional
+	TLSCertFile string
+	TLSKeyFile  string
+	TLSCAFile   string
+
+	MaxIdleConnsPerBuilder int
+}
+
+// Client calls builder payload endpoints, keeping a connection pool per builder
+type Client struct {
+	params    Params
+	transport func() *http.Transport
+
+	mu      sync.Mutex
+	clients map[string]*http.Client
+	stats   map[string]*BuilderStats
+
+	log *logrus.Entry
+}
+
+// BuilderStats tracks how a builder's payload endpoints behaved
+type BuilderStats struct {
+	Requests       uint64        `json:"requests"`
+	Failures       uint64        `json:"failures"`
+	Hedged         uint64        `json:"hedged"`
+	AverageLatency time.Duration `json:"average_latency"`
+	LastLatency    time.Duration `json:"last_latency"`
+	LastError      string        `json:"last_error,omitempty"`
+	LastRequest    time.Time     `json:"last_request"`
+}
+
+type attemptResult struct {
+	endpoint string
+	body     []byte
+	latency  time.Duration
+	err      error
+}
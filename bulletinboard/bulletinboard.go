@@ -0,0 +1,189 @@
+package bulletinboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+	"github.com/sirupsen/logrus"
+
+	beaconclient "github.com/pon-pbs/bbRelay/beaconinterface"
+	"github.com/pon-pbs/bbRelay/signing"
+)
+
+func NewBulletinBoard(ctx context.Context, clientParameters bulletinBoardTypes.RelayMQTTOpts, beaconClient *beaconclient.MultiBeaconClient, keys *signing.KeySet, options Options) (*BulletinBoard, error) {
+
+	relayClient := new(BulletinBoard)
+
+	relayClient.Broker = clientParameters.Broker
+	relayClient.Port = clientParameters.Port
+	relayClient.BeaconInterface = beaconClient
+	relayClient.publishers = new(sync.WaitGroup)
+	relayClient.keys = keys
+	relayClient.options = options.Messages
+	relayClient.publish = options.Publish
+	relayClient.retainedSlots = make(map[bulletinBoardTypes.MQTTTopic]map[uint64]struct{})
+	relayClient.retainedMu = new(sync.Mutex)
+
+	genesis, err := beaconClient.Genesis(ctx)
+	if err != nil {
+		return nil, err
+	}
+	relayClient.domain, err = BulletinBoardDomain(genesis.GenesisForkVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	relayClient.Log = logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		"package":    "BulletinBoard",
+		"publishers": options.Transports.Publishers,
+	})
+
+	relayClient.Channel.HighestBidChannel = make(chan HighestBid, options.Publish.Buffer)
+	relayClient.Channel.ProposerHeaderChannel = make(chan HeaderRequest, options.Publish.Buffer)
+	relayClient.Channel.SlotPayloadChannel = make(chan PayloadRequest, options.Publish.Buffer)
+	relayClient.Channel.BountyBidChannel = make(chan BountyBid, options.Publish.Buffer)
+	relayClient.Channel.TimelineChannel = make(chan timelineEntry, options.Publish.Buffer)
+
+	relayClient.publisher, err = relayClient.newPublishers(clientParameters, options)
+	if err != nil {
+		return nil, err
+	}
+
+	relayClient.BulletinBoards(ctx)
+	relayClient.watchBeacon()
+
+	relayClient.Log.Info("Bulletin Board Ready For Relay")
+	return relayClient, nil
+}
+
+// BulletinBoards starts the publishers, they run until ctx is cancelled and
+// then publish whatever is still queued before returning
+func (relayClient *BulletinBoard) BulletinBoards(ctx context.Context) {
+	publishers := []func(context.Context){
+		relayClient.HighestBidPublish,
+		relayClient.SlotHeaderRequested,
+		relayClient.SlotPayloadRequested,
+		relayClient.BountyBidWon,
+		relayClient.AuctionTimeline,
+	}
+
+	for _, publisher := range publishers {
+		relayClient.publishers.Add(1)
+		go func(publish func(context.Context)) {
+			defer relayClient.publishers.Done()
+			publish(ctx)
+		}(publisher)
+	}
+}
+
+// Close waits for the publishers to drain their queues and closes the transports
+func (relayClient *BulletinBoard) Close() {
+	relayClient.publishers.Wait()
+	relayClient.publisher.Close()
+	relayClient.Log.Info("Bulletin Board Closed")
+}
+
+// Healthy returns why messages can't be published right now, nil if they can
+func (relayClient *BulletinBoard) Healthy() error {
+	return relayClient.publisher.Healthy()
+}
+
+// FanOut is the WebSocket and SSE endpoint of the relay, nil unless the
+// fanout publisher is enabled
+func (relayClient *BulletinBoard) FanOut() *FanOut {
+	return relayClient.fanOut
+}
+
+// publishMessage publishes the structured message and, in legacy mode, the
+// unsigned legacy text on the topic
+func (relayClient *BulletinBoard) publishMessage(topic bulletinBoardTypes.MQTTTopic, legacy string, message Message) error {
+	if relayClient.options.Legacy {
+		if err := relayClient.publisher.Publish(topic, []byte(legacy)); err != nil {
+			return err
+		}
+	}
+	return relayClient.publishStructured(topic, message)
+}
+
+// publishStructured signs the message and publishes it as JSON on the
+// versioned topic, retained per slot and SSZ encoded if enabled
+func (relayClient *BulletinBoard) publishStructured(topic bulletinBoardTypes.MQTTTopic, message Message) error {
+	header := message.Header()
+	signature, err := relayClient.keys.SignerForSlot(header.Slot).Sign(context.Background(), message, relayClient.domain)
+	if err != nil {
+		return err
+	}
+	header.Signature = signature
+
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if err := relayClient.publisher.Publish(VersionedTopic(topic), messageJSON); err != nil {
+		return err
+	}
+	if relayClient.publish.RetainSlots > 0 {
+		if err := relayClient.retain(topic, header.Slot, messageJSON); err != nil {
+			return err
+		}
+	}
+
+	if relayClient.options.SSZ {
+		messageSSZ, err := message.MarshalSSZ()
+		if err != nil {
+			return err
+		}
+		return relayClient.publisher.Publish(SSZTopic(topic), messageSSZ)
+	}
+	return nil
+}
+
+// SlotTopic is the topic the latest message of the topic for the slot is retained on
+func SlotTopic(topic bulletinBoardTypes.MQTTTopic, slot uint64) bulletinBoardTypes.MQTTTopic {
+	return bulletinBoardTypes.MQTTTopic(fmt.Sprintf("%s/slot/%d", VersionedTopic(topic), slot))
+}
+
+// retain keeps the message as the latest state of the slot and clears the
+// retained messages of slots older than RetainSlots. Only slots a message was
+// retained for are cleared, a slot without bids costs nothing.
+func (relayClient *BulletinBoard) retain(topic bulletinBoardTypes.MQTTTopic, slot uint64, message []byte) error {
+	if err := relayClient.publisher.Retain(SlotTopic(topic, slot), message); err != nil {
+		return err
+	}
+
+	relayClient.retainedMu.Lock()
+	slots, ok := relayClient.retainedSlots[topic]
+	if !ok {
+		slots = make(map[uint64]struct{})
+		relayClient.retainedSlots[topic] = slots
+	}
+	slots[slot] = struct{}{}
+	var expired []uint64
+	for retainedSlot := range slots {
+		if retainedSlot+relayClient.publish.RetainSlots <= slot {
+			expired = append(expired, retainedSlot)
+		}
+	}
+	relayClient.retainedMu.Unlock()
+
+	sort.Slice(expired, func(i, j int) bool { return expired[i] < expired[j] })
+	for _, expiredSlot := range expired {
+		if err := relayClient.publisher.Retain(SlotTopic(topic, expiredSlot), []byte{}); err != nil {
+			// cleared with the next message
+			return err
+		}
+		relayClient.retainedMu.Lock()
+		delete(slots, expiredSlot)
+		relayClient.retainedMu.Unlock()
+	}
+	return nil
+}
+
+// Domain is the domain messages are signed with
+func (relayClient *BulletinBoard) Domain() signing.Domain {
+	return relayClient.domain
+}
@@ -0,0 +1,61 @@
+package bulletinboard
+
+import "time"
+
+// The Send functions queue a message without ever blocking the caller, if
+// the publisher is behind the oldest queued message is dropped
+
+func (relayClient *BulletinBoard) SendHighestBid(bid HighestBid) {
+	if dropOldest(relayClient.Channel.HighestBidChannel, bid) {
+		relayClient.Log.WithField("slot", bid.Slot).Warn("Highest Bid Queue Full, Dropped Oldest")
+	}
+}
+
+func (relayClient *BulletinBoard) SendHeaderRequest(request HeaderRequest) {
+	if dropOldest(relayClient.Channel.ProposerHeaderChannel, request) {
+		relayClient.Log.WithField("slot", request.Slot).Warn("Header Request Queue Full, Dropped Oldest")
+	}
+}
+
+func (relayClient *BulletinBoard) SendPayloadRequest(request PayloadRequest) {
+	if dropOldest(relayClient.Channel.SlotPayloadChannel, request) {
+		relayClient.Log.WithField("slot", request.Slot).Warn("Payload Request Queue Full, Dropped Oldest")
+	}
+}
+
+func (relayClient *BulletinBoard) SendBountyBid(bid BountyBid) {
+	if dropOldest(relayClient.Channel.BountyBidChannel, bid) {
+		relayClient.Log.WithField("slot", bid.Slot).Warn("Bounty Bid Queue Full, Dropped Oldest")
+	}
+}
+
+// SendTimeline queues an auction timeline event, timestamped now
+func (relayClient *BulletinBoard) SendTimeline(event TimelineEvent) {
+	if served, ok := event.(PayloadServed); ok {
+		relayClient.payloadServed(served)
+	}
+	if dropOldest(relayClient.Channel.TimelineChannel, timelineEntry{event: event, at: time.Now()}) {
+		relayClient.Log.WithField("slot", event.slot()).Warn("Auction Timeline Queue Full, Dropped Oldest")
+	}
+}
+
+// dropOldest sends value on ch, making room by dropping the oldest queued
+// value while ch is full. An unbuffered channel drops value when no one is
+// receiving. It returns whether anything was dropped.
+func dropOldest[T any](ch chan T, value T) (dropped bool) {
+	for {
+		select {
+		case ch <- value:
+			return dropped
+		default:
+		}
+		if cap(ch) == 0 {
+			return true
+		}
+		select {
+		case <-ch:
+			dropped = true
+		default:
+		}
+	}
+}
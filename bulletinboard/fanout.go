@@ -0,0 +1,314 @@
+package bulletinboard
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrFanOutClosed = errors.New("bulletin board fan-out closed")
+	ErrFanOutFull   = errors.New("too many bulletin board subscribers")
+)
+
+var (
+	// Interval of keepalives on idle streams so proxies don't close them
+	fanOutKeepAlive = 15 * time.Second
+	// Time a WebSocket subscriber gets to accept a message
+	fanOutWriteTimeout = 5 * time.Second
+)
+
+var fanOutUpgrader = websocket.Upgrader{
+	// The bulletin board is public, any page may subscribe
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// FanOut serves messages to subscribers over WebSocket and server-sent events
+// on the relay itself, so builders can follow the bulletin board without a
+// broker. Subscribers pick topics with MQTT filters in the topic query
+// parameter, e.g. ?topic=topic/HighestBid/v1&topic=topic/+/v1/slot/#, all
+// topics without one. Retained messages matching the filters are sent first.
+// A subscriber that can't keep up loses its oldest queued messages.
+type FanOut struct {
+	buffer         int
+	maxSubscribers int
+	log            *logrus.Entry
+
+	subscribers map[*fanOutSubscriber]struct{}
+	retained    map[bulletinBoardTypes.MQTTTopic][]byte
+	mu          sync.Mutex
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// FanOutMessage is what subscribers receive, as a JSON text frame over
+// WebSocket and as an event named after the topic over SSE. Data is the
+// payload, hex encoded on SSZ topics.
+type FanOutMessage struct {
+	Topic string `json:"topic"`
+	Data  string `json:"data"`
+}
+
+type fanOutSubscriber struct {
+	filters  []string
+	messages chan FanOutMessage
+}
+
+func NewFanOut(buffer int, maxSubscribers int, log *logrus.Entry) *FanOut {
+	return &FanOut{
+		buffer:         buffer,
+		maxSubscribers: maxSubscribers,
+		log:            log.WithField("publisher", PublisherFanOut),
+		subscribers:    make(map[*fanOutSubscriber]struct{}),
+		retained:       make(map[bulletinBoardTypes.MQTTTopic][]byte),
+		closed:         make(chan struct{}),
+	}
+}
+
+func (fanOut *FanOut) Publish(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	message := FanOutMessage{Topic: string(topic), Data: fanOutData(topic, payload)}
+
+	fanOut.mu.Lock()
+	defer fanOut.mu.Unlock()
+
+	for subscriber := range fanOut.subscribers {
+		if subscriber.matches(topic) && dropOldest(subscriber.messages, message) {
+			fanOut.log.WithField("topic", topic).Debug("Subscriber Behind, Dropped Oldest")
+		}
+	}
+	return nil
+}
+
+func (fanOut *FanOut) Retain(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	fanOut.mu.Lock()
+	if len(payload) == 0 {
+		delete(fanOut.retained, topic)
+		fanOut.mu.Unlock()
+		return nil
+	}
+	fanOut.retained[topic] = append([]byte{}, payload...)
+	fanOut.mu.Unlock()
+
+	return fanOut.Publish(topic, payload)
+}
+
+func (fanOut *FanOut) Healthy() error {
+	return nil
+}
+
+// Close ends every stream, the relay calls it when its server shuts down
+func (fanOut *FanOut) Close() {
+	fanOut.closeOnce.Do(func() {
+		close(fanOut.closed)
+	})
+}
+
+// Subscribers is the number of open streams
+func (fanOut *FanOut) Subscribers() int {
+	fanOut.mu.Lock()
+	defer fanOut.mu.Unlock()
+	return len(fanOut.subscribers)
+}
+
+func (fanOut *FanOut) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	filters := req.URL.Query()["topic"]
+	if len(filters) == 0 {
+		filters = []string{"#"}
+	}
+	for _, filter := range filters {
+		if !ValidTopicFilter(filter) {
+			http.Error(w, fmt.Sprintf("invalid topic filter %s", filter), http.StatusBadRequest)
+			return
+		}
+	}
+
+	subscriber, err := fanOut.subscribe(filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer fanOut.unsubscribe(subscriber)
+
+	if websocket.IsWebSocketUpgrade(req) {
+		fanOut.serveWebSocket(w, req, subscriber)
+		return
+	}
+	fanOut.serveEvents(w, req, subscriber)
+}
+
+func (fanOut *FanOut) subscribe(filters []string) (*fanOutSubscriber, error) {
+	fanOut.mu.Lock()
+	defer fanOut.mu.Unlock()
+
+	select {
+	case <-fanOut.closed:
+		return nil, ErrFanOutClosed
+	default:
+	}
+	if fanOut.maxSubscribers > 0 && len(fanOut.subscribers) >= fanOut.maxSubscribers {
+		return nil, ErrFanOutFull
+	}
+
+	subscriber := &fanOutSubscriber{
+		filters:  filters,
+		messages: make(chan FanOutMessage, fanOut.buffer),
+	}
+	for topic, payload := range fanOut.retained {
+		if subscriber.matches(topic) {
+			dropOldest(subscriber.messages, FanOutMessage{Topic: string(topic), Data: fanOutData(topic, payload)})
+		}
+	}
+	fanOut.subscribers[subscriber] = struct{}{}
+	return subscriber, nil
+}
+
+func (fanOut *FanOut) unsubscribe(subscriber *fanOutSubscriber) {
+	fanOut.mu.Lock()
+	delete(fanOut.subscribers, subscriber)
+	fanOut.mu.Unlock()
+}
+
+func (fanOut *FanOut) serveEvents(w http.ResponseWriter, req *http.Request, subscriber *fanOutSubscriber) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	// The stream outlives the write timeout of the server
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		fanOut.log.WithError(err).Debug("Could Not Clear Write Deadline")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(fanOutKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case message := <-subscriber.messages:
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Topic, message.Data); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case <-req.Context().Done():
+			return
+		case <-fanOut.closed:
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func (fanOut *FanOut) serveWebSocket(w http.ResponseWriter, req *http.Request, subscriber *fanOutSubscriber) {
+	conn, err := fanOutUpgrader.Upgrade(w, req, nil)
+	if err != nil {
+		// Upgrade already responded to the subscriber
+		return
+	}
+	defer conn.Close()
+
+	// The server's read timeout doesn't apply to the stream, subscribers only
+	// listen and reading just handles their pings and close
+	conn.SetReadDeadline(time.Time{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(fanOutKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case message := <-subscriber.messages:
+			conn.SetWriteDeadline(time.Now().Add(fanOutWriteTimeout))
+			if err := conn.WriteJSON(message); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(fanOutWriteTimeout)); err != nil {
+				return
+			}
+		case <-done:
+			return
+		case <-fanOut.closed:
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "relay shutting down"), time.Now().Add(fanOutWriteTimeout))
+			return
+		}
+	}
+}
+
+func (subscriber *fanOutSubscriber) matches(topic bulletinBoardTypes.MQTTTopic) bool {
+	for _, filter := range subscriber.filters {
+		if TopicMatches(filter, string(topic)) {
+			return true
+		}
+	}
+	return false
+}
+
+func fanOutData(topic bulletinBoardTypes.MQTTTopic, payload []byte) string {
+	if strings.HasSuffix(string(topic), "/ssz") {
+		return hexutil.Encode(payload)
+	}
+	return string(payload)
+}
+
+// ValidTopicFilter reports whether filter is a valid MQTT topic filter, +
+// matches one level and a trailing # any number of levels
+func ValidTopicFilter(filter string) bool {
+	if filter == "" {
+		return false
+	}
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		if strings.Contains(level, "#") && (level != "#" || i != len(levels)-1) {
+			return false
+		}
+		if strings.Contains(level, "+") && level != "+" {
+			return false
+		}
+	}
+	return true
+}
+
+// TopicMatches reports whether the topic matches the MQTT topic filter
+func TopicMatches(filter string, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
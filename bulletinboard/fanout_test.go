@@ -0,0 +1,220 @@
+package bulletinboard
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/websocket"
+)
+
+func testFanOut(t *testing.T, buffer int, maxSubscribers int) (*FanOut, *httptest.Server) {
+	t.Helper()
+	fanOut := NewFanOut(buffer, maxSubscribers, testLog())
+	server := httptest.NewServer(fanOut)
+	t.Cleanup(func() {
+		fanOut.Close()
+		server.Close()
+	})
+	return fanOut, server
+}
+
+// sseEvents reads the events of a server-sent events stream, skipping comments
+func sseEvents(t *testing.T, url string) (<-chan FanOutMessage, *http.Response) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	events := make(chan FanOutMessage, 16)
+	go func() {
+		defer close(events)
+		var event FanOutMessage
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event.Topic = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.Data = strings.TrimPrefix(line, "data: ")
+			case line == "" && event.Topic != "":
+				events <- event
+				event = FanOutMessage{}
+			}
+		}
+	}()
+	return events, resp
+}
+
+func nextMessage(t *testing.T, messages <-chan FanOutMessage) FanOutMessage {
+	t.Helper()
+	select {
+	case message, ok := <-messages:
+		if !ok {
+			t.Fatal("stream ended")
+		}
+		return message
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message on the stream")
+	}
+	return FanOutMessage{}
+}
+
+func TestFanOutServerSentEvents(t *testing.T) {
+	fanOut, server := testFanOut(t, 16, 0)
+	fanOut.Retain("topic/HighestBid/v1/slot/1", []byte(`{"slot":"1"}`))
+	fanOut.Retain("topic/BountyBidWon/v1/slot/1", []byte(`{"slot":"1"}`))
+
+	events, resp := sseEvents(t, server.URL+"?topic=topic/HighestBid/v1&topic=topic/HighestBid/v1/%2B/%23")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("stream responded %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// the retained message of the filters comes first
+	if message := nextMessage(t, events); message.Topic != "topic/HighestBid/v1/slot/1" || message.Data != `{"slot":"1"}` {
+		t.Fatalf("first event %+v, want the retained message", message)
+	}
+
+	fanOut.Publish("topic/BountyBidWon/v1", []byte(`{"slot":"2"}`))
+	fanOut.Publish("topic/HighestBid/v1", []byte(`{"slot":"2"}`))
+	if message := nextMessage(t, events); message.Topic != "topic/HighestBid/v1" || message.Data != `{"slot":"2"}` {
+		t.Fatalf("event %+v, want only the subscribed topic", message)
+	}
+
+	// closing the fan-out ends the stream
+	fanOut.Close()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("event after close")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream still open after close")
+	}
+}
+
+func TestFanOutWebSocket(t *testing.T) {
+	fanOut, server := testFanOut(t, 16, 0)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?topic=topic/%2B/v1/ssz"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	eventually(t, func() bool { return fanOut.Subscribers() == 1 }, "websocket not subscribed")
+
+	payload := []byte{0x01, 0x02, 0xff}
+	fanOut.Publish("topic/HighestBid/v1", []byte(`{"slot":"1"}`))
+	fanOut.Publish("topic/HighestBid/v1/ssz", payload)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var message FanOutMessage
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Topic != "topic/HighestBid/v1/ssz" || message.Data != hexutil.Encode(payload) {
+		t.Fatalf("received %+v, want the hex encoded SSZ message", message)
+	}
+
+	// the relay shutting down closes the stream with going away
+	fanOut.Close()
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("error %v, want going away", err)
+	}
+	eventually(t, func() bool { return fanOut.Subscribers() == 0 }, "closed websocket still subscribed")
+}
+
+func TestFanOutSlowSubscriber(t *testing.T) {
+	fanOut := NewFanOut(2, 0, testLog())
+	slow, err := fanOut.subscribe([]string{"#"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, err := fanOut.subscribe([]string{"#"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// publishing never waits for a subscriber that doesn't read
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, payload := range []string{"1", "2", "3", "4"} {
+			fanOut.Publish("topic/HighestBid/v1", []byte(payload))
+			<-fast.messages
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("publish blocked on a slow subscriber")
+	}
+
+	if first, second := <-slow.messages, <-slow.messages; first.Data != "3" || second.Data != "4" {
+		t.Fatalf("slow subscriber kept %s and %s, want the latest 3 and 4", first.Data, second.Data)
+	}
+}
+
+func TestFanOutRejectsSubscribers(t *testing.T) {
+	fanOut, server := testFanOut(t, 16, 1)
+
+	resp, err := http.Get(server.URL + "?topic=topic/%23/v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid filter responded %d", resp.StatusCode)
+	}
+
+	sseEvents(t, server.URL)
+	eventually(t, func() bool { return fanOut.Subscribers() == 1 }, "stream not subscribed")
+	resp, err = http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("subscriber over the limit responded %d", resp.StatusCode)
+	}
+
+	fanOut.Close()
+	if _, err := fanOut.subscribe([]string{"#"}); err != ErrFanOutClosed {
+		t.Fatalf("error %v after close, want %v", err, ErrFanOutClosed)
+	}
+}
+
+func TestTopicMatches(t *testing.T) {
+	tests := []struct {
+		filter, topic string
+		matches       bool
+	}{
+		{"topic/HighestBid/v1", "topic/HighestBid/v1", true},
+		{"topic/HighestBid/v1", "topic/HighestBid/v1/ssz", false},
+		{"topic/+/v1", "topic/BountyBidWon/v1", true},
+		{"topic/+/v1", "topic/BountyBidWon/v1/ssz", false},
+		{"topic/HighestBid/#", "topic/HighestBid/v1/slot/1", true},
+		{"topic/HighestBid/#", "topic/HighestBid", true},
+		{"#", "topic/HighestBid/v1", true},
+		{"topic/HighestBid/v1/slot/+", "topic/HighestBid/v1/slot", false},
+	}
+	for _, test := range tests {
+		if matches := TopicMatches(test.filter, test.topic); matches != test.matches {
+			t.Fatalf("%s matches %s %t, want %t", test.filter, test.topic, matches, test.matches)
+		}
+	}
+
+	for filter, valid := range map[string]bool{"#": true, "topic/+/v1/#": true, "": false, "topic/#/v1": false, "topic/High+": false, "topic/v1#": false} {
+		if ValidTopicFilter(filter) != valid {
+			t.Fatalf("filter %q valid %t, want %t", filter, !valid, valid)
+		}
+	}
+}
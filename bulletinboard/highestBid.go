@@ -1,91 +1,144 @@
 package bulletinboard
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
 )
 
-func (relayClient *RelayMQTT) HighestBidPublish() {
+func (relayClient *BulletinBoard) HighestBidPublish(ctx context.Context) {
 
 	for {
 		select {
 		case bid := <-relayClient.Channel.HighestBidChannel:
-			relayClient.Log.Info("Publish Highest Bid Run")
-			publishBid := fmt.Sprintf("slot: %d, builder: %s, amount: %s", bid.Slot, bid.BuilderPublicKey, bid.Amount)
-
-			err := relayClient.publishBulletinBoard(HighestBidTopic, publishBid)
-			if err != nil {
-				relayClient.Log.WithError(err).Errorf("Couldn't Update Bid For Proposer %s, Slot %d", bid.BuilderPublicKey, bid.Slot)
-			} else {
-				relayClient.Log.WithFields(logrus.Fields{
-					"slot":  bid.Slot,
-					"value": bid.Amount,
-				}).Info("Highest Bid Published")
+			relayClient.publishHighestBid(bid)
+		case <-ctx.Done():
+			for {
+				select {
+				case bid := <-relayClient.Channel.HighestBidChannel:
+					relayClient.publishHighestBid(bid)
+				default:
+					return
+				}
 			}
 		}
 	}
 }
 
-func (relayClient *RelayMQTT) SlotHeaderRequested() {
+func (relayClient *BulletinBoard) publishHighestBid(bid HighestBid) {
+	relayClient.Log.Info("Publish Highest Bid Run")
+	publishBid := fmt.Sprintf("slot: %d, builder: %s, amount: %d", bid.Slot, bid.Builder, bid.Value)
+
+	err := relayClient.publishMessage(HighestBidTopic, publishBid, relayClient.highestBidMessage(bid))
+	if err != nil {
+		relayClient.Log.WithError(err).Errorf("Couldn't Update Bid For Proposer %s, Slot %d", bid.Builder, bid.Slot)
+	} else {
+		relayClient.Log.WithFields(logrus.Fields{
+			"slot":  bid.Slot,
+			"value": bid.Value,
+		}).Info("Highest Bid Published")
+	}
+}
+
+func (relayClient *BulletinBoard) SlotHeaderRequested(ctx context.Context) {
 
 	for {
 		select {
 		case slot := <-relayClient.Channel.ProposerHeaderChannel:
-			relayClient.Log.Info("Proposer Header Request Run")
-			proposerRequest := fmt.Sprintf("slot: %d, proposer: %s, timestamp: %d", slot.Slot, slot.Proposer, slot.Timestamp)
-
-			err := relayClient.publishBulletinBoard(ProposerRequestTopic, proposerRequest)
-			if err != nil {
-				relayClient.Log.WithError(err).Errorf("Couldn't Update Proposer Request For Proposer %s, Slot %d", slot.Proposer, slot.Slot)
-			} else {
-				relayClient.Log.WithFields(logrus.Fields{
-					"slot":     slot.Slot,
-					"proposer": slot.Proposer,
-				}).Info("Proposer Slot Request")
+			relayClient.publishSlotHeaderRequest(slot)
+		case <-ctx.Done():
+			for {
+				select {
+				case slot := <-relayClient.Channel.ProposerHeaderChannel:
+					relayClient.publishSlotHeaderRequest(slot)
+				default:
+					return
+				}
 			}
 		}
 	}
 }
 
-func (relayClient *RelayMQTT) SlotPayloadRequested() {
+func (relayClient *BulletinBoard) publishSlotHeaderRequest(slot HeaderRequest) {
+	relayClient.Log.Info("Proposer Header Request Run")
+	proposerRequest := fmt.Sprintf("slot: %d, proposer: %s, timestamp: %d", slot.Slot, slot.Proposer, slot.Timestamp)
+
+	err := relayClient.publishMessage(ProposerRequestTopic, proposerRequest, relayClient.headerRequestedMessage(slot))
+	if err != nil {
+		relayClient.Log.WithError(err).Errorf("Couldn't Update Proposer Request For Proposer %s, Slot %d", slot.Proposer, slot.Slot)
+	} else {
+		relayClient.Log.WithFields(logrus.Fields{
+			"slot":     slot.Slot,
+			"proposer": slot.Proposer,
+		}).Info("Proposer Slot Request")
+	}
+}
+
+func (relayClient *BulletinBoard) SlotPayloadRequested(ctx context.Context) {
 
 	for {
 		select {
 		case slot := <-relayClient.Channel.SlotPayloadChannel:
-			relayClient.Log.Info("Proposer Payload Request Run")
-			proposerRequest := fmt.Sprintf("slot: %d, proposer: %s", slot.Slot, slot.Proposer)
-
-			err := relayClient.publishBulletinBoard(ProposerPayloadRequestTopic, proposerRequest)
-			if err != nil {
-				relayClient.Log.WithError(err).Errorf("Couldn't Update Proposer Request For Proposer %s, Slot %d", slot.Proposer, slot.Slot)
-			} else {
-				relayClient.Log.WithFields(logrus.Fields{
-					"slot":     slot.Slot,
-					"proposer": slot.Proposer,
-				}).Info("Slot Payload Requested")
+			relayClient.publishSlotPayloadRequest(slot)
+		case <-ctx.Done():
+			for {
+				select {
+				case slot := <-relayClient.Channel.SlotPayloadChannel:
+					relayClient.publishSlotPayloadRequest(slot)
+				default:
+					return
+				}
 			}
 		}
 	}
 }
 
-func (relayClient *RelayMQTT) BountyBidWon() {
+func (relayClient *BulletinBoard) publishSlotPayloadRequest(slot PayloadRequest) {
+	relayClient.Log.Info("Proposer Payload Request Run")
+	proposerRequest := fmt.Sprintf("slot: %d, proposer: %s", slot.Slot, slot.Proposer)
+
+	err := relayClient.publishMessage(ProposerPayloadRequestTopic, proposerRequest, relayClient.payloadRequestedMessage(slot))
+	if err != nil {
+		relayClient.Log.WithError(err).Errorf("Couldn't Update Proposer Request For Proposer %s, Slot %d", slot.Proposer, slot.Slot)
+	} else {
+		relayClient.Log.WithFields(logrus.Fields{
+			"slot":     slot.Slot,
+			"proposer": slot.Proposer,
+		}).Info("Slot Payload Requested")
+	}
+}
+
+func (relayClient *BulletinBoard) BountyBidWon(ctx context.Context) {
 
 	for {
 		select {
 		case slot := <-relayClient.Channel.BountyBidChannel:
-			relayClient.Log.Info("Proposer Payload Request Run")
-			builderBountyBid := fmt.Sprintf("slot: %d, builder: %s", slot.Slot, slot.Builder)
-
-			err := relayClient.publishBulletinBoard(BountyBidTopic, builderBountyBid)
-			if err != nil {
-				relayClient.Log.WithError(err).Errorf("Couldn't Update Bounty Bid For Builder %s, Slot %d", slot.Builder, slot.Slot)
-			} else {
-				relayClient.Log.WithFields(logrus.Fields{
-					"slot":    slot.Slot,
-					"builder": slot.Builder,
-				}).Info("Bounty Bid Won")
+			relayClient.publishBountyBidWon(slot)
+		case <-ctx.Done():
+			for {
+				select {
+				case slot := <-relayClient.Channel.BountyBidChannel:
+					relayClient.publishBountyBidWon(slot)
+				default:
+					return
+				}
 			}
 		}
 	}
 }
+
+func (relayClient *BulletinBoard) publishBountyBidWon(slot BountyBid) {
+	relayClient.Log.Info("Proposer Payload Request Run")
+	builderBountyBid := fmt.Sprintf("slot: %d, builder: %s", slot.Slot, slot.Builder)
+
+	err := relayClient.publishMessage(BountyBidTopic, builderBountyBid, relayClient.bountyBidWonMessage(slot))
+	if err != nil {
+		relayClient.Log.WithError(err).Errorf("Couldn't Update Bounty Bid For Builder %s, Slot %d", slot.Builder, slot.Slot)
+	} else {
+		relayClient.Log.WithFields(logrus.Fields{
+			"slot":    slot.Slot,
+			"builder": slot.Builder,
+		}).Info("Bounty Bid Won")
+	}
+}
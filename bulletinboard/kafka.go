@@ -0,0 +1,126 @@
+package bulletinboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+)
+
+// KafkaPublisher produces to Kafka through a REST proxy speaking the v2 API,
+// e.g. the Confluent REST Proxy or the Redpanda HTTP proxy. Kafka topics are
+// the topics with dots instead of slashes, e.g. topic.HighestBid.v1. Kafka
+// keeps every message anyway, so nothing is retained per slot.
+type KafkaPublisher struct {
+	url    string
+	client *http.Client
+
+	// err is the result of the last produce request
+	err error
+	mu  sync.Mutex
+}
+
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+// kafkaRecord is a record of the binary embedded format, Value is base64
+// encoded by encoding/json
+type kafkaRecord struct {
+	Value []byte `json:"value"`
+}
+
+type kafkaOffsets struct {
+	Offsets []struct {
+		Error *string `json:"error"`
+	} `json:"offsets"`
+}
+
+func NewKafkaPublisher(restURL string, timeout time.Duration) (*KafkaPublisher, error) {
+	parsed, err := url.Parse(restURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("Kafka REST proxy url must start with http:// or https://, got %s", restURL)
+	}
+
+	return &KafkaPublisher{
+		url:    strings.TrimSuffix(restURL, "/"),
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// KafkaTopic is the Kafka topic of the topic
+func KafkaTopic(topic bulletinBoardTypes.MQTTTopic) string {
+	return strings.ReplaceAll(string(topic), "/", ".")
+}
+
+func (publisher *KafkaPublisher) Publish(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	err := publisher.produce(KafkaTopic(topic), payload)
+
+	publisher.mu.Lock()
+	publisher.err = err
+	publisher.mu.Unlock()
+	return err
+}
+
+func (publisher *KafkaPublisher) produce(topic string, payload []byte) error {
+	body, err := json.Marshal(kafkaRecords{Records: []kafkaRecord{{Value: payload}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, publisher.url+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.binary.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := publisher.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kafka rest proxy returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	offsets := new(kafkaOffsets)
+	if err := json.Unmarshal(respBody, offsets); err != nil {
+		return err
+	}
+	for _, offset := range offsets.Offsets {
+		if offset.Error != nil {
+			return fmt.Errorf("kafka rejected record on %s: %s", topic, *offset.Error)
+		}
+	}
+	return nil
+}
+
+func (publisher *KafkaPublisher) Retain(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	return nil
+}
+
+func (publisher *KafkaPublisher) Healthy() error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	return publisher.err
+}
+
+func (publisher *KafkaPublisher) Close() {
+	publisher.client.CloseIdleConnections()
+}
@@ -0,0 +1,118 @@
+package bulletinboard
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// kafkaRequest is a produce request the REST proxy got
+type kafkaRequest struct {
+	path        string
+	contentType string
+	accept      string
+	records     kafkaRecords
+}
+
+// testKafkaProxy answers every produce request with status and body
+func testKafkaProxy(t *testing.T, status int, body string) (*httptest.Server, chan kafkaRequest) {
+	t.Helper()
+	requests := make(chan kafkaRequest, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := kafkaRequest{path: r.URL.EscapedPath(), contentType: r.Header.Get("Content-Type"), accept: r.Header.Get("Accept")}
+		json.NewDecoder(r.Body).Decode(&request.records)
+		requests <- request
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestKafkaPublish(t *testing.T) {
+	server, requests := testKafkaProxy(t, http.StatusOK, `{"offsets": [{"partition": 0, "offset": 7}]}`)
+	publisher, err := NewKafkaPublisher(server.URL+"/", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer publisher.Close()
+
+	if err := publisher.Publish(VersionedTopic(HighestBidTopic), []byte(`{"slot": "7"}`)); err != nil {
+		t.Fatal(err)
+	}
+	request := <-requests
+	if request.path != "/topics/topic.HighestBid.v1" || request.contentType != "application/vnd.kafka.binary.v2+json" || request.accept != "application/vnd.kafka.v2+json" {
+		t.Fatalf("request %+v", request)
+	}
+	if len(request.records.Records) != 1 || string(request.records.Records[0].Value) != `{"slot": "7"}` {
+		t.Fatalf("records %+v, want the message", request.records)
+	}
+	if err := publisher.Healthy(); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is retained
+	if err := publisher.Retain(SlotTopic(HighestBidTopic, 7), []byte("bid")); err != nil || len(requests) != 0 {
+		t.Fatalf("retain error %v, %d requests", err, len(requests))
+	}
+}
+
+func TestKafkaPublishErrors(t *testing.T) {
+	responses := map[string]struct {
+		status int
+		body   string
+	}{
+		"proxy error":     {http.StatusInternalServerError, `{"error_code": 50001}`},
+		"record rejected": {http.StatusOK, `{"offsets": [{"error": "topic authorization failed"}]}`},
+		"invalid answer":  {http.StatusOK, `offsets`},
+	}
+	for name, response := range responses {
+		server, _ := testKafkaProxy(t, response.status, response.body)
+		publisher, err := NewKafkaPublisher(server.URL, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := publisher.Publish(HighestBidTopic, []byte("bid")); err == nil {
+			t.Fatalf("%s: published", name)
+		}
+		if err := publisher.Healthy(); err == nil {
+			t.Fatalf("%s: healthy after a failed publish", name)
+		}
+	}
+
+	// a successful publish makes the publisher healthy again
+	var rejecting atomic.Bool
+	rejecting.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if rejecting.Load() {
+			io.WriteString(w, `{"offsets": [{"error": "topic authorization failed"}]}`)
+			return
+		}
+		io.WriteString(w, `{"offsets": [{"offset": 1}]}`)
+	}))
+	defer server.Close()
+	publisher, err := NewKafkaPublisher(server.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := publisher.Publish(HighestBidTopic, []byte("bid")); err == nil || !strings.Contains(err.Error(), "topic authorization failed") {
+		t.Fatalf("error %v, want the rejection", err)
+	}
+	rejecting.Store(false)
+	if err := publisher.Publish(HighestBidTopic, []byte("bid")); err != nil {
+		t.Fatal(err)
+	}
+	if err := publisher.Healthy(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewKafkaPublisher("kafka://localhost:9092", time.Second); err == nil {
+		t.Fatal("publisher created for a broker url")
+	}
+}
@@ -0,0 +1,90 @@
+package bulletinboard
+
+import (
+	"sync"
+
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+)
+
+// MemoryPublisher keeps the published messages in memory, for tests and for
+// running a relay without any transport
+type MemoryPublisher struct {
+	limit int
+
+	messages []MemoryMessage
+	retained map[bulletinBoardTypes.MQTTTopic][]byte
+	mu       sync.Mutex
+}
+
+// MemoryMessage is a message kept by the MemoryPublisher, Retained is set for
+// retained messages including the empty ones removing a retained message
+type MemoryMessage struct {
+	Topic    bulletinBoardTypes.MQTTTopic
+	Payload  []byte
+	Retained bool
+}
+
+// NewMemoryPublisher keeps the last limit messages, all of them if limit is 0
+func NewMemoryPublisher(limit int) *MemoryPublisher {
+	return &MemoryPublisher{
+		limit:    limit,
+		retained: make(map[bulletinBoardTypes.MQTTTopic][]byte),
+	}
+}
+
+func (publisher *MemoryPublisher) Publish(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	publisher.add(MemoryMessage{Topic: topic, Payload: append([]byte{}, payload...)})
+	return nil
+}
+
+func (publisher *MemoryPublisher) Retain(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	if len(payload) == 0 {
+		delete(publisher.retained, topic)
+	} else {
+		publisher.retained[topic] = append([]byte{}, payload...)
+	}
+	publisher.add(MemoryMessage{Topic: topic, Payload: append([]byte{}, payload...), Retained: true})
+	return nil
+}
+
+func (publisher *MemoryPublisher) add(message MemoryMessage) {
+	publisher.messages = append(publisher.messages, message)
+	if publisher.limit > 0 && len(publisher.messages) > publisher.limit {
+		publisher.messages = publisher.messages[len(publisher.messages)-publisher.limit:]
+	}
+}
+
+// Messages returns the kept messages, oldest first
+func (publisher *MemoryPublisher) Messages() []MemoryMessage {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	return append([]MemoryMessage{}, publisher.messages...)
+}
+
+// Retained returns the retained message of the topic
+func (publisher *MemoryPublisher) Retained(topic bulletinBoardTypes.MQTTTopic) ([]byte, bool) {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	payload, ok := publisher.retained[topic]
+	return payload, ok
+}
+
+// Reset forgets all messages
+func (publisher *MemoryPublisher) Reset() {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	publisher.messages = nil
+	publisher.retained = make(map[bulletinBoardTypes.MQTTTopic][]byte)
+}
+
+func (publisher *MemoryPublisher) Healthy() error {
+	return nil
+}
+
+func (publisher *MemoryPublisher) Close() {}
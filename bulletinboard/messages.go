@@ -0,0 +1,414 @@
+package bulletinboard
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ssz "github.com/ferranbt/fastssz"
+
+	"github.com/pon-pbs/bbRelay/signing"
+)
+
+// MessageVersion is appended to the topics the structured messages are
+// published on, e.g. topic/HighestBid/v1. Messages of a newer version go to
+// new topics so subscribers can migrate at their own pace.
+const MessageVersion = "v1"
+
+var (
+	ErrMessageValue     = errors.New("message value doesn't fit 256 bits")
+	ErrUntrustedRelay   = errors.New("message not published by a trusted relay")
+	ErrMessageSignature = errors.New("invalid message signature")
+	ErrMessageBool      = errors.New("message bool isn't 0 or 1")
+)
+
+// Message is implemented by all structured messages. The relay signs the
+// hash tree root of the message, which leaves out the signature itself.
+type Message interface {
+	signing.HashTreeRoot
+	MarshalSSZ() ([]byte, error)
+	UnmarshalSSZ(buf []byte) error
+	Header() *MessageHeader
+}
+
+// BulletinBoardDomain is the domain messages are signed with on the network
+// of the genesis fork version
+func BulletinBoardDomain(genesisForkVersion string) (signing.Domain, error) {
+	return signing.ComputeDomain(signing.DomainTypeAppBulletinBoard, genesisForkVersion, signing.Root{}.String())
+}
+
+// VerifyMessage checks the message was signed by one of the trusted relay
+// keys, relays announce their keys on /relay/config
+func VerifyMessage(message Message, domain signing.Domain, trusted ...phase0.BLSPubKey) error {
+	header := message.Header()
+
+	relayTrusted := false
+	for _, key := range trusted {
+		relayTrusted = relayTrusted || key == header.Relay
+	}
+	if !relayTrusted {
+		return fmt.Errorf("%w: %s", ErrUntrustedRelay, header.Relay.String())
+	}
+
+	ok, err := signing.VerifySignature(message, domain, header.Relay[:], header.Signature[:])
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrMessageSignature, err.Error())
+	}
+	if !ok {
+		return ErrMessageSignature
+	}
+	return nil
+}
+
+// VersionedTopic is the topic structured JSON messages of the topic are published on
+func VersionedTopic(topic bulletinBoardTypes.MQTTTopic) bulletinBoardTypes.MQTTTopic {
+	return bulletinBoardTypes.MQTTTopic(fmt.Sprintf("%s/%s", topic, MessageVersion))
+}
+
+// SSZTopic is the topic SSZ encoded messages of the topic are published on
+func SSZTopic(topic bulletinBoardTypes.MQTTTopic) bulletinBoardTypes.MQTTTopic {
+	return bulletinBoardTypes.MQTTTopic(fmt.Sprintf("%s/%s/ssz", topic, MessageVersion))
+}
+
+// NewMessage returns an empty structured message of the topic to decode
+// into, nil for topics without structured messages
+func NewMessage(topic bulletinBoardTypes.MQTTTopic) Message {
+	switch topic {
+	case HighestBidTopic:
+		return new(HighestBidMessage)
+	case ProposerRequestTopic:
+		return new(HeaderRequestedMessage)
+	case ProposerPayloadRequestTopic:
+		return new(PayloadRequestedMessage)
+	case BountyBidTopic:
+		return new(BountyBidWonMessage)
+	case AuctionOpenedTopic:
+		return new(AuctionOpenedMessage)
+	case BidAcceptedTopic:
+		return new(BidAcceptedMessage)
+	case HeaderServedTopic:
+		return new(HeaderServedMessage)
+	case PayloadServedTopic:
+		return new(PayloadServedMessage)
+	case BlockPublishedTopic:
+		return new(BlockPublishedMessage)
+	}
+	return nil
+}
+
+// MessageHeader is shared by all structured messages. Timestamp is in unix
+// milliseconds and Relay is the BLS public key the relay signs the slot with.
+type MessageHeader struct {
+	Version   string              `json:"version"`
+	Slot      uint64              `json:"slot,string"`
+	BlockHash phase0.Hash32       `json:"block_hash"`
+	Timestamp uint64              `json:"timestamp,string"`
+	Relay     phase0.BLSPubKey    `json:"relay"`
+	Signature phase0.BLSSignature `json:"signature"`
+}
+
+func (header *MessageHeader) Header() *MessageHeader {
+	return header
+}
+
+// HighestBidMessage is published on topic/HighestBid/v1 when the highest bid
+// of a slot changes, Value is in wei
+type HighestBidMessage struct {
+	MessageHeader
+	Builder common.Address `json:"builder"`
+	Value   string         `json:"value"`
+}
+
+// HeaderRequestedMessage is published on topic/ProposerSlotHeaderRequest/v1
+// when the proposer got the header of the block
+type HeaderRequestedMessage struct {
+	MessageHeader
+	Proposer phase0.BLSPubKey `json:"proposer"`
+}
+
+// PayloadRequestedMessage is published on topic/ProposerPayloadRequest/v1
+// when the payload of the block was delivered to the proposer
+type PayloadRequestedMessage struct {
+	MessageHeader
+	Proposer phase0.BLSPubKey `json:"proposer"`
+}
+
+// BountyBidWonMessage is published on topic/BountyBidWon/v1
+type BountyBidWonMessage struct {
+	MessageHeader
+	Builder common.Address `json:"builder"`
+}
+
+func (relayClient *BulletinBoard) messageHeader(slot uint64, blockHash phase0.Hash32) MessageHeader {
+	return relayClient.messageHeaderAt(slot, blockHash, time.Now())
+}
+
+func (relayClient *BulletinBoard) messageHeaderAt(slot uint64, blockHash phase0.Hash32, at time.Time) MessageHeader {
+	return MessageHeader{
+		Version:   MessageVersion,
+		Slot:      slot,
+		BlockHash: blockHash,
+		Timestamp: uint64(at.UnixMilli()),
+		Relay:     relayClient.keys.SignerForSlot(slot).PublicKey(),
+	}
+}
+
+func (relayClient *BulletinBoard) highestBidMessage(bid HighestBid) *HighestBidMessage {
+	value := "0"
+	if bid.Value != nil {
+		value = bid.Value.String()
+	}
+	return &HighestBidMessage{
+		MessageHeader: relayClient.messageHeader(bid.Slot, bid.BlockHash),
+		Builder:       common.HexToAddress(bid.Builder),
+		Value:         value,
+	}
+}
+
+func (relayClient *BulletinBoard) headerRequestedMessage(request HeaderRequest) *HeaderRequestedMessage {
+	return &HeaderRequestedMessage{
+		MessageHeader: relayClient.messageHeader(request.Slot, request.BlockHash),
+		Proposer:      blsPubKey(request.Proposer),
+	}
+}
+
+func (relayClient *BulletinBoard) payloadRequestedMessage(request PayloadRequest) *PayloadRequestedMessage {
+	return &PayloadRequestedMessage{
+		MessageHeader: relayClient.messageHeader(request.Slot, request.BlockHash),
+		Proposer:      blsPubKey(request.Proposer),
+	}
+}
+
+func (relayClient *BulletinBoard) bountyBidWonMessage(bid BountyBid) *BountyBidWonMessage {
+	return &BountyBidWonMessage{
+		MessageHeader: relayClient.messageHeader(bid.Slot, bid.BlockHash),
+		Builder:       common.HexToAddress(bid.Builder),
+	}
+}
+
+func blsPubKey(pubkeyHex string) (pubkey phase0.BLSPubKey) {
+	pubkeyBytes, err := hexutil.Decode(pubkeyHex)
+	if err == nil {
+		copy(pubkey[:], pubkeyBytes)
+	}
+	return pubkey
+}
+
+// SSZ encoding. Every message is a fixed size container of its header
+// fields followed by its own fields, the version is implied by the topic:
+//
+//	MessageHeader:           slot uint64, block_hash Bytes32, timestamp uint64, relay Bytes48
+//	HighestBidMessage:       MessageHeader, builder Bytes20, value uint256
+//	HeaderRequestedMessage:  MessageHeader, proposer Bytes48
+//	PayloadRequestedMessage: MessageHeader, proposer Bytes48
+//	BountyBidWonMessage:     MessageHeader, builder Bytes20
+//
+// The hash tree root of the container {message_type uint64, fields of the
+// message} is signed, the message type keeps a signature from being valid for
+// a message of another topic with the same fields. It isn't sent, the topic
+// implies it. On the wire the message is sent as the container
+// {message, signature Bytes96}, which encodes as the message followed by the
+// signature.
+
+// message types of the signed containers, never reuse one
+const (
+	messageTypeHighestBid uint64 = iota + 1
+	messageTypeHeaderRequested
+	messageTypePayloadRequested
+	messageTypeBountyBidWon
+	messageTypeAuctionOpened
+	messageTypeBidAccepted
+	messageTypeHeaderServed
+	messageTypePayloadServed
+	messageTypeBlockPublished
+)
+
+const (
+	messageHeaderSSZSize   = 8 + 32 + 8 + 48
+	highestBidSSZSize      = messageHeaderSSZSize + 20 + 32 + 96
+	proposerMessageSSZSize = messageHeaderSSZSize + 48 + 96
+	bountyBidWonSSZSize    = messageHeaderSSZSize + 20 + 96
+)
+
+func (header *MessageHeader) marshalSSZTo(dst []byte) []byte {
+	dst = ssz.MarshalUint64(dst, header.Slot)
+	dst = append(dst, header.BlockHash[:]...)
+	dst = ssz.MarshalUint64(dst, header.Timestamp)
+	return append(dst, header.Relay[:]...)
+}
+
+// unmarshalSSZ reads the header from the start and the signature from the end of buf
+func (header *MessageHeader) unmarshalSSZ(buf []byte) {
+	header.Version = MessageVersion
+	header.Slot = binary.LittleEndian.Uint64(buf[0:8])
+	copy(header.BlockHash[:], buf[8:40])
+	header.Timestamp = binary.LittleEndian.Uint64(buf[40:48])
+	copy(header.Relay[:], buf[48:96])
+	copy(header.Signature[:], buf[len(buf)-96:])
+}
+
+func (header *MessageHeader) hashTreeRootWith(hh ssz.HashWalker, messageType uint64) {
+	hh.PutUint64(messageType)
+	hh.PutUint64(header.Slot)
+	hh.PutBytes(header.BlockHash[:])
+	hh.PutUint64(header.Timestamp)
+	hh.PutBytes(header.Relay[:])
+}
+
+// valueSSZ encodes a decimal wei value as little endian uint256
+func valueSSZ(value string) ([]byte, error) {
+	v, ok := new(big.Int).SetString(value, 10)
+	if !ok || v.Sign() < 0 || v.BitLen() > 256 {
+		return nil, fmt.Errorf("%w: %s", ErrMessageValue, value)
+	}
+	return reverse(v.FillBytes(make([]byte, 32))), nil
+}
+
+func reverse(b []byte) []byte {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
+
+func (m *HighestBidMessage) MarshalSSZ() ([]byte, error) {
+	value, err := valueSSZ(m.Value)
+	if err != nil {
+		return nil, err
+	}
+	dst := m.marshalSSZTo(make([]byte, 0, highestBidSSZSize))
+	dst = append(dst, m.Builder[:]...)
+	dst = append(dst, value...)
+	return append(dst, m.Signature[:]...), nil
+}
+
+func (m *HighestBidMessage) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != highestBidSSZSize {
+		return ssz.ErrSize
+	}
+	m.unmarshalSSZ(buf)
+	copy(m.Builder[:], buf[messageHeaderSSZSize:messageHeaderSSZSize+20])
+	value := append([]byte{}, buf[messageHeaderSSZSize+20:messageHeaderSSZSize+52]...)
+	m.Value = new(big.Int).SetBytes(reverse(value)).String()
+	return nil
+}
+
+func (m *HighestBidMessage) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(m)
+}
+
+func (m *HighestBidMessage) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(m)
+}
+
+func (m *HighestBidMessage) HashTreeRootWith(hh ssz.HashWalker) error {
+	value, err := valueSSZ(m.Value)
+	if err != nil {
+		return err
+	}
+	indx := hh.Index()
+	m.hashTreeRootWith(hh, messageTypeHighestBid)
+	hh.PutBytes(m.Builder[:])
+	hh.PutBytes(value)
+	hh.Merkleize(indx)
+	return nil
+}
+
+func (m *HeaderRequestedMessage) MarshalSSZ() ([]byte, error) {
+	dst := m.marshalSSZTo(make([]byte, 0, proposerMessageSSZSize))
+	dst = append(dst, m.Proposer[:]...)
+	return append(dst, m.Signature[:]...), nil
+}
+
+func (m *HeaderRequestedMessage) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != proposerMessageSSZSize {
+		return ssz.ErrSize
+	}
+	m.unmarshalSSZ(buf)
+	copy(m.Proposer[:], buf[messageHeaderSSZSize:messageHeaderSSZSize+48])
+	return nil
+}
+
+func (m *HeaderRequestedMessage) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(m)
+}
+
+func (m *HeaderRequestedMessage) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(m)
+}
+
+func (m *HeaderRequestedMessage) HashTreeRootWith(hh ssz.HashWalker) error {
+	indx := hh.Index()
+	m.hashTreeRootWith(hh, messageTypeHeaderRequested)
+	hh.PutBytes(m.Proposer[:])
+	hh.Merkleize(indx)
+	return nil
+}
+
+func (m *PayloadRequestedMessage) MarshalSSZ() ([]byte, error) {
+	dst := m.marshalSSZTo(make([]byte, 0, proposerMessageSSZSize))
+	dst = append(dst, m.Proposer[:]...)
+	return append(dst, m.Signature[:]...), nil
+}
+
+func (m *PayloadRequestedMessage) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != proposerMessageSSZSize {
+		return ssz.ErrSize
+	}
+	m.unmarshalSSZ(buf)
+	copy(m.Proposer[:], buf[messageHeaderSSZSize:messageHeaderSSZSize+48])
+	return nil
+}
+
+func (m *PayloadRequestedMessage) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(m)
+}
+
+func (m *PayloadRequestedMessage) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(m)
+}
+
+func (m *PayloadRequestedMessage) HashTreeRootWith(hh ssz.HashWalker) error {
+	indx := hh.Index()
+	m.hashTreeRootWith(hh, messageTypePayloadRequested)
+	hh.PutBytes(m.Proposer[:])
+	hh.Merkleize(indx)
+	return nil
+}
+
+func (m *BountyBidWonMessage) MarshalSSZ() ([]byte, error) {
+	dst := m.marshalSSZTo(make([]byte, 0, bountyBidWonSSZSize))
+	dst = append(dst, m.Builder[:]...)
+	return append(dst, m.Signature[:]...), nil
+}
+
+func (m *BountyBidWonMessage) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != bountyBidWonSSZSize {
+		return ssz.ErrSize
+	}
+	m.unmarshalSSZ(buf)
+	copy(m.Builder[:], buf[messageHeaderSSZSize:messageHeaderSSZSize+20])
+	return nil
+}
+
+func (m *BountyBidWonMessage) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(m)
+}
+
+func (m *BountyBidWonMessage) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(m)
+}
+
+func (m *BountyBidWonMessage) HashTreeRootWith(hh ssz.HashWalker) error {
+	indx := hh.Index()
+	m.hashTreeRootWith(hh, messageTypeBountyBidWon)
+	hh.PutBytes(m.Builder[:])
+	hh.Merkleize(indx)
+	return nil
+}
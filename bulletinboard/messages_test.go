@@ -0,0 +1,74 @@
+package bulletinboard
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+)
+
+var messageTopics = []bulletinBoardTypes.MQTTTopic{
+	HighestBidTopic, ProposerRequestTopic, ProposerPayloadRequestTopic, BountyBidTopic,
+	AuctionOpenedTopic, BidAcceptedTopic, HeaderServedTopic, PayloadServedTopic, BlockPublishedTopic,
+}
+
+func TestMessageRootsDifferPerTopic(t *testing.T) {
+	roots := make(map[[32]byte]bulletinBoardTypes.MQTTTopic)
+	for _, topic := range messageTopics {
+		// the same header and zero fields for every message
+		message := NewMessage(topic)
+		if err := json.Unmarshal([]byte(`{"slot": "7", "timestamp": "1700000000000", "value": "0"}`), message); err != nil {
+			t.Fatal(err)
+		}
+		root, err := message.HashTreeRoot()
+		if err != nil {
+			t.Fatalf("%s: %v", topic, err)
+		}
+		if other, ok := roots[root]; ok {
+			t.Fatalf("%s and %s messages share the root %x", topic, other, root)
+		}
+		roots[root] = topic
+	}
+}
+
+func TestMovedSignatureRejected(t *testing.T) {
+	memory := NewMemoryPublisher(0)
+	relayClient := testBulletinBoard(t, memory, Options{})
+
+	// messages with the same fields, signed for the first topic and
+	// replayed on the second
+	moves := []struct {
+		signed  bulletinBoardTypes.MQTTTopic
+		message Message
+		moved   bulletinBoardTypes.MQTTTopic
+	}{
+		{ProposerRequestTopic, relayClient.headerRequestedMessage(HeaderRequest{Slot: 7, Proposer: "0x8a"}), ProposerPayloadRequestTopic},
+		{ProposerPayloadRequestTopic, relayClient.payloadRequestedMessage(PayloadRequest{Slot: 7, Proposer: "0x8a"}), ProposerRequestTopic},
+		{HighestBidTopic, relayClient.highestBidMessage(HighestBid{Slot: 7, Builder: "0x00000000000000000000000000000000000000bb"}), BidAcceptedTopic},
+	}
+	for _, move := range moves {
+		memory.Reset()
+		if err := relayClient.publishStructured(move.signed, move.message); err != nil {
+			t.Fatal(err)
+		}
+		payload := memory.Messages()[0].Payload
+		relayKey := relayClient.keys.SignerForSlot(7).PublicKey()
+
+		signed := NewMessage(move.signed)
+		if err := json.Unmarshal(payload, signed); err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyMessage(signed, relayClient.Domain(), relayKey); err != nil {
+			t.Fatalf("%s: %v", move.signed, err)
+		}
+
+		moved := NewMessage(move.moved)
+		if err := json.Unmarshal(payload, moved); err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyMessage(moved, relayClient.Domain(), relayKey); !errors.Is(err, ErrMessageSignature) {
+			t.Fatalf("%s message on %s: error %v, want %v", move.signed, move.moved, err, ErrMessageSignature)
+		}
+	}
+}
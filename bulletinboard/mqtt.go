@@ -1,85 +1,207 @@
 package bulletinboard
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
-	mqtt "github.com/eclipse/paho.mqtt.golang"
 	pahoMQTT "github.com/eclipse/paho.mqtt.golang"
-	"github.com/ethereum/go-ethereum/log"
 	"github.com/go-errors/errors"
 	"github.com/sirupsen/logrus"
+)
 
-	beaconclient "github.com/pon-pbs/bbRelay/beaconinterface"
+// Transports the client can reach the broker over, tcp and wss come from the
+// shared bulletin board types
+const (
+	TransportSSL = "ssl"
+	TransportWS  = "ws"
 )
 
-var (
-	relayConnectionHandler pahoMQTT.OnConnectHandler = func(client mqtt.Client) {
-		log.Info("MQTT Client Connected For The Relay")
+func ClientBrokerUrl(transport string, broker string, port uint64, path string) string {
+	switch transport {
+	case TransportWS, bulletinBoardTypes.WSS:
+		return fmt.Sprintf("%s://%s:%d/%s", transport, broker, port, strings.TrimPrefix(path, "/"))
 	}
+	return fmt.Sprintf("%s://%s:%d", transport, broker, port)
+}
 
-	relayConncetionLostHandler pahoMQTT.ConnectionLostHandler = func(client mqtt.Client, err error) {
-		log.Info("Connection Lost To MQTT Client", err)
+// ValidTransport reports whether the broker can be reached over transport
+func ValidTransport(transport string) bool {
+	switch transport {
+	case bulletinBoardTypes.TCP, TransportSSL, TransportWS, bulletinBoardTypes.WSS:
+		return true
 	}
-)
+	return false
+}
 
-func ClientBrokerUrl(broker string, port uint64) string {
-	return fmt.Sprintf("%s://%s:%d", bulletinBoardTypes.TCP, broker, port)
+// TLSConfig loads the CA and client certificate of the connection, nil is
+// returned for transports without TLS
+func (connection ConnectionOptions) TLSConfig() (*tls.Config, error) {
+	if connection.Transport != TransportSSL && connection.Transport != bulletinBoardTypes.WSS {
+		return nil, nil
+	}
+	return connection.loadTLSConfig()
 }
 
-func NewMQTTClient(clientParameters bulletinBoardTypes.RelayMQTTOpts, beaconClient *beaconclient.MultiBeaconClient) (*RelayMQTT, error) {
+// loadTLSConfig loads the CA and client certificate of the connection
+func (connection ConnectionOptions) loadTLSConfig() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if connection.TLSCAFile != "" {
+		ca, err := os.ReadFile(connection.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", connection.TLSCAFile)
+		}
+	}
 
-	relayClient := new(RelayMQTT)
+	if connection.TLSCertFile != "" || connection.TLSKeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(connection.TLSCertFile, connection.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
 
-	relayClient.Broker = clientParameters.Broker
-	relayClient.Port = clientParameters.Port
-	relayClient.BeaconInterface = beaconClient
+	return config, nil
+}
 
-	relayClient.Log = logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
-		"package": "BulletinBoard",
-		"broker":  clientParameters.Broker,
-	})
+// MQTTPublisher publishes to an MQTT broker, reconnecting with backoff and
+// restoring subscriptions whenever the connection comes back
+type MQTTPublisher struct {
+	ClientOptions *pahoMQTT.ClientOptions
+	Client        pahoMQTT.Client
 
-	relayClient.Channel.HighestBidChannel = make(chan bulletinBoardTypes.RelayHighestBid)
-	relayClient.Channel.ProposerHeaderChannel = make(chan bulletinBoardTypes.ProposerHeaderRequest)
-	relayClient.Channel.SlotPayloadChannel = make(chan bulletinBoardTypes.SlotPayloadRequest)
+	qos     byte
+	timeout time.Duration
+	log     *logrus.Entry
 
-	relayClient.ClientOptions = pahoMQTT.NewClientOptions()
-	relayClient.ClientOptions.AddBroker(ClientBrokerUrl(clientParameters.Broker, clientParameters.Port))
+	// subscriptions are restored every time the client reconnects
+	subscriptions   map[string]pahoMQTT.MessageHandler
+	subscriptionsMu *sync.Mutex
+}
 
-	relayClient.ClientOptions.SetClientID(clientParameters.ClientID)
-	relayClient.ClientOptions.SetUsername(clientParameters.UserName)
-	relayClient.ClientOptions.SetPassword(clientParameters.Password)
+func NewMQTTPublisher(clientParameters bulletinBoardTypes.RelayMQTTOpts, connection ConnectionOptions, publish PublishOptions, log *logrus.Entry) (*MQTTPublisher, error) {
+
+	mqttClient := &MQTTPublisher{
+		qos:     publish.QoS,
+		timeout: publish.Timeout,
+		log: log.WithFields(logrus.Fields{
+			"broker":    clientParameters.Broker,
+			"transport": connection.Transport,
+		}),
+		subscriptions:   make(map[string]pahoMQTT.MessageHandler),
+		subscriptionsMu: new(sync.Mutex),
+	}
 
-	relayClient.ClientOptions.OnConnect = relayConnectionHandler
-	relayClient.ClientOptions.OnConnectionLost = relayConncetionLostHandler
+	tlsConfig, err := connection.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
 
-	relayClient.Client = pahoMQTT.NewClient(relayClient.ClientOptions)
+	mqttClient.ClientOptions = pahoMQTT.NewClientOptions()
+	mqttClient.ClientOptions.AddBroker(ClientBrokerUrl(connection.Transport, clientParameters.Broker, clientParameters.Port, connection.WebSocketPath))
 
-	if relayClientToken := relayClient.Client.Connect(); relayClientToken.Wait() && relayClientToken.Error() != nil {
-		relayClient.Log.WithError(relayClientToken.Error()).Fatal("Bulletin Board Client Ready For Relay")
-		return nil, relayClientToken.Error()
+	mqttClient.ClientOptions.SetClientID(clientParameters.ClientID)
+	mqttClient.ClientOptions.SetUsername(clientParameters.UserName)
+	mqttClient.ClientOptions.SetPassword(clientParameters.Password)
+	if tlsConfig != nil {
+		mqttClient.ClientOptions.SetTLSConfig(tlsConfig)
 	}
 
-	relayClient.BulletinBoards()
+	mqttClient.ClientOptions.SetConnectTimeout(publish.Timeout)
+	mqttClient.ClientOptions.SetAutoReconnect(true)
+	mqttClient.ClientOptions.SetMaxReconnectInterval(connection.MaxReconnectInterval)
+	mqttClient.ClientOptions.SetConnectRetry(!connection.RequireBroker)
+	mqttClient.ClientOptions.SetConnectRetryInterval(connection.MaxReconnectInterval)
+
+	mqttClient.ClientOptions.SetOnConnectHandler(mqttClient.connected)
+	mqttClient.ClientOptions.SetConnectionLostHandler(mqttClient.connectionLost)
+	mqttClient.ClientOptions.SetReconnectingHandler(mqttClient.reconnecting)
+
+	mqttClient.Client = pahoMQTT.NewClient(mqttClient.ClientOptions)
+
+	mqttClientToken := mqttClient.Client.Connect()
+	if connection.RequireBroker {
+		if mqttClientToken.Wait(); mqttClientToken.Error() != nil {
+			return nil, mqttClientToken.Error()
+		}
+	} else if mqttClientToken.WaitTimeout(publish.Timeout) && mqttClientToken.Error() != nil {
+		return nil, mqttClientToken.Error()
+	} else if !mqttClient.Client.IsConnectionOpen() {
+		mqttClient.log.Warn("Bulletin Board Broker Unavailable, Retrying In The Background")
+	}
 
-	relayClient.Log.Info("Bulletin Board Client Ready For Relay")
-	return relayClient, nil
+	return mqttClient, nil
 }
 
-func (relayClient *RelayMQTT) BulletinBoards() {
-	go relayClient.HighestBidPublish()
-	go relayClient.SlotHeaderRequested()
-	go relayClient.SlotPayloadRequested()
-	go relayClient.BountyBidWon()
+func (mqttClient *MQTTPublisher) Publish(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	return mqttClient.wait(mqttClient.Client.Publish(string(topic), mqttClient.qos, false, payload))
 }
 
-func (relayClient *RelayMQTT) publishBulletinBoard(topic bulletinBoardTypes.MQTTTopic, message string) error {
+// Retain publishes a retained message, an empty retained message removes the
+// retained message of the topic
+func (mqttClient *MQTTPublisher) Retain(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	return mqttClient.wait(mqttClient.Client.Publish(string(topic), mqttClient.qos, true, payload))
+}
 
-	relayToken := relayClient.Client.Publish(string(topic), 0, false, message)
+func (mqttClient *MQTTPublisher) Healthy() error {
+	// IsConnected stays true while reconnecting, only an open connection is healthy
+	if !mqttClient.Client.IsConnectionOpen() {
+		return fmt.Errorf("not connected to broker %s", mqttClient.ClientOptions.Servers[0].Host)
+	}
+	return nil
+}
+
+func (mqttClient *MQTTPublisher) Close() {
+	mqttClient.Client.Disconnect(disconnectQuiesce)
+	mqttClient.log.Info("MQTT Client Disconnected")
+}
+
+// Subscribe subscribes to topic and keeps the subscription across reconnects
+func (mqttClient *MQTTPublisher) Subscribe(topic string, handler pahoMQTT.MessageHandler) error {
+	mqttClient.subscriptionsMu.Lock()
+	mqttClient.subscriptions[topic] = handler
+	mqttClient.subscriptionsMu.Unlock()
+
+	if !mqttClient.Client.IsConnectionOpen() {
+		// subscribed by the connect handler once the broker is reachable
+		return nil
+	}
+	return mqttClient.wait(mqttClient.Client.Subscribe(topic, mqttClient.qos, handler))
+}
+
+func (mqttClient *MQTTPublisher) connected(client pahoMQTT.Client) {
+	mqttClient.log.Info("MQTT Client Connected For The Relay")
+
+	mqttClient.subscriptionsMu.Lock()
+	defer mqttClient.subscriptionsMu.Unlock()
+
+	for topic, handler := range mqttClient.subscriptions {
+		if err := mqttClient.wait(client.Subscribe(topic, mqttClient.qos, handler)); err != nil {
+			mqttClient.log.WithError(err).WithField("topic", topic).Error("Failed To Resubscribe")
+		}
+	}
+}
+
+func (mqttClient *MQTTPublisher) connectionLost(client pahoMQTT.Client, err error) {
+	mqttClient.log.WithError(err).Warn("Connection Lost To MQTT Broker, Reconnecting")
+}
+
+func (mqttClient *MQTTPublisher) reconnecting(client pahoMQTT.Client, options *pahoMQTT.ClientOptions) {
+	mqttClient.log.Info("Reconnecting To MQTT Broker")
+}
 
-	timeout := relayToken.WaitTimeout(time.Duration(mqttTimeout))
+func (mqttClient *MQTTPublisher) wait(relayToken pahoMQTT.Token) error {
+	timeout := relayToken.WaitTimeout(mqttClient.timeout)
 	if !timeout {
 		return errors.New("Timeout Sending To Broker")
 	}
@@ -0,0 +1,352 @@
+package bulletinboard
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+	pahoMQTT "github.com/eclipse/paho.mqtt.golang"
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/sirupsen/logrus"
+)
+
+// testBroker is an in-process MQTT 3.1.1 broker, enough of it for the relay
+// client: connect, subscribe, QoS 0 and 1 publishes, retained messages and
+// pings. Subscriptions live as long as the connection, like clean sessions.
+type testBroker struct {
+	t    *testing.T
+	addr string
+
+	mu       sync.Mutex
+	listener net.Listener
+	clients  map[*brokerClient]struct{}
+	retained map[string][]byte
+}
+
+type brokerClient struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+	topics  []string
+}
+
+func (client *brokerClient) write(packet packets.ControlPacket) {
+	client.writeMu.Lock()
+	defer client.writeMu.Unlock()
+	packet.Write(client.conn)
+}
+
+func newTestBroker(t *testing.T) *testBroker {
+	t.Helper()
+	broker := &testBroker{t: t, retained: make(map[string][]byte)}
+	broker.start("127.0.0.1:0")
+	t.Cleanup(broker.stop)
+	return broker
+}
+
+// start listens on addr, the address of the broker is kept so it can be
+// restarted where the clients expect it
+func (broker *testBroker) start(addr string) {
+	broker.t.Helper()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		broker.t.Fatal(err)
+	}
+
+	broker.mu.Lock()
+	broker.listener = listener
+	broker.addr = listener.Addr().String()
+	broker.clients = make(map[*brokerClient]struct{})
+	broker.mu.Unlock()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go broker.serve(conn)
+		}
+	}()
+}
+
+// stop closes the listener and drops every client
+func (broker *testBroker) stop() {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.listener == nil {
+		return
+	}
+	broker.listener.Close()
+	broker.listener = nil
+	for client := range broker.clients {
+		client.conn.Close()
+	}
+	broker.clients = nil
+}
+
+func (broker *testBroker) hostPort() (string, uint64) {
+	host, port, _ := net.SplitHostPort(broker.addr)
+	portNumber, _ := strconv.ParseUint(port, 10, 16)
+	return host, portNumber
+}
+
+func (broker *testBroker) serve(conn net.Conn) {
+	client := &brokerClient{conn: conn}
+	broker.mu.Lock()
+	if broker.clients == nil {
+		broker.mu.Unlock()
+		conn.Close()
+		return
+	}
+	broker.clients[client] = struct{}{}
+	broker.mu.Unlock()
+
+	defer func() {
+		broker.mu.Lock()
+		delete(broker.clients, client)
+		broker.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		packet, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+
+		switch packet := packet.(type) {
+		case *packets.ConnectPacket:
+			client.write(packets.NewControlPacket(packets.Connack))
+
+		case *packets.SubscribePacket:
+			suback := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			suback.MessageID = packet.MessageID
+			suback.ReturnCodes = packet.Qoss
+			broker.mu.Lock()
+			client.topics = append(client.topics, packet.Topics...)
+			var retained []*packets.PublishPacket
+			for topic, payload := range broker.retained {
+				if subscribed(packet.Topics, topic) {
+					retained = append(retained, publishPacket(topic, payload, true))
+				}
+			}
+			broker.mu.Unlock()
+			client.write(suback)
+			for _, publish := range retained {
+				client.write(publish)
+			}
+
+		case *packets.PublishPacket:
+			if packet.Qos > 0 {
+				puback := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				puback.MessageID = packet.MessageID
+				client.write(puback)
+			}
+			broker.publish(packet)
+
+		case *packets.PingreqPacket:
+			client.write(packets.NewControlPacket(packets.Pingresp))
+
+		case *packets.DisconnectPacket:
+			return
+		}
+	}
+}
+
+func (broker *testBroker) publish(packet *packets.PublishPacket) {
+	broker.mu.Lock()
+	if packet.Retain {
+		if len(packet.Payload) == 0 {
+			delete(broker.retained, packet.TopicName)
+		} else {
+			broker.retained[packet.TopicName] = packet.Payload
+		}
+	}
+	var receivers []*brokerClient
+	for client := range broker.clients {
+		if subscribed(client.topics, packet.TopicName) {
+			receivers = append(receivers, client)
+		}
+	}
+	broker.mu.Unlock()
+
+	for _, client := range receivers {
+		client.write(publishPacket(packet.TopicName, packet.Payload, false))
+	}
+}
+
+func publishPacket(topic string, payload []byte, retain bool) *packets.PublishPacket {
+	publish := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+	publish.TopicName = topic
+	publish.Payload = payload
+	publish.Retain = retain
+	return publish
+}
+
+// subscribed matches topic against the subscription filters, with + and #
+// wildcards
+func subscribed(filters []string, topic string) bool {
+	for _, filter := range filters {
+		filterLevels, topicLevels := strings.Split(filter, "/"), strings.Split(topic, "/")
+		for i, level := range filterLevels {
+			if level == "#" {
+				return true
+			}
+			if i >= len(topicLevels) || (level != "+" && level != topicLevels[i]) {
+				break
+			}
+			if i == len(filterLevels)-1 && i == len(topicLevels)-1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func testMQTTPublisher(t *testing.T, broker *testBroker, requireBroker bool) (*MQTTPublisher, error) {
+	t.Helper()
+	host, port := broker.hostPort()
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+
+	publisher, err := NewMQTTPublisher(
+		bulletinBoardTypes.RelayMQTTOpts{Broker: host, Port: port, ClientID: t.Name()},
+		ConnectionOptions{Transport: bulletinBoardTypes.TCP, RequireBroker: requireBroker, MaxReconnectInterval: 100 * time.Millisecond},
+		PublishOptions{QoS: 1, Timeout: time.Second},
+		logrus.NewEntry(log),
+	)
+	if err == nil {
+		t.Cleanup(publisher.Close)
+	}
+	return publisher, err
+}
+
+// messages collects the payloads a subscription receives
+func messages() (chan string, pahoMQTT.MessageHandler) {
+	received := make(chan string, 16)
+	return received, func(client pahoMQTT.Client, message pahoMQTT.Message) {
+		received <- string(message.Payload())
+	}
+}
+
+// eventually fails the test unless condition holds within a few seconds
+func eventually(t *testing.T, condition func() bool, format string, args ...interface{}) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !condition(); {
+		if time.Now().After(deadline) {
+			t.Fatalf(format, args...)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// publishUntilReceived publishes until the payload comes back, subscriptions
+// restored after a reconnect aren't acknowledged to the caller
+func publishUntilReceived(t *testing.T, publisher *MQTTPublisher, topic string, payload string, received chan string) {
+	t.Helper()
+	eventually(t, func() bool {
+		publisher.Publish(bulletinBoardTypes.MQTTTopic(topic), []byte(payload))
+		select {
+		case message := <-received:
+			return message == payload
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, "%s never received on %s", payload, topic)
+}
+
+func TestMQTTPublishSubscribe(t *testing.T) {
+	broker := newTestBroker(t)
+	publisher, err := testMQTTPublisher(t, broker, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := publisher.Healthy(); err != nil {
+		t.Fatal(err)
+	}
+
+	received, handler := messages()
+	if err := publisher.Subscribe("relay/+/bid", handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := publisher.Publish("relay/v1/bid", []byte("bid")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case message := <-received:
+		if message != "bid" {
+			t.Fatalf("received %q, want %q", message, "bid")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("published message not received")
+	}
+
+	// a retained message reaches later subscribers
+	if err := publisher.Retain("relay/v1/slot/1", []byte("slot")); err != nil {
+		t.Fatal(err)
+	}
+	retained, handler := messages()
+	if err := publisher.Subscribe("relay/v1/slot/#", handler); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case message := <-retained:
+		if message != "slot" {
+			t.Fatalf("retained %q, want %q", message, "slot")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("retained message not received")
+	}
+}
+
+func TestMQTTReconnectResubscribes(t *testing.T) {
+	broker := newTestBroker(t)
+	publisher, err := testMQTTPublisher(t, broker, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	received, handler := messages()
+	if err := publisher.Subscribe("relay/v1/bid", handler); err != nil {
+		t.Fatal(err)
+	}
+	publishUntilReceived(t, publisher, "relay/v1/bid", "before restart", received)
+
+	broker.stop()
+	eventually(t, func() bool { return publisher.Healthy() != nil }, "client still healthy without a broker")
+
+	// the restarted broker forgot the subscription, the client restores it
+	broker.start(broker.addr)
+	eventually(t, func() bool { return publisher.Healthy() == nil }, "client didn't reconnect")
+	publishUntilReceived(t, publisher, "relay/v1/bid", "after restart", received)
+}
+
+func TestMQTTStartWithBrokerDown(t *testing.T) {
+	broker := newTestBroker(t)
+	broker.stop()
+
+	if _, err := testMQTTPublisher(t, broker, true); err == nil {
+		t.Fatal("required broker down, want an error")
+	}
+
+	publisher, err := testMQTTPublisher(t, broker, false)
+	if err != nil {
+		t.Fatalf("optional broker down: %v", err)
+	}
+	if publisher.Healthy() == nil {
+		t.Fatal("client healthy without a broker")
+	}
+
+	// subscriptions made while down are made once the broker is up
+	received, handler := messages()
+	if err := publisher.Subscribe("relay/v1/bid", handler); err != nil {
+		t.Fatal(err)
+	}
+
+	broker.start(broker.addr)
+	eventually(t, func() bool { return publisher.Healthy() == nil }, "client didn't connect once the broker came up")
+	publishUntilReceived(t, publisher, "relay/v1/bid", "bid", received)
+}
@@ -0,0 +1,372 @@
+package bulletinboard
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/tls"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+	"github.com/sirupsen/logrus"
+)
+
+// NATSPublisher publishes to a NATS server over the core protocol. Subjects
+// are the topics with dots instead of slashes, e.g. topic.HighestBid.v1.
+// NATS has no retained messages, a lost connection is redialled on the next
+// publish.
+type NATSPublisher struct {
+	url     *url.URL
+	timeout time.Duration
+	tls     *tls.Config
+	// nkey signs the nonce of the server, nil without nkey authentication
+	nkey       ed25519.PrivateKey
+	nkeyPublic string
+	log        *logrus.Entry
+
+	conn   net.Conn
+	writer *bufio.Writer
+	// err is why the last connection ended
+	err error
+	mu  sync.Mutex
+}
+
+// NATSOptions configures the NATS publisher. User and password, or a token
+// alone, are taken from the url.
+type NATSOptions struct {
+	URL string
+	// NKeySeedFile holds the seed of the user nkey to authenticate with
+	NKeySeedFile string
+	// TLS is used for tls:// urls and servers requiring TLS, the system
+	// roots verify the server when nil
+	TLS     *tls.Config
+	Timeout time.Duration
+}
+
+// natsInfo is the part of the INFO message of the NATS protocol the
+// publisher needs
+type natsInfo struct {
+	TLSRequired bool   `json:"tls_required"`
+	Nonce       string `json:"nonce"`
+}
+
+// natsConnect is the CONNECT message of the NATS protocol
+type natsConnect struct {
+	Verbose     bool   `json:"verbose"`
+	Pedantic    bool   `json:"pedantic"`
+	TLSRequired bool   `json:"tls_required"`
+	Name        string `json:"name"`
+	Lang        string `json:"lang"`
+	Version     string `json:"version"`
+	User        string `json:"user,omitempty"`
+	Pass        string `json:"pass,omitempty"`
+	AuthToken   string `json:"auth_token,omitempty"`
+	NKey        string `json:"nkey,omitempty"`
+	Sig         string `json:"sig,omitempty"`
+}
+
+func NewNATSPublisher(options NATSOptions, log *logrus.Entry) (*NATSPublisher, error) {
+	parsed, err := url.Parse(options.URL)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "nats" && parsed.Scheme != "tls" {
+		return nil, fmt.Errorf("NATS url must start with nats:// or tls://, got %s", redactedNATSURL(parsed))
+	}
+
+	publisher := &NATSPublisher{
+		url:     parsed,
+		timeout: options.Timeout,
+		tls:     options.TLS,
+		log:     log.WithField("nats", parsed.Host),
+	}
+	if options.NKeySeedFile != "" {
+		seed, err := os.ReadFile(options.NKeySeedFile)
+		if err != nil {
+			return nil, err
+		}
+		if publisher.nkey, publisher.nkeyPublic, err = decodeNKeySeed(seed); err != nil {
+			return nil, fmt.Errorf("%s: %w", options.NKeySeedFile, err)
+		}
+	}
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	if err := publisher.connect(); err != nil {
+		publisher.log.WithError(err).Warn("NATS Server Unavailable, Retrying On Publish")
+	}
+	return publisher, nil
+}
+
+// NATSSubject is the NATS subject of the topic
+func NATSSubject(topic bulletinBoardTypes.MQTTTopic) string {
+	return strings.ReplaceAll(string(topic), "/", ".")
+}
+
+func redactedNATSURL(natsURL *url.URL) string {
+	redacted := *natsURL
+	redacted.User = nil
+	return redacted.String()
+}
+
+// connect dials the server and completes the handshake, mu must be held
+func (publisher *NATSPublisher) connect() error {
+	dialer := &net.Dialer{Timeout: publisher.timeout}
+	conn, err := dialer.Dial("tcp", publisher.url.Host)
+	if err != nil {
+		publisher.err = err
+		return err
+	}
+
+	if err := publisher.handshake(conn); err != nil {
+		conn.Close()
+		publisher.err = err
+		return err
+	}
+	publisher.log.Info("Connected To NATS Server")
+	return nil
+}
+
+// handshake reads the INFO of the server, upgrades the connection to TLS
+// when asked to and authenticates with CONNECT
+func (publisher *NATSPublisher) handshake(conn net.Conn) error {
+	if err := conn.SetDeadline(time.Now().Add(publisher.timeout)); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(conn)
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	infoJSON, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !ok {
+		return fmt.Errorf("unexpected NATS greeting %q", strings.TrimSpace(line))
+	}
+	info := new(natsInfo)
+	if err := json.Unmarshal([]byte(infoJSON), info); err != nil {
+		return fmt.Errorf("invalid NATS INFO: %w", err)
+	}
+
+	// the server sends INFO in plain text, TLS starts after it
+	useTLS := publisher.url.Scheme == "tls" || info.TLSRequired
+	if useTLS {
+		config := &tls.Config{MinVersion: tls.VersionTLS12}
+		if publisher.tls != nil {
+			config = publisher.tls.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName = publisher.url.Hostname()
+		}
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.Handshake(); err != nil {
+			return err
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+	writer := bufio.NewWriter(conn)
+
+	connect := natsConnect{
+		TLSRequired: useTLS,
+		Name:        "pon-relay",
+		Lang:        "go",
+		Version:     MessageVersion,
+	}
+	if password, ok := publisher.url.User.Password(); ok {
+		connect.User, connect.Pass = publisher.url.User.Username(), password
+	} else {
+		connect.AuthToken = publisher.url.User.Username()
+	}
+	if publisher.nkey != nil {
+		connect.NKey = publisher.nkeyPublic
+		connect.Sig = base64.RawURLEncoding.EncodeToString(ed25519.Sign(publisher.nkey, []byte(info.Nonce)))
+	}
+	connectJSON, err := json.Marshal(connect)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "CONNECT %s\r\nPING\r\n", connectJSON)
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	// A PONG means the server accepted the CONNECT, it may send another INFO first
+	for {
+		reply, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		reply = strings.TrimSpace(reply)
+		if strings.HasPrefix(reply, "INFO ") {
+			continue
+		}
+		if reply != "PONG" {
+			return fmt.Errorf("NATS server refused connection: %s", reply)
+		}
+		break
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return err
+	}
+	publisher.conn = conn
+	publisher.writer = writer
+	go publisher.read(conn, reader)
+	return nil
+}
+
+// decodeNKeySeed decodes the seed of a user nkey, e.g. SUAM..., returning
+// its private key and its public key, e.g. UAB2... Lines of the file not
+// holding the seed, like those of a .creds file, are skipped.
+func decodeNKeySeed(seedFile []byte) (ed25519.PrivateKey, string, error) {
+	var seed string
+	for _, line := range strings.Split(string(seedFile), "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "SU") {
+			seed = line
+			break
+		}
+	}
+	if seed == "" {
+		return nil, "", errors.New("no user nkey seed found")
+	}
+
+	raw, err := nkeyEncoding.DecodeString(seed)
+	if err != nil || len(raw) != 36 {
+		return nil, "", errors.New("invalid nkey seed")
+	}
+	if binary.LittleEndian.Uint16(raw[34:]) != crc16(raw[:34]) {
+		return nil, "", errors.New("invalid nkey seed checksum")
+	}
+	if raw[0]&0xf8 != nkeyPrefixSeed || (raw[0]&7)<<5|raw[1]>>3 != nkeyPrefixUser {
+		return nil, "", errors.New("not the seed of a user nkey")
+	}
+
+	key := ed25519.NewKeyFromSeed(raw[2:34])
+	public := append([]byte{nkeyPrefixUser}, key.Public().(ed25519.PublicKey)...)
+	public = binary.LittleEndian.AppendUint16(public, crc16(public))
+	return key, nkeyEncoding.EncodeToString(public), nil
+}
+
+// nkeys are base32 encoded with a prefix byte for the key type and a crc16
+// checksum, seeds carry the type of their key in the second byte
+const (
+	nkeyPrefixSeed = 18 << 3
+	nkeyPrefixUser = 20 << 3
+)
+
+var nkeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// crc16 is the CRC-16/XMODEM checksum of nkeys
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// read answers the server's pings until the connection ends
+func (publisher *NATSPublisher) read(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			publisher.disconnect(conn, err)
+			return
+		}
+
+		switch line = strings.TrimSpace(line); {
+		case line == "PING":
+			publisher.mu.Lock()
+			if publisher.conn == conn {
+				publisher.writer.WriteString("PONG\r\n")
+				publisher.writer.Flush()
+			}
+			publisher.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			publisher.log.WithField("error", line).Warn("NATS Server Error")
+		}
+	}
+}
+
+func (publisher *NATSPublisher) disconnect(conn net.Conn, err error) {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	if publisher.conn != conn {
+		return
+	}
+	conn.Close()
+	publisher.conn = nil
+	publisher.err = err
+	publisher.log.WithError(err).Warn("Connection Lost To NATS Server")
+}
+
+func (publisher *NATSPublisher) Publish(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	if publisher.conn == nil {
+		if err := publisher.connect(); err != nil {
+			return err
+		}
+	}
+
+	conn := publisher.conn
+	if err := conn.SetWriteDeadline(time.Now().Add(publisher.timeout)); err != nil {
+		return err
+	}
+	fmt.Fprintf(publisher.writer, "PUB %s %d\r\n", NATSSubject(topic), len(payload))
+	publisher.writer.Write(payload)
+	publisher.writer.WriteString("\r\n")
+	if err := publisher.writer.Flush(); err != nil {
+		conn.Close()
+		publisher.conn = nil
+		publisher.err = err
+		return err
+	}
+	return nil
+}
+
+func (publisher *NATSPublisher) Retain(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	return nil
+}
+
+func (publisher *NATSPublisher) Healthy() error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	if publisher.conn == nil {
+		return fmt.Errorf("not connected to NATS server %s: %v", publisher.url.Host, publisher.err)
+	}
+	return nil
+}
+
+func (publisher *NATSPublisher) Close() {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	if publisher.conn != nil {
+		publisher.writer.Flush()
+		publisher.conn.Close()
+		publisher.conn = nil
+	}
+	publisher.log.Info("NATS Connection Closed")
+}
@@ -0,0 +1,331 @@
+package bulletinboard
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// the seed with the bytes 0 to 31 and its user nkey, signing testNATSNonce
+// gives testNATSSig, generated with github.com/nats-io/nkeys
+const (
+	testNKeySeed   = "SUAAAAICAMCAKBQHBAEQUCYMBUHA6EARCIJRIFIWC4MBSGQ3DQOR4H776Y"
+	testNKeyPublic = "UAB2CB576PHBBPQ5ODORRZ2LYCMWPZGWGCN2KDK7DXOIMZASKUY3RLKK"
+	testNATSNonce  = "nonce-1234"
+	testNATSSig    = "IJcLK9yIHQTZlR2XBJPxQPosivRSOP0_FYnR-7VmHeUGJxapG-u8Mhph3Q9a-MPmBeNCA9OAtiHB5Mi29BvpBg"
+)
+
+type natsPub struct {
+	subject string
+	payload string
+}
+
+// testNATSServer is an in-process NATS server, enough of the core protocol
+// for the publisher: INFO, TLS upgrades, CONNECT, PING and PUB
+type testNATSServer struct {
+	t    *testing.T
+	addr string
+	// tls upgrades every connection after INFO when set
+	tls   *tls.Config
+	nonce string
+	// authorize decides on the CONNECT of a client
+	authorize func(natsConnect) bool
+
+	connects chan natsConnect
+	pubs     chan natsPub
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    []net.Conn
+}
+
+func newTestNATSServer(t *testing.T, tlsConfig *tls.Config) *testNATSServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &testNATSServer{
+		t:         t,
+		addr:      listener.Addr().String(),
+		tls:       tlsConfig,
+		nonce:     testNATSNonce,
+		authorize: func(natsConnect) bool { return true },
+		connects:  make(chan natsConnect, 16),
+		pubs:      make(chan natsPub, 16),
+		listener:  listener,
+	}
+	t.Cleanup(func() {
+		listener.Close()
+		server.dropClients()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.conns = append(server.conns, conn)
+			server.mu.Unlock()
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (server *testNATSServer) dropClients() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, conn := range server.conns {
+		conn.Close()
+	}
+	server.conns = nil
+}
+
+func (server *testNATSServer) serve(conn net.Conn) {
+	defer conn.Close()
+	info, _ := json.Marshal(map[string]interface{}{"server_id": "test", "tls_required": server.tls != nil, "nonce": server.nonce})
+	fmt.Fprintf(conn, "INFO %s\r\n", info)
+
+	if server.tls != nil {
+		tlsConn := tls.Server(conn, server.tls)
+		if tlsConn.Handshake() != nil {
+			return
+		}
+		conn = tlsConn
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		op, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch op {
+		case "CONNECT":
+			connect := natsConnect{}
+			json.Unmarshal([]byte(args), &connect)
+			server.connects <- connect
+			if !server.authorize(connect) {
+				io.WriteString(conn, "-ERR 'Authorization Violation'\r\n")
+				return
+			}
+		case "PING":
+			io.WriteString(conn, "PONG\r\n")
+		case "PUB":
+			subject, size, _ := strings.Cut(args, " ")
+			n, err := strconv.Atoi(size)
+			if err != nil {
+				return
+			}
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			server.pubs <- natsPub{subject: subject, payload: string(payload[:n])}
+		}
+	}
+}
+
+func (server *testNATSServer) connect(t *testing.T) natsConnect {
+	t.Helper()
+	select {
+	case connect := <-server.connects:
+		return connect
+	case <-time.After(5 * time.Second):
+		t.Fatal("no CONNECT")
+	}
+	return natsConnect{}
+}
+
+func (server *testNATSServer) pub(t *testing.T) natsPub {
+	t.Helper()
+	select {
+	case pub := <-server.pubs:
+		return pub
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing published")
+	}
+	return natsPub{}
+}
+
+func testNATSPublisher(t *testing.T, options NATSOptions) *NATSPublisher {
+	t.Helper()
+	if options.Timeout == 0 {
+		options.Timeout = 5 * time.Second
+	}
+	publisher, err := NewNATSPublisher(options, testLog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(publisher.Close)
+	return publisher
+}
+
+// testCertificate is a self-signed certificate of 127.0.0.1 and a pool
+// trusting it
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func TestNATSPublish(t *testing.T) {
+	server := newTestNATSServer(t, nil)
+	publisher := testNATSPublisher(t, NATSOptions{URL: "nats://relay:secret@" + server.addr})
+
+	connect := server.connect(t)
+	if connect.User != "relay" || connect.Pass != "secret" || connect.AuthToken != "" || connect.TLSRequired {
+		t.Fatalf("CONNECT %+v, want user and password without TLS", connect)
+	}
+	if err := publisher.Healthy(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := publisher.Publish(VersionedTopic(HighestBidTopic), []byte("bid\r\nwith lines")); err != nil {
+		t.Fatal(err)
+	}
+	if pub := server.pub(t); pub.subject != "topic.HighestBid.v1" || pub.payload != "bid\r\nwith lines" {
+		t.Fatalf("published %+v", pub)
+	}
+}
+
+func TestNATSToken(t *testing.T) {
+	server := newTestNATSServer(t, nil)
+	testNATSPublisher(t, NATSOptions{URL: "nats://s3cret@" + server.addr})
+
+	if connect := server.connect(t); connect.AuthToken != "s3cret" || connect.User != "" {
+		t.Fatalf("CONNECT %+v, want the token alone", connect)
+	}
+}
+
+func TestNATSNKey(t *testing.T) {
+	seedFile := filepath.Join(t.TempDir(), "relay.creds")
+	creds := "-----BEGIN USER NKEY SEED-----\n" + testNKeySeed + "\n------END USER NKEY SEED------\n"
+	if err := os.WriteFile(seedFile, []byte(creds), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := newTestNATSServer(t, nil)
+	testNATSPublisher(t, NATSOptions{URL: "nats://" + server.addr, NKeySeedFile: seedFile})
+
+	if connect := server.connect(t); connect.NKey != testNKeyPublic || connect.Sig != testNATSSig {
+		t.Fatalf("CONNECT nkey %s sig %s, want %s signing the nonce", connect.NKey, connect.Sig, testNKeyPublic)
+	}
+
+	for _, seed := range []string{"", "SUAAAAICAMCAKBQHBAEQUCYMBUHA6EARCIJRIFIWC4MBSGQ3DQOR4H776A", testNKeyPublic} {
+		if _, _, err := decodeNKeySeed([]byte(seed)); err == nil {
+			t.Fatalf("decoded seed %q", seed)
+		}
+	}
+	if _, err := NewNATSPublisher(NATSOptions{URL: "nats://" + server.addr, NKeySeedFile: seedFile + ".missing"}, testLog()); err == nil {
+		t.Fatal("publisher created without its nkey seed")
+	}
+}
+
+func TestNATSTLS(t *testing.T) {
+	certificate, pool := testCertificate(t)
+	server := newTestNATSServer(t, &tls.Config{Certificates: []tls.Certificate{certificate}})
+
+	// a nats:// url upgrades to TLS when the server requires it
+	publisher := testNATSPublisher(t, NATSOptions{URL: "nats://" + server.addr, TLS: &tls.Config{RootCAs: pool}})
+	if connect := server.connect(t); !connect.TLSRequired {
+		t.Fatalf("CONNECT %+v, want TLS", connect)
+	}
+	if err := publisher.Publish(HighestBidTopic, []byte("bid")); err != nil {
+		t.Fatal(err)
+	}
+	if pub := server.pub(t); pub.payload != "bid" {
+		t.Fatalf("published %+v", pub)
+	}
+
+	// the server certificate is verified
+	untrusted := testNATSPublisher(t, NATSOptions{URL: "tls://" + server.addr, Timeout: time.Second})
+	if err := untrusted.Healthy(); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("healthy %v, want the untrusted certificate", err)
+	}
+}
+
+func TestNATSRefused(t *testing.T) {
+	server := newTestNATSServer(t, nil)
+	server.authorize = func(connect natsConnect) bool { return connect.Pass == "secret" }
+
+	publisher := testNATSPublisher(t, NATSOptions{URL: "nats://relay:wrong@" + server.addr})
+	server.connect(t)
+	if err := publisher.Healthy(); err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Fatalf("healthy %v, want the authorization violation", err)
+	}
+	if err := publisher.Publish(HighestBidTopic, []byte("bid")); err == nil {
+		t.Fatal("published without being authorized")
+	}
+
+	if _, err := NewNATSPublisher(NATSOptions{URL: "http://" + server.addr}, testLog()); err == nil {
+		t.Fatal("publisher created for an http url")
+	}
+}
+
+func TestNATSReconnect(t *testing.T) {
+	server := newTestNATSServer(t, nil)
+	publisher := testNATSPublisher(t, NATSOptions{URL: "nats://" + server.addr})
+	server.connect(t)
+
+	server.dropClients()
+	deadline := time.Now().Add(5 * time.Second)
+	for publisher.Healthy() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("lost connection not noticed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the next publish dials again
+	if err := publisher.Publish(HighestBidTopic, []byte("bid")); err != nil {
+		t.Fatal(err)
+	}
+	server.connect(t)
+	if pub := server.pub(t); pub.payload != "bid" {
+		t.Fatalf("published %+v", pub)
+	}
+	if err := publisher.Healthy(); err != nil {
+		t.Fatal(err)
+	}
+}
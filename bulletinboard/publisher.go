@@ -0,0 +1,130 @@
+package bulletinboard
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+)
+
+// Publishers messages can be sent through, see TransportOptions
+const (
+	PublisherMQTT   = "mqtt"
+	PublisherNATS   = "nats"
+	PublisherKafka  = "kafka"
+	PublisherFanOut = "fanout"
+	PublisherMemory = "memory"
+)
+
+// Publisher carries bulletin board messages to subscribers. Topics are MQTT
+// style, transports with other naming rules map them.
+type Publisher interface {
+	Publish(topic bulletinBoardTypes.MQTTTopic, payload []byte) error
+	// Retain keeps the payload as the latest message of the topic for
+	// subscribers joining later, an empty payload removes it. Transports
+	// without retained messages ignore it.
+	Retain(topic bulletinBoardTypes.MQTTTopic, payload []byte) error
+	// Healthy returns why messages can't be delivered right now, nil if they can
+	Healthy() error
+	Close()
+}
+
+// ValidPublisher reports whether name is a known publisher
+func ValidPublisher(name string) bool {
+	switch name {
+	case PublisherMQTT, PublisherNATS, PublisherKafka, PublisherFanOut, PublisherMemory:
+		return true
+	}
+	return false
+}
+
+func (relayClient *BulletinBoard) newPublishers(clientParameters bulletinBoardTypes.RelayMQTTOpts, options Options) (Publisher, error) {
+	if options.Transports.Publisher != nil {
+		relayClient.fanOut, _ = options.Transports.Publisher.(*FanOut)
+		return options.Transports.Publisher, nil
+	}
+
+	var publishers multiPublisher
+	for _, name := range options.Transports.Publishers {
+		var (
+			publisher Publisher
+			err       error
+		)
+		switch name {
+		case PublisherMQTT:
+			publisher, err = NewMQTTPublisher(clientParameters, options.Connection, options.Publish, relayClient.Log)
+		case PublisherNATS:
+			var tlsConfig *tls.Config
+			if tlsConfig, err = options.Connection.loadTLSConfig(); err == nil {
+				publisher, err = NewNATSPublisher(NATSOptions{
+					URL:          options.Transports.NATSURL,
+					NKeySeedFile: options.Transports.NATSNKeySeedFile,
+					TLS:          tlsConfig,
+					Timeout:      options.Publish.Timeout,
+				}, relayClient.Log)
+			}
+		case PublisherKafka:
+			publisher, err = NewKafkaPublisher(options.Transports.KafkaRESTURL, options.Publish.Timeout)
+		case PublisherFanOut:
+			relayClient.fanOut = NewFanOut(options.Publish.Buffer, options.Transports.FanOutMaxSubscribers, relayClient.Log)
+			publisher = relayClient.fanOut
+		case PublisherMemory:
+			publisher = NewMemoryPublisher(options.Publish.Buffer)
+		default:
+			err = fmt.Errorf("unknown bulletin board publisher %s", name)
+		}
+		if err != nil {
+			publishers.Close()
+			return nil, err
+		}
+		publishers = append(publishers, publisher)
+	}
+
+	if len(publishers) == 0 {
+		return nil, errors.New("no bulletin board publisher configured")
+	}
+	if len(publishers) == 1 {
+		return publishers[0], nil
+	}
+	return publishers, nil
+}
+
+// multiPublisher sends every message through all of its publishers
+type multiPublisher []Publisher
+
+func (publishers multiPublisher) Publish(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	var errs []error
+	for _, publisher := range publishers {
+		if err := publisher.Publish(topic, payload); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (publishers multiPublisher) Retain(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	var errs []error
+	for _, publisher := range publishers {
+		if err := publisher.Retain(topic, payload); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (publishers multiPublisher) Healthy() error {
+	var errs []error
+	for _, publisher := range publishers {
+		if err := publisher.Healthy(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (publishers multiPublisher) Close() {
+	for _, publisher := range publishers {
+		publisher.Close()
+	}
+}
@@ -0,0 +1,337 @@
+package bulletinboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+	"testing"
+
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+	"github.com/sirupsen/logrus"
+
+	bls "github.com/pon-pbs/bbRelay/bls"
+	"github.com/pon-pbs/bbRelay/signing"
+)
+
+var errPublisherDown = errors.New("publisher down")
+
+// failingPublisher fails every message, counting the attempts
+type failingPublisher struct {
+	mu       sync.Mutex
+	attempts int
+	closed   bool
+}
+
+func (publisher *failingPublisher) Publish(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	publisher.attempts++
+	return errPublisherDown
+}
+
+func (publisher *failingPublisher) Retain(topic bulletinBoardTypes.MQTTTopic, payload []byte) error {
+	return publisher.Publish(topic, payload)
+}
+
+func (publisher *failingPublisher) Healthy() error { return errPublisherDown }
+
+func (publisher *failingPublisher) Close() {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	publisher.closed = true
+}
+
+func testLog() *logrus.Entry {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return logrus.NewEntry(log)
+}
+
+// testBulletinBoard publishes through publisher without a beacon node, the
+// publish loops aren't started
+func testBulletinBoard(t *testing.T, publisher Publisher, options Options) *BulletinBoard {
+	t.Helper()
+	sk, _, err := bls.GenerateNewKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := signing.NewLocalSigner(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	domain, err := BulletinBoardDomain("0x00000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	relayClient := &BulletinBoard{
+		Log:           testLog(),
+		keys:          signing.NewKeySet(signer),
+		domain:        domain,
+		options:       options.Messages,
+		publish:       options.Publish,
+		publisher:     publisher,
+		retainedSlots: make(map[bulletinBoardTypes.MQTTTopic]map[uint64]struct{}),
+		retainedMu:    new(sync.Mutex),
+		publishers:    new(sync.WaitGroup),
+	}
+	relayClient.fanOut, _ = publisher.(*FanOut)
+	relayClient.Channel.HighestBidChannel = make(chan HighestBid, options.Publish.Buffer)
+	return relayClient
+}
+
+// drainHighestBids publishes the queued highest bids like the publish loop
+// does once the relay shuts down
+func drainHighestBids(relayClient *BulletinBoard) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	relayClient.HighestBidPublish(ctx)
+}
+
+func topics(messages []MemoryMessage) []string {
+	var topics []string
+	for _, message := range messages {
+		topics = append(topics, string(message.Topic))
+	}
+	return topics
+}
+
+func TestMultiPublisherFansOut(t *testing.T) {
+	first, second, failing := NewMemoryPublisher(0), NewMemoryPublisher(0), new(failingPublisher)
+	publishers := multiPublisher{first, failing, second}
+
+	if err := publishers.Publish("topic/HighestBid/v1", []byte("bid")); !errors.Is(err, errPublisherDown) {
+		t.Fatalf("error %v, want the failing publisher's", err)
+	}
+	if err := publishers.Retain("topic/HighestBid/v1/slot/1", []byte("bid")); !errors.Is(err, errPublisherDown) {
+		t.Fatalf("error %v, want the failing publisher's", err)
+	}
+
+	// a failing publisher doesn't hold the message back from the others
+	for _, publisher := range []*MemoryPublisher{first, second} {
+		if messages := publisher.Messages(); len(messages) != 2 || string(messages[0].Payload) != "bid" || !messages[1].Retained {
+			t.Fatalf("messages %+v, want the published and the retained message", messages)
+		}
+	}
+	if failing.attempts != 2 {
+		t.Fatalf("%d attempts on the failing publisher, want 2", failing.attempts)
+	}
+
+	if err := publishers.Healthy(); !errors.Is(err, errPublisherDown) {
+		t.Fatalf("healthy %v, want the failing publisher's error", err)
+	}
+	if err := (multiPublisher{first, second}).Healthy(); err != nil {
+		t.Fatal(err)
+	}
+
+	publishers.Close()
+	if !failing.closed {
+		t.Fatal("publisher not closed")
+	}
+}
+
+func TestNewPublishers(t *testing.T) {
+	relayClient := &BulletinBoard{Log: testLog()}
+	publisher, err := relayClient.newPublishers(bulletinBoardTypes.RelayMQTTOpts{}, Options{
+		Transports: TransportOptions{Publishers: []string{PublisherMemory, PublisherFanOut}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publishers, ok := publisher.(multiPublisher); !ok || len(publishers) != 2 {
+		t.Fatalf("publisher %T, want both publishers", publisher)
+	}
+	if relayClient.FanOut() == nil {
+		t.Fatal("fan-out publisher not served")
+	}
+
+	relayClient = &BulletinBoard{Log: testLog()}
+	publisher, err = relayClient.newPublishers(bulletinBoardTypes.RelayMQTTOpts{}, Options{
+		Transports: TransportOptions{Publishers: []string{PublisherMemory}},
+	})
+	if _, ok := publisher.(*MemoryPublisher); err != nil || !ok {
+		t.Fatalf("publisher %T, error %v, want the memory publisher alone", publisher, err)
+	}
+
+	for _, names := range [][]string{nil, {"carrier-pigeon"}} {
+		relayClient = &BulletinBoard{Log: testLog()}
+		if _, err := relayClient.newPublishers(bulletinBoardTypes.RelayMQTTOpts{}, Options{Transports: TransportOptions{Publishers: names}}); err == nil {
+			t.Fatalf("publishers %v, want an error", names)
+		}
+	}
+}
+
+func TestPublishThroughEveryPublisher(t *testing.T) {
+	memory := NewMemoryPublisher(0)
+	fanOut := NewFanOut(16, 0, testLog())
+	relayClient := testBulletinBoard(t, multiPublisher{memory, fanOut}, Options{
+		Messages: MessageOptions{SSZ: true},
+		Publish:  PublishOptions{Buffer: 8, RetainSlots: 2},
+	})
+	subscriber, err := fanOut.subscribe([]string{"#"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for slot := uint64(1); slot <= 3; slot++ {
+		relayClient.SendHighestBid(HighestBid{Slot: slot, Builder: "0x00000000000000000000000000000000000000bb", Value: big.NewInt(int64(slot))})
+	}
+	drainHighestBids(relayClient)
+
+	messages := memory.Messages()
+	want := []string{
+		"topic/HighestBid/v1", "topic/HighestBid/v1/slot/1", "topic/HighestBid/v1/ssz",
+		"topic/HighestBid/v1", "topic/HighestBid/v1/slot/2", "topic/HighestBid/v1/ssz",
+		// slot 1 is no longer retained once slot 3 is
+		"topic/HighestBid/v1", "topic/HighestBid/v1/slot/3", "topic/HighestBid/v1/slot/1", "topic/HighestBid/v1/ssz",
+	}
+	if got := topics(messages); len(got) != len(want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	for i, message := range messages {
+		if string(message.Topic) != want[i] {
+			t.Fatalf("published %v, want %v", topics(messages), want)
+		}
+	}
+
+	// the fan-out got the same messages, without the removals of retained ones
+	for _, message := range messages {
+		if message.Retained && len(message.Payload) == 0 {
+			continue
+		}
+		received := <-subscriber.messages
+		if received.Topic != string(message.Topic) || received.Data != fanOutData(message.Topic, message.Payload) {
+			t.Fatalf("fan-out received %+v, want %s", received, message.Topic)
+		}
+	}
+	if _, retained := memory.Retained(SlotTopic(HighestBidTopic, 1)); retained {
+		t.Fatal("slot 1 still retained")
+	}
+	if late, err := fanOut.subscribe([]string{"topic/HighestBid/v1/slot/+"}); err != nil || len(late.messages) != 2 {
+		t.Fatalf("late subscriber got %d retained messages, error %v, want 2", len(late.messages), err)
+	}
+
+	// the messages are signed by the relay
+	message := new(HighestBidMessage)
+	if err := json.Unmarshal(messages[len(messages)-4].Payload, message); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyMessage(message, relayClient.Domain(), relayClient.keys.SignerForSlot(3).PublicKey()); err != nil {
+		t.Fatal(err)
+	}
+	if message.Slot != 3 || message.Value != "3" {
+		t.Fatalf("message of slot %d value %s, want slot 3 value 3", message.Slot, message.Value)
+	}
+}
+
+func TestRetainClearsRetainedSlotsOnly(t *testing.T) {
+	memory := NewMemoryPublisher(0)
+	relayClient := testBulletinBoard(t, memory, Options{Publish: PublishOptions{Buffer: 8, RetainSlots: 2}})
+
+	// no bids for a long time between slot 1 and 1000
+	for _, slot := range []uint64{1, 1000, 1001, 1003} {
+		if err := relayClient.retain(HighestBidTopic, slot, []byte("bid")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var cleared []string
+	for _, message := range memory.Messages() {
+		if len(message.Payload) == 0 {
+			cleared = append(cleared, string(message.Topic))
+		}
+	}
+	want := []string{"topic/HighestBid/v1/slot/1", "topic/HighestBid/v1/slot/1000", "topic/HighestBid/v1/slot/1001"}
+	if len(cleared) != len(want) {
+		t.Fatalf("cleared %v, want %v", cleared, want)
+	}
+	for i := range want {
+		if cleared[i] != want[i] {
+			t.Fatalf("cleared %v, want %v", cleared, want)
+		}
+	}
+	if _, retained := memory.Retained(SlotTopic(HighestBidTopic, 1003)); !retained {
+		t.Fatal("latest slot not retained")
+	}
+}
+
+func TestSlowPublisherDropsOldest(t *testing.T) {
+	memory := NewMemoryPublisher(0)
+	relayClient := testBulletinBoard(t, memory, Options{Publish: PublishOptions{Buffer: 2}})
+
+	// nothing publishes while the bids are queued, sending never blocks
+	for slot := uint64(1); slot <= 5; slot++ {
+		relayClient.SendHighestBid(HighestBid{Slot: slot, Value: big.NewInt(1)})
+	}
+	drainHighestBids(relayClient)
+
+	var slots []uint64
+	for _, published := range memory.Messages() {
+		message := new(HighestBidMessage)
+		if err := json.Unmarshal(published.Payload, message); err != nil {
+			t.Fatal(err)
+		}
+		slots = append(slots, message.Slot)
+	}
+	if len(slots) != 2 || slots[0] != 4 || slots[1] != 5 {
+		t.Fatalf("published slots %v, want the latest 4 and 5", slots)
+	}
+
+	// a failing publisher doesn't stop the queue
+	failing := new(failingPublisher)
+	relayClient = testBulletinBoard(t, failing, Options{Publish: PublishOptions{Buffer: 2}})
+	relayClient.SendHighestBid(HighestBid{Slot: 1, Value: big.NewInt(1)})
+	relayClient.SendHighestBid(HighestBid{Slot: 2, Value: big.NewInt(1)})
+	drainHighestBids(relayClient)
+	if failing.attempts != 2 || len(relayClient.Channel.HighestBidChannel) != 0 {
+		t.Fatalf("%d attempts, %d queued, want both bids tried", failing.attempts, len(relayClient.Channel.HighestBidChannel))
+	}
+}
+
+func TestDropOldest(t *testing.T) {
+	ch := make(chan int, 2)
+	for i := 1; i <= 2; i++ {
+		if dropOldest(ch, i) {
+			t.Fatalf("dropped with room for %d", i)
+		}
+	}
+	if !dropOldest(ch, 3) {
+		t.Fatal("full channel didn't drop")
+	}
+	if first, second := <-ch, <-ch; first != 2 || second != 3 {
+		t.Fatalf("queued %d and %d, want 2 and 3", first, second)
+	}
+
+	// nobody receiving on an unbuffered channel
+	if !dropOldest(make(chan int), 1) {
+		t.Fatal("unbuffered channel without a receiver didn't drop")
+	}
+}
+
+func TestMemoryPublisher(t *testing.T) {
+	publisher := NewMemoryPublisher(2)
+	for _, payload := range []string{"1", "2", "3"} {
+		publisher.Publish(HighestBidTopic, []byte(payload))
+	}
+	messages := publisher.Messages()
+	if len(messages) != 2 || string(messages[0].Payload) != "2" || string(messages[1].Payload) != "3" {
+		t.Fatalf("kept %+v, want the last 2 messages", messages)
+	}
+
+	publisher.Retain("topic/HighestBid/v1/slot/1", []byte("retained"))
+	if payload, ok := publisher.Retained("topic/HighestBid/v1/slot/1"); !ok || string(payload) != "retained" {
+		t.Fatalf("retained %q, %t", payload, ok)
+	}
+	publisher.Retain("topic/HighestBid/v1/slot/1", nil)
+	if _, ok := publisher.Retained("topic/HighestBid/v1/slot/1"); ok {
+		t.Fatal("empty retained message didn't remove the retained message")
+	}
+
+	publisher.Reset()
+	if len(publisher.Messages()) != 0 {
+		t.Fatal("messages kept after reset")
+	}
+}
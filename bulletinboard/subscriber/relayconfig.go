@@ -0,0 +1,93 @@
+package subscriber
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/pon-pbs/bbRelay/signing"
+)
+
+// RelayConfig is what a relay announces about its bulletin board on
+// /relay/config
+type RelayConfig struct {
+	MQTTBroker          string   `json:"mqtt_broker"`
+	MQTTPort            uint16   `json:"mqtt_port"`
+	PublicKeys          []string `json:"public_keys"`
+	BulletinBoardDomain string   `json:"bulletin_board_domain"`
+}
+
+// FetchRelayConfig gets the config of the relay at relayURL, e.g.
+// https://relay.example.com
+func FetchRelayConfig(ctx context.Context, client *http.Client, relayURL string) (*RelayConfig, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(relayURL, "/")+"/relay/config", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return nil, fmt.Errorf("relay config returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	config := new(RelayConfig)
+	if err := json.NewDecoder(resp.Body).Decode(config); err != nil {
+		return nil, fmt.Errorf("couldn't decode relay config: %w", err)
+	}
+	return config, nil
+}
+
+// Domain is the domain the relay signs messages with
+func (config *RelayConfig) Domain() (signing.Domain, error) {
+	return ParseDomain(config.BulletinBoardDomain)
+}
+
+// Keys are the relay keys messages may be signed with, the current key and
+// the announced next keys
+func (config *RelayConfig) Keys() ([]phase0.BLSPubKey, error) {
+	return ParseKeys(config.PublicKeys)
+}
+
+// ParseDomain parses a hex encoded domain
+func ParseDomain(domainHex string) (domain signing.Domain, err error) {
+	domainBytes, err := hexutil.Decode(domainHex)
+	if err != nil {
+		return domain, fmt.Errorf("invalid bulletin board domain %q: %w", domainHex, err)
+	}
+	if len(domainBytes) != len(domain) {
+		return domain, fmt.Errorf("invalid bulletin board domain %q: expected %d bytes", domainHex, len(domain))
+	}
+	copy(domain[:], domainBytes)
+	return domain, nil
+}
+
+// ParseKeys parses hex encoded relay public keys
+func ParseKeys(keysHex []string) ([]phase0.BLSPubKey, error) {
+	keys := make([]phase0.BLSPubKey, 0, len(keysHex))
+	for _, keyHex := range keysHex {
+		keyBytes, err := hexutil.Decode(keyHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid relay key %q: %w", keyHex, err)
+		}
+
+		var key phase0.BLSPubKey
+		if len(keyBytes) != len(key) {
+			return nil, fmt.Errorf("invalid relay key %q: expected %d bytes", keyHex, len(key))
+		}
+		copy(key[:], keyBytes)
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
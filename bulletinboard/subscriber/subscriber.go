@@ -0,0 +1,251 @@
+package subscriber
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+	pahoMQTT "github.com/eclipse/paho.mqtt.golang"
+	"github.com/sirupsen/logrus"
+
+	"github.com/pon-pbs/bbRelay/bulletinboard"
+	"github.com/pon-pbs/bbRelay/signing"
+)
+
+var (
+	ErrNoTrustedRelays = errors.New("no trusted relay keys to verify messages with")
+	ErrUnknownTopic    = errors.New("no structured messages on topic")
+)
+
+// DefaultTopics are followed when the config names none
+var DefaultTopics = []bulletinBoardTypes.MQTTTopic{
+	bulletinboard.HighestBidTopic,
+	bulletinboard.ProposerRequestTopic,
+	bulletinboard.ProposerPayloadRequestTopic,
+	bulletinboard.BountyBidTopic,
+}
+
+// Config of a Subscriber, Broker and Connection are the same options the
+// relay publishes with
+type Config struct {
+	Broker     bulletinBoardTypes.RelayMQTTOpts
+	Connection bulletinboard.ConnectionOptions
+	// QoS of the subscriptions, 0 to 2
+	QoS byte
+	// Timeout waiting for the broker to acknowledge a subscription
+	Timeout time.Duration
+
+	// Topics to follow without version, e.g. topic/HighestBid, DefaultTopics
+	// when empty
+	Topics []bulletinBoardTypes.MQTTTopic
+	// SSZ follows the SSZ encoded messages instead of the JSON ones
+	SSZ bool
+	// Retained also follows the latest message retained per slot, the broker
+	// sends those right after subscribing
+	Retained bool
+
+	// Domain the relay signs messages with, announced on /relay/config
+	Domain signing.Domain
+	// TrustedRelays are the relay keys messages must be signed with
+	TrustedRelays []phase0.BLSPubKey
+	// SkipVerify passes messages on without checking their signature
+	SkipVerify bool
+
+	// Buffer is how many events wait for the consumer before the
+	// subscription stops reading from the broker
+	Buffer int
+}
+
+// Event is a message received from the bulletin board. Message is nil when
+// the payload couldn't be decoded, Error says why the message couldn't be
+// decoded or verified.
+type Event struct {
+	Topic      string                `json:"topic"`
+	Retained   bool                  `json:"retained,omitempty"`
+	ReceivedAt time.Time             `json:"received_at"`
+	Verified   bool                  `json:"verified"`
+	Error      string                `json:"error,omitempty"`
+	Message    bulletinboard.Message `json:"message,omitempty"`
+}
+
+// Subscriber follows the bulletin board on the broker. It reconnects on its
+// own and restores its subscriptions when the connection comes back.
+type Subscriber struct {
+	config Config
+	client *bulletinboard.MQTTPublisher
+	log    *logrus.Entry
+
+	events    chan Event
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func New(config Config, log *logrus.Entry) (*Subscriber, error) {
+	if !config.SkipVerify && len(config.TrustedRelays) == 0 {
+		return nil, ErrNoTrustedRelays
+	}
+	if len(config.Topics) == 0 {
+		config.Topics = DefaultTopics
+	}
+	for _, topic := range config.Topics {
+		if bulletinboard.NewMessage(topic) == nil {
+			return nil, fmt.Errorf("%w %s", ErrUnknownTopic, topic)
+		}
+	}
+
+	subscriber := &Subscriber{
+		config: config,
+		log:    log.WithField("package", "BulletinBoardSubscriber"),
+		events: make(chan Event, config.Buffer),
+		done:   make(chan struct{}),
+	}
+
+	var err error
+	subscriber.client, err = bulletinboard.NewMQTTPublisher(config.Broker, config.Connection, bulletinboard.PublishOptions{
+		QoS:     config.QoS,
+		Timeout: config.Timeout,
+	}, subscriber.log)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, filter := range subscriber.Filters() {
+		if err := subscriber.client.Subscribe(filter, subscriber.received); err != nil {
+			subscriber.Close()
+			return nil, fmt.Errorf("couldn't subscribe to %s: %w", filter, err)
+		}
+	}
+
+	subscriber.log.WithField("topics", config.Topics).Info("Bulletin Board Subscriber Ready")
+	return subscriber, nil
+}
+
+// Filters are the MQTT topic filters subscribed to
+func (subscriber *Subscriber) Filters() []string {
+	var filters []string
+	for _, topic := range subscriber.config.Topics {
+		if subscriber.config.SSZ {
+			filters = append(filters, string(bulletinboard.SSZTopic(topic)))
+		} else {
+			filters = append(filters, string(bulletinboard.VersionedTopic(topic)))
+		}
+		if subscriber.config.Retained {
+			filters = append(filters, string(bulletinboard.VersionedTopic(topic))+"/slot/+")
+		}
+	}
+	return filters
+}
+
+// Events are the received messages in the order the broker sent them
+func (subscriber *Subscriber) Events() <-chan Event {
+	return subscriber.events
+}
+
+// Run hands every event to handle until ctx is cancelled or handle fails,
+// the subscriber is closed when it returns
+func (subscriber *Subscriber) Run(ctx context.Context, handle func(Event) error) error {
+	defer subscriber.Close()
+
+	for {
+		select {
+		case event := <-subscriber.events:
+			if err := handle(event); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Healthy returns why no messages can be received right now, nil if they can
+func (subscriber *Subscriber) Healthy() error {
+	return subscriber.client.Healthy()
+}
+
+func (subscriber *Subscriber) Close() {
+	subscriber.closeOnce.Do(func() {
+		close(subscriber.done)
+		subscriber.client.Close()
+	})
+}
+
+func (subscriber *Subscriber) received(client pahoMQTT.Client, message pahoMQTT.Message) {
+	// an empty retained message only clears an expired slot
+	if len(message.Payload()) == 0 {
+		return
+	}
+
+	event := Event{
+		Topic:      message.Topic(),
+		Retained:   message.Retained(),
+		ReceivedAt: time.Now().UTC(),
+	}
+
+	decoded, err := Decode(message.Topic(), message.Payload())
+	event.Message = decoded
+	if err == nil && !subscriber.config.SkipVerify {
+		err = bulletinboard.VerifyMessage(decoded, subscriber.config.Domain, subscriber.config.TrustedRelays...)
+		event.Verified = err == nil
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	select {
+	case subscriber.events <- event:
+	case <-subscriber.done:
+	}
+}
+
+// Decode decodes a message received on topic, JSON or SSZ encoded depending
+// on the topic
+func Decode(topic string, payload []byte) (bulletinboard.Message, error) {
+	base, ssz, err := ParseTopic(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	message := bulletinboard.NewMessage(base)
+	if message == nil {
+		return nil, fmt.Errorf("%w %s", ErrUnknownTopic, topic)
+	}
+	if ssz {
+		err = message.UnmarshalSSZ(payload)
+	} else {
+		err = json.Unmarshal(payload, message)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decode message on %s: %w", topic, err)
+	}
+	return message, nil
+}
+
+// ParseTopic splits a topic structured messages are published on into the
+// topic without version and whether the messages are SSZ encoded. Messages
+// retained per slot are JSON encoded.
+func ParseTopic(topic string) (bulletinBoardTypes.MQTTTopic, bool, error) {
+	version := "/" + bulletinboard.MessageVersion
+	index := strings.Index(topic, version+"/")
+	if index < 0 {
+		if !strings.HasSuffix(topic, version) {
+			return "", false, fmt.Errorf("%w %s", ErrUnknownTopic, topic)
+		}
+		return bulletinBoardTypes.MQTTTopic(strings.TrimSuffix(topic, version)), false, nil
+	}
+
+	base := bulletinBoardTypes.MQTTTopic(topic[:index])
+	switch suffix := topic[index+len(version):]; {
+	case suffix == "/ssz":
+		return base, true, nil
+	case strings.HasPrefix(suffix, "/slot/") && !strings.Contains(suffix[len("/slot/"):], "/"):
+		return base, false, nil
+	}
+	return "", false, fmt.Errorf("%w %s", ErrUnknownTopic, topic)
+}
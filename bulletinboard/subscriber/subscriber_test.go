@@ -0,0 +1,273 @@
+package subscriber
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+
+	bls "github.com/pon-pbs/bbRelay/bls"
+	"github.com/pon-pbs/bbRelay/bulletinboard"
+	"github.com/pon-pbs/bbRelay/signing"
+)
+
+// brokerMessage is a message as the broker hands it to the subscription
+type brokerMessage struct {
+	topic    string
+	payload  []byte
+	retained bool
+}
+
+func (message brokerMessage) Duplicate() bool   { return false }
+func (message brokerMessage) Qos() byte         { return 0 }
+func (message brokerMessage) Retained() bool    { return message.retained }
+func (message brokerMessage) Topic() string     { return message.topic }
+func (message brokerMessage) MessageID() uint16 { return 0 }
+func (message brokerMessage) Payload() []byte   { return message.payload }
+func (message brokerMessage) Ack()              {}
+
+func testSigner(t *testing.T) signing.Signer {
+	t.Helper()
+	sk, _, err := bls.GenerateNewKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := signing.NewLocalSigner(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func testDomain(t *testing.T) signing.Domain {
+	t.Helper()
+	domain, err := bulletinboard.BulletinBoardDomain("0x00000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return domain
+}
+
+// testSubscriber receives messages without a broker, they are handed to
+// received as the MQTT client would
+func testSubscriber(config Config) *Subscriber {
+	return &Subscriber{
+		config: config,
+		log:    logrus.NewEntry(logrus.New()),
+		events: make(chan Event, 64),
+		done:   make(chan struct{}),
+	}
+}
+
+func (subscriber *Subscriber) receive(t *testing.T, message bulletinboard.MemoryMessage) Event {
+	t.Helper()
+	subscriber.received(nil, brokerMessage{topic: string(message.Topic), payload: message.Payload, retained: message.Retained})
+	select {
+	case event := <-subscriber.events:
+		return event
+	default:
+		t.Fatalf("no event for %s", message.Topic)
+		return Event{}
+	}
+}
+
+// publish signs the message and publishes it the way the relay does, JSON on
+// the versioned topic, retained on the slot topic and SSZ encoded
+func publish(t *testing.T, publisher *bulletinboard.MemoryPublisher, signer signing.Signer, domain signing.Domain, topic bulletinBoardTypes.MQTTTopic, message bulletinboard.Message) {
+	t.Helper()
+	header := message.Header()
+	header.Relay = signer.PublicKey()
+	signature, err := signer.Sign(context.Background(), message, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header.Signature = signature
+
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messageSSZ, err := message.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher.Publish(bulletinboard.VersionedTopic(topic), messageJSON)
+	publisher.Retain(bulletinboard.SlotTopic(topic, header.Slot), messageJSON)
+	publisher.Publish(bulletinboard.SSZTopic(topic), messageSSZ)
+}
+
+// testMessages are a message with every field set for every topic
+func testMessages() map[bulletinBoardTypes.MQTTTopic]bulletinboard.Message {
+	header := func() bulletinboard.MessageHeader {
+		return bulletinboard.MessageHeader{
+			Version:   bulletinboard.MessageVersion,
+			Slot:      6541234,
+			BlockHash: phase0.Hash32{0xab, 0xcd},
+			Timestamp: 1695200000123,
+		}
+	}
+	builder := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	proposer := phase0.BLSPubKey{0x8a, 0x01}
+
+	return map[bulletinBoardTypes.MQTTTopic]bulletinboard.Message{
+		bulletinboard.HighestBidTopic:             &bulletinboard.HighestBidMessage{MessageHeader: header(), Builder: builder, Value: "1234567890123456789012"},
+		bulletinboard.ProposerRequestTopic:        &bulletinboard.HeaderRequestedMessage{MessageHeader: header(), Proposer: proposer},
+		bulletinboard.ProposerPayloadRequestTopic: &bulletinboard.PayloadRequestedMessage{MessageHeader: header(), Proposer: proposer},
+		bulletinboard.BountyBidTopic:              &bulletinboard.BountyBidWonMessage{MessageHeader: header(), Builder: builder},
+		bulletinboard.AuctionOpenedTopic: &bulletinboard.AuctionOpenedMessage{
+			MessageHeader:    header(),
+			ProposerIndex:    42,
+			FeeRecipient:     builder,
+			PrevRandao:       phase0.Hash32{0x01},
+			PayloadTimestamp: 1695200004,
+		},
+		bulletinboard.BidAcceptedTopic:    &bulletinboard.BidAcceptedMessage{MessageHeader: header(), Builder: builder, Value: "1000"},
+		bulletinboard.HeaderServedTopic:   &bulletinboard.HeaderServedMessage{MessageHeader: header(), Proposer: proposer, Builder: builder, Value: "1000"},
+		bulletinboard.PayloadServedTopic:  &bulletinboard.PayloadServedMessage{MessageHeader: header(), Proposer: proposer, Builder: builder, Delivered: true},
+		bulletinboard.BlockPublishedTopic: &bulletinboard.BlockPublishedMessage{MessageHeader: header(), Proposer: proposer, BlockRoot: phase0.Root{0x02}},
+	}
+}
+
+func TestDecodeEveryTopic(t *testing.T) {
+	signer, domain := testSigner(t), testDomain(t)
+	subscriber := testSubscriber(Config{Domain: domain, TrustedRelays: []phase0.BLSPubKey{signer.PublicKey()}})
+
+	for topic, message := range testMessages() {
+		publisher := bulletinboard.NewMemoryPublisher(0)
+		publish(t, publisher, signer, domain, topic, message)
+
+		messages := publisher.Messages()
+		if len(messages) != 3 {
+			t.Fatalf("%s: %d messages published, want JSON, retained and SSZ", topic, len(messages))
+		}
+		for _, published := range messages {
+			event := subscriber.receive(t, published)
+			if event.Error != "" || !event.Verified {
+				t.Fatalf("%s: event error %q, verified %t", published.Topic, event.Error, event.Verified)
+			}
+			if event.Retained != published.Retained {
+				t.Fatalf("%s: retained %t, want %t", published.Topic, event.Retained, published.Retained)
+			}
+			if !reflect.DeepEqual(event.Message, message) {
+				t.Fatalf("%s: decoded %+v, want %+v", published.Topic, event.Message, message)
+			}
+		}
+	}
+}
+
+func TestTamperedMessages(t *testing.T) {
+	signer, domain := testSigner(t), testDomain(t)
+	subscriber := testSubscriber(Config{Domain: domain, TrustedRelays: []phase0.BLSPubKey{signer.PublicKey()}})
+
+	for topic, message := range testMessages() {
+		publisher := bulletinboard.NewMemoryPublisher(0)
+		publish(t, publisher, signer, domain, topic, message)
+
+		for _, published := range publisher.Messages() {
+			if strings.HasSuffix(string(published.Topic), "/ssz") {
+				// the slot is the first field of every message
+				published.Payload[0] ^= 0x01
+			} else {
+				published.Payload = []byte(strings.Replace(string(published.Payload), `"slot":"6541234"`, `"slot":"6541235"`, 1))
+			}
+
+			event := subscriber.receive(t, published)
+			if event.Verified || !strings.Contains(event.Error, bulletinboard.ErrMessageSignature.Error()) {
+				t.Fatalf("%s: tampered message verified %t, error %q", published.Topic, event.Verified, event.Error)
+			}
+		}
+	}
+}
+
+func TestMessagesOfOtherKeys(t *testing.T) {
+	relay, other, domain := testSigner(t), testSigner(t), testDomain(t)
+	subscriber := testSubscriber(Config{Domain: domain, TrustedRelays: []phase0.BLSPubKey{relay.PublicKey()}})
+
+	// signed by a relay that isn't trusted
+	publisher := bulletinboard.NewMemoryPublisher(0)
+	publish(t, publisher, other, domain, bulletinboard.HighestBidTopic, testMessages()[bulletinboard.HighestBidTopic])
+	for _, published := range publisher.Messages() {
+		event := subscriber.receive(t, published)
+		if event.Verified || !strings.Contains(event.Error, bulletinboard.ErrUntrustedRelay.Error()) {
+			t.Fatalf("%s: untrusted message verified %t, error %q", published.Topic, event.Verified, event.Error)
+		}
+	}
+
+	// claims the trusted relay but signed by another key
+	message := testMessages()[bulletinboard.HighestBidTopic]
+	header := message.Header()
+	header.Relay = relay.PublicKey()
+	header.Signature, _ = other.Sign(context.Background(), message, domain)
+	if err := bulletinboard.VerifyMessage(message, domain, relay.PublicKey()); !errors.Is(err, bulletinboard.ErrMessageSignature) {
+		t.Fatalf("error %v for a forged relay key, want %v", err, bulletinboard.ErrMessageSignature)
+	}
+
+	// signed for another network
+	otherDomain, err := bulletinboard.BulletinBoardDomain("0x00001020")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher.Reset()
+	publish(t, publisher, relay, otherDomain, bulletinboard.HighestBidTopic, testMessages()[bulletinboard.HighestBidTopic])
+	event := subscriber.receive(t, publisher.Messages()[0])
+	if event.Verified || !strings.Contains(event.Error, bulletinboard.ErrMessageSignature.Error()) {
+		t.Fatalf("message of another network verified %t, error %q", event.Verified, event.Error)
+	}
+}
+
+func TestReceivedWithoutVerifying(t *testing.T) {
+	signer, domain := testSigner(t), testDomain(t)
+	subscriber := testSubscriber(Config{SkipVerify: true})
+
+	publisher := bulletinboard.NewMemoryPublisher(0)
+	publish(t, publisher, signer, domain, bulletinboard.BountyBidTopic, testMessages()[bulletinboard.BountyBidTopic])
+	event := subscriber.receive(t, publisher.Messages()[0])
+	if event.Error != "" || event.Verified || event.Message == nil {
+		t.Fatalf("unverified event %+v", event)
+	}
+
+	// undecodable messages are passed on with the reason
+	event = subscriber.receive(t, bulletinboard.MemoryMessage{Topic: bulletinboard.SSZTopic(bulletinboard.BountyBidTopic), Payload: []byte{0x01}})
+	if event.Message != nil || event.Error == "" {
+		t.Fatalf("undecodable event %+v", event)
+	}
+
+	// clearing a retained message isn't an event
+	subscriber.received(nil, brokerMessage{topic: string(bulletinboard.SlotTopic(bulletinboard.BountyBidTopic, 1)), retained: true})
+	if len(subscriber.events) != 0 {
+		t.Fatal("empty retained message passed on")
+	}
+}
+
+func TestParseTopic(t *testing.T) {
+	tests := []struct {
+		topic string
+		base  bulletinBoardTypes.MQTTTopic
+		ssz   bool
+		err   error
+	}{
+		{"topic/HighestBid/v1", bulletinboard.HighestBidTopic, false, nil},
+		{"topic/HighestBid/v1/ssz", bulletinboard.HighestBidTopic, true, nil},
+		{"topic/HighestBid/v1/slot/6541234", bulletinboard.HighestBidTopic, false, nil},
+		{"topic/HighestBid", "", false, ErrUnknownTopic},
+		{"topic/HighestBid/v1/json", "", false, ErrUnknownTopic},
+		{"topic/HighestBid/v1/slot/1/ssz", "", false, ErrUnknownTopic},
+	}
+	for _, test := range tests {
+		base, ssz, err := ParseTopic(test.topic)
+		if base != test.base || ssz != test.ssz || !errors.Is(err, test.err) {
+			t.Fatalf("%s: parsed %s, ssz %t, error %v", test.topic, base, ssz, err)
+		}
+	}
+
+	if _, err := Decode("topic/Unknown/v1", []byte("{}")); !errors.Is(err, ErrUnknownTopic) {
+		t.Fatalf("error %v for a topic without messages, want %v", err, ErrUnknownTopic)
+	}
+}
@@ -0,0 +1,545 @@
+package bulletinboard
+
+import (
+	"context"
+	"encoding/binary"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	beaconTypes "github.com/bsn-eng/pon-golang-types/beaconclient"
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ssz "github.com/ferranbt/fastssz"
+	"github.com/sirupsen/logrus"
+)
+
+// The auction timeline of every slot, only published as structured messages
+var (
+	AuctionOpenedTopic  = bulletinBoardTypes.MQTTTopic("topic/AuctionOpened")
+	BidAcceptedTopic    = bulletinBoardTypes.MQTTTopic("topic/BidAccepted")
+	HeaderServedTopic   = bulletinBoardTypes.MQTTTopic("topic/HeaderServed")
+	PayloadServedTopic  = bulletinBoardTypes.MQTTTopic("topic/PayloadServed")
+	BlockPublishedTopic = bulletinBoardTypes.MQTTTopic("topic/BlockPublished")
+)
+
+// Slots a delivered payload waits for its block to become head
+var deliveredSlots uint64 = 64
+
+// TimelineEvent is one of AuctionOpened, BidAccepted, HeaderServed,
+// PayloadServed or BlockPublished
+type TimelineEvent interface {
+	timelineMessage(relayClient *BulletinBoard, at time.Time) (bulletinBoardTypes.MQTTTopic, Message)
+	slot() uint64
+}
+
+// timelineEntry is a queued event and the time it happened
+type timelineEntry struct {
+	event TimelineEvent
+	at    time.Time
+}
+
+// AuctionOpened is sent when the payload attributes of a slot arrive
+type AuctionOpened struct {
+	Slot          uint64
+	ProposerIndex uint64
+	ParentHash    phase0.Hash32
+	FeeRecipient  common.Address
+	PrevRandao    phase0.Hash32
+	// Timestamp of the payload in seconds
+	Timestamp uint64
+}
+
+// BidAccepted is sent for every bid the relay stored, Value is in wei
+type BidAccepted struct {
+	Slot      uint64
+	Builder   string
+	Value     *big.Int
+	BlockHash phase0.Hash32
+}
+
+// HeaderServed is sent when getHeader returned the bid of the builder
+type HeaderServed struct {
+	Slot      uint64
+	Proposer  string
+	Builder   string
+	Value     *big.Int
+	BlockHash phase0.Hash32
+}
+
+// PayloadServed is sent when getPayload for a known bid finished, Delivered
+// is false when the payload never reached the proposer
+type PayloadServed struct {
+	Slot      uint64
+	Proposer  string
+	Builder   string
+	BlockHash phase0.Hash32
+	Delivered bool
+}
+
+// BlockPublished is sent when a block containing a payload the relay
+// delivered becomes head
+type BlockPublished struct {
+	Slot      uint64
+	Proposer  string
+	BlockHash phase0.Hash32
+	BlockRoot phase0.Root
+}
+
+// AuctionOpenedMessage is published on topic/AuctionOpened/v1, the block
+// hash is the parent block the auction builds on
+type AuctionOpenedMessage struct {
+	MessageHeader
+	ProposerIndex    uint64         `json:"proposer_index,string"`
+	FeeRecipient     common.Address `json:"fee_recipient"`
+	PrevRandao       phase0.Hash32  `json:"prev_randao"`
+	PayloadTimestamp uint64         `json:"payload_timestamp,string"`
+}
+
+// BidAcceptedMessage is published on topic/BidAccepted/v1, Value is in wei
+type BidAcceptedMessage struct {
+	MessageHeader
+	Builder common.Address `json:"builder"`
+	Value   string         `json:"value"`
+}
+
+// HeaderServedMessage is published on topic/HeaderServed/v1
+type HeaderServedMessage struct {
+	MessageHeader
+	Proposer phase0.BLSPubKey `json:"proposer"`
+	Builder  common.Address   `json:"builder"`
+	Value    string           `json:"value"`
+}
+
+// PayloadServedMessage is published on topic/PayloadServed/v1
+type PayloadServedMessage struct {
+	MessageHeader
+	Proposer  phase0.BLSPubKey `json:"proposer"`
+	Builder   common.Address   `json:"builder"`
+	Delivered bool             `json:"delivered"`
+}
+
+// BlockPublishedMessage is published on topic/BlockPublished/v1
+type BlockPublishedMessage struct {
+	MessageHeader
+	Proposer  phase0.BLSPubKey `json:"proposer"`
+	BlockRoot phase0.Root      `json:"block_root"`
+}
+
+func (event AuctionOpened) slot() uint64  { return event.Slot }
+func (event BidAccepted) slot() uint64    { return event.Slot }
+func (event HeaderServed) slot() uint64   { return event.Slot }
+func (event PayloadServed) slot() uint64  { return event.Slot }
+func (event BlockPublished) slot() uint64 { return event.Slot }
+
+func (event AuctionOpened) timelineMessage(relayClient *BulletinBoard, at time.Time) (bulletinBoardTypes.MQTTTopic, Message) {
+	return AuctionOpenedTopic, &AuctionOpenedMessage{
+		MessageHeader:    relayClient.messageHeaderAt(event.Slot, event.ParentHash, at),
+		ProposerIndex:    event.ProposerIndex,
+		FeeRecipient:     event.FeeRecipient,
+		PrevRandao:       event.PrevRandao,
+		PayloadTimestamp: event.Timestamp,
+	}
+}
+
+func (event BidAccepted) timelineMessage(relayClient *BulletinBoard, at time.Time) (bulletinBoardTypes.MQTTTopic, Message) {
+	return BidAcceptedTopic, &BidAcceptedMessage{
+		MessageHeader: relayClient.messageHeaderAt(event.Slot, event.BlockHash, at),
+		Builder:       common.HexToAddress(event.Builder),
+		Value:         weiString(event.Value),
+	}
+}
+
+func (event HeaderServed) timelineMessage(relayClient *BulletinBoard, at time.Time) (bulletinBoardTypes.MQTTTopic, Message) {
+	return HeaderServedTopic, &HeaderServedMessage{
+		MessageHeader: relayClient.messageHeaderAt(event.Slot, event.BlockHash, at),
+		Proposer:      blsPubKey(event.Proposer),
+		Builder:       common.HexToAddress(event.Builder),
+		Value:         weiString(event.Value),
+	}
+}
+
+func (event PayloadServed) timelineMessage(relayClient *BulletinBoard, at time.Time) (bulletinBoardTypes.MQTTTopic, Message) {
+	return PayloadServedTopic, &PayloadServedMessage{
+		MessageHeader: relayClient.messageHeaderAt(event.Slot, event.BlockHash, at),
+		Proposer:      blsPubKey(event.Proposer),
+		Builder:       common.HexToAddress(event.Builder),
+		Delivered:     event.Delivered,
+	}
+}
+
+func (event BlockPublished) timelineMessage(relayClient *BulletinBoard, at time.Time) (bulletinBoardTypes.MQTTTopic, Message) {
+	return BlockPublishedTopic, &BlockPublishedMessage{
+		MessageHeader: relayClient.messageHeaderAt(event.Slot, event.BlockHash, at),
+		Proposer:      blsPubKey(event.Proposer),
+		BlockRoot:     event.BlockRoot,
+	}
+}
+
+func weiString(value *big.Int) string {
+	if value == nil {
+		return "0"
+	}
+	return value.String()
+}
+
+// deliveredPayloads are the payloads waiting for their block to become head
+type deliveredPayloads struct {
+	payloads map[uint64]PayloadServed
+	mu       sync.Mutex
+}
+
+// watchBeacon opens the auction of a slot on its payload attributes and
+// publishes the block of a delivered payload once it is head
+func (relayClient *BulletinBoard) watchBeacon() {
+	relayClient.delivered = &deliveredPayloads{payloads: make(map[uint64]PayloadServed)}
+	relayClient.headBlockHash = func(ctx context.Context, blockRoot string) (phase0.Hash32, error) {
+		blockHash, err := relayClient.BeaconInterface.GetExecutionBlockHash(ctx, blockRoot)
+		return phase0.Hash32(blockHash), err
+	}
+
+	relayClient.BeaconInterface.OnPayloadAttributes(relayClient.auctionOpened)
+	relayClient.BeaconInterface.OnHead(relayClient.headUpdated)
+}
+
+func (relayClient *BulletinBoard) auctionOpened(attrs beaconTypes.PayloadAttributesEventData) {
+	var parentHash, prevRandao phase0.Hash32
+	copy(parentHash[:], hexBytes(attrs.ParentBlockHash))
+	copy(prevRandao[:], hexBytes(attrs.PayloadAttributes.PrevRandao))
+
+	relayClient.SendTimeline(AuctionOpened{
+		Slot:          attrs.ProposalSlot,
+		ProposerIndex: attrs.ProposerIndex,
+		ParentHash:    parentHash,
+		FeeRecipient:  common.HexToAddress(attrs.PayloadAttributes.SuggestedFeeRecipient),
+		PrevRandao:    prevRandao,
+		Timestamp:     attrs.PayloadAttributes.Timestamp,
+	})
+}
+
+func (relayClient *BulletinBoard) headUpdated(head beaconTypes.HeadEventData) {
+	relayClient.delivered.mu.Lock()
+	payload, ok := relayClient.delivered.payloads[head.Slot]
+	for slot := range relayClient.delivered.payloads {
+		if slot+deliveredSlots < head.Slot {
+			delete(relayClient.delivered.payloads, slot)
+		}
+	}
+	relayClient.delivered.mu.Unlock()
+
+	if ok {
+		// head listeners must not block
+		go relayClient.blockPublished(payload, head)
+	}
+}
+
+// blockPublished publishes the head block of the slot a payload was
+// delivered for when it contains the payload. The proposer may have published
+// another block, then the payload waits for a later head of the slot.
+func (relayClient *BulletinBoard) blockPublished(payload PayloadServed, head beaconTypes.HeadEventData) {
+	log := relayClient.Log.WithFields(logrus.Fields{
+		"slot":  head.Slot,
+		"block": head.Block,
+	})
+	blockHash, err := relayClient.headBlockHash(context.Background(), head.Block)
+	if err != nil {
+		log.WithError(err).Warn("Couldn't Get Head Block, Block Published Not Sent")
+		return
+	}
+	if blockHash != payload.BlockHash {
+		log.WithField("payloadBlockHash", payload.BlockHash.String()).Info("Head Block Doesn't Contain Delivered Payload")
+		return
+	}
+
+	relayClient.delivered.mu.Lock()
+	_, ok := relayClient.delivered.payloads[head.Slot]
+	delete(relayClient.delivered.payloads, head.Slot)
+	relayClient.delivered.mu.Unlock()
+	if !ok {
+		// published for an earlier head event of the block
+		return
+	}
+
+	var blockRoot phase0.Root
+	copy(blockRoot[:], hexBytes(head.Block))
+
+	relayClient.SendTimeline(BlockPublished{
+		Slot:      payload.Slot,
+		Proposer:  payload.Proposer,
+		BlockHash: payload.BlockHash,
+		BlockRoot: blockRoot,
+	})
+}
+
+// payloadServed remembers a delivered payload until its block is head
+func (relayClient *BulletinBoard) payloadServed(payload PayloadServed) {
+	if !payload.Delivered {
+		return
+	}
+	relayClient.delivered.mu.Lock()
+	relayClient.delivered.payloads[payload.Slot] = payload
+	relayClient.delivered.mu.Unlock()
+}
+
+func hexBytes(value string) []byte {
+	decoded, err := hexutil.Decode(value)
+	if err != nil {
+		return nil
+	}
+	return decoded
+}
+
+func (relayClient *BulletinBoard) AuctionTimeline(ctx context.Context) {
+
+	for {
+		select {
+		case entry := <-relayClient.Channel.TimelineChannel:
+			relayClient.publishTimeline(entry)
+		case <-ctx.Done():
+			for {
+				select {
+				case entry := <-relayClient.Channel.TimelineChannel:
+					relayClient.publishTimeline(entry)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (relayClient *BulletinBoard) publishTimeline(entry timelineEntry) {
+	topic, message := entry.event.timelineMessage(relayClient, entry.at)
+
+	err := relayClient.publishStructured(topic, message)
+	if err != nil {
+		relayClient.Log.WithError(err).WithFields(logrus.Fields{
+			"slot":  entry.event.slot(),
+			"topic": topic,
+		}).Error("Couldn't Publish Auction Timeline")
+	}
+}
+
+// SSZ encoding of the timeline messages, see messages.go:
+//
+//	AuctionOpenedMessage:  MessageHeader, proposer_index uint64, fee_recipient Bytes20, prev_randao Bytes32, payload_timestamp uint64
+//	BidAcceptedMessage:    MessageHeader, builder Bytes20, value uint256
+//	HeaderServedMessage:   MessageHeader, proposer Bytes48, builder Bytes20, value uint256
+//	PayloadServedMessage:  MessageHeader, proposer Bytes48, builder Bytes20, delivered bool
+//	BlockPublishedMessage: MessageHeader, proposer Bytes48, block_root Bytes32
+
+const (
+	auctionOpenedSSZSize  = messageHeaderSSZSize + 8 + 20 + 32 + 8 + 96
+	bidAcceptedSSZSize    = messageHeaderSSZSize + 20 + 32 + 96
+	headerServedSSZSize   = messageHeaderSSZSize + 48 + 20 + 32 + 96
+	payloadServedSSZSize  = messageHeaderSSZSize + 48 + 20 + 1 + 96
+	blockPublishedSSZSize = messageHeaderSSZSize + 48 + 32 + 96
+)
+
+func (m *AuctionOpenedMessage) MarshalSSZ() ([]byte, error) {
+	dst := m.marshalSSZTo(make([]byte, 0, auctionOpenedSSZSize))
+	dst = ssz.MarshalUint64(dst, m.ProposerIndex)
+	dst = append(dst, m.FeeRecipient[:]...)
+	dst = append(dst, m.PrevRandao[:]...)
+	dst = ssz.MarshalUint64(dst, m.PayloadTimestamp)
+	return append(dst, m.Signature[:]...), nil
+}
+
+func (m *AuctionOpenedMessage) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != auctionOpenedSSZSize {
+		return ssz.ErrSize
+	}
+	m.unmarshalSSZ(buf)
+	buf = buf[messageHeaderSSZSize:]
+	m.ProposerIndex = binary.LittleEndian.Uint64(buf[0:8])
+	copy(m.FeeRecipient[:], buf[8:28])
+	copy(m.PrevRandao[:], buf[28:60])
+	m.PayloadTimestamp = binary.LittleEndian.Uint64(buf[60:68])
+	return nil
+}
+
+func (m *AuctionOpenedMessage) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(m)
+}
+
+func (m *AuctionOpenedMessage) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(m)
+}
+
+func (m *AuctionOpenedMessage) HashTreeRootWith(hh ssz.HashWalker) error {
+	indx := hh.Index()
+	m.hashTreeRootWith(hh, messageTypeAuctionOpened)
+	hh.PutUint64(m.ProposerIndex)
+	hh.PutBytes(m.FeeRecipient[:])
+	hh.PutBytes(m.PrevRandao[:])
+	hh.PutUint64(m.PayloadTimestamp)
+	hh.Merkleize(indx)
+	return nil
+}
+
+func (m *BidAcceptedMessage) MarshalSSZ() ([]byte, error) {
+	value, err := valueSSZ(m.Value)
+	if err != nil {
+		return nil, err
+	}
+	dst := m.marshalSSZTo(make([]byte, 0, bidAcceptedSSZSize))
+	dst = append(dst, m.Builder[:]...)
+	dst = append(dst, value...)
+	return append(dst, m.Signature[:]...), nil
+}
+
+func (m *BidAcceptedMessage) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != bidAcceptedSSZSize {
+		return ssz.ErrSize
+	}
+	m.unmarshalSSZ(buf)
+	buf = buf[messageHeaderSSZSize:]
+	copy(m.Builder[:], buf[0:20])
+	m.Value = new(big.Int).SetBytes(reverse(append([]byte{}, buf[20:52]...))).String()
+	return nil
+}
+
+func (m *BidAcceptedMessage) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(m)
+}
+
+func (m *BidAcceptedMessage) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(m)
+}
+
+func (m *BidAcceptedMessage) HashTreeRootWith(hh ssz.HashWalker) error {
+	value, err := valueSSZ(m.Value)
+	if err != nil {
+		return err
+	}
+	indx := hh.Index()
+	m.hashTreeRootWith(hh, messageTypeBidAccepted)
+	hh.PutBytes(m.Builder[:])
+	hh.PutBytes(value)
+	hh.Merkleize(indx)
+	return nil
+}
+
+func (m *HeaderServedMessage) MarshalSSZ() ([]byte, error) {
+	value, err := valueSSZ(m.Value)
+	if err != nil {
+		return nil, err
+	}
+	dst := m.marshalSSZTo(make([]byte, 0, headerServedSSZSize))
+	dst = append(dst, m.Proposer[:]...)
+	dst = append(dst, m.Builder[:]...)
+	dst = append(dst, value...)
+	return append(dst, m.Signature[:]...), nil
+}
+
+func (m *HeaderServedMessage) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != headerServedSSZSize {
+		return ssz.ErrSize
+	}
+	m.unmarshalSSZ(buf)
+	buf = buf[messageHeaderSSZSize:]
+	copy(m.Proposer[:], buf[0:48])
+	copy(m.Builder[:], buf[48:68])
+	m.Value = new(big.Int).SetBytes(reverse(append([]byte{}, buf[68:100]...))).String()
+	return nil
+}
+
+func (m *HeaderServedMessage) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(m)
+}
+
+func (m *HeaderServedMessage) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(m)
+}
+
+func (m *HeaderServedMessage) HashTreeRootWith(hh ssz.HashWalker) error {
+	value, err := valueSSZ(m.Value)
+	if err != nil {
+		return err
+	}
+	indx := hh.Index()
+	m.hashTreeRootWith(hh, messageTypeHeaderServed)
+	hh.PutBytes(m.Proposer[:])
+	hh.PutBytes(m.Builder[:])
+	hh.PutBytes(value)
+	hh.Merkleize(indx)
+	return nil
+}
+
+func (m *PayloadServedMessage) MarshalSSZ() ([]byte, error) {
+	dst := m.marshalSSZTo(make([]byte, 0, payloadServedSSZSize))
+	dst = append(dst, m.Proposer[:]...)
+	dst = append(dst, m.Builder[:]...)
+	dst = ssz.MarshalBool(dst, m.Delivered)
+	return append(dst, m.Signature[:]...), nil
+}
+
+func (m *PayloadServedMessage) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != payloadServedSSZSize {
+		return ssz.ErrSize
+	}
+	m.unmarshalSSZ(buf)
+	buf = buf[messageHeaderSSZSize:]
+	copy(m.Proposer[:], buf[0:48])
+	copy(m.Builder[:], buf[48:68])
+	if buf[68] > 1 {
+		return ErrMessageBool
+	}
+	m.Delivered = ssz.UnmarshalBool(buf[68:69])
+	return nil
+}
+
+func (m *PayloadServedMessage) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(m)
+}
+
+func (m *PayloadServedMessage) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(m)
+}
+
+func (m *PayloadServedMessage) HashTreeRootWith(hh ssz.HashWalker) error {
+	indx := hh.Index()
+	m.hashTreeRootWith(hh, messageTypePayloadServed)
+	hh.PutBytes(m.Proposer[:])
+	hh.PutBytes(m.Builder[:])
+	hh.PutBool(m.Delivered)
+	hh.Merkleize(indx)
+	return nil
+}
+
+func (m *BlockPublishedMessage) MarshalSSZ() ([]byte, error) {
+	dst := m.marshalSSZTo(make([]byte, 0, blockPublishedSSZSize))
+	dst = append(dst, m.Proposer[:]...)
+	dst = append(dst, m.BlockRoot[:]...)
+	return append(dst, m.Signature[:]...), nil
+}
+
+func (m *BlockPublishedMessage) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != blockPublishedSSZSize {
+		return ssz.ErrSize
+	}
+	m.unmarshalSSZ(buf)
+	buf = buf[messageHeaderSSZSize:]
+	copy(m.Proposer[:], buf[0:48])
+	copy(m.BlockRoot[:], buf[48:80])
+	return nil
+}
+
+func (m *BlockPublishedMessage) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(m)
+}
+
+func (m *BlockPublishedMessage) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(m)
+}
+
+func (m *BlockPublishedMessage) HashTreeRootWith(hh ssz.HashWalker) error {
+	indx := hh.Index()
+	m.hashTreeRootWith(hh, messageTypeBlockPublished)
+	hh.PutBytes(m.Proposer[:])
+	hh.PutBytes(m.BlockRoot[:])
+	hh.Merkleize(indx)
+	return nil
+}
@@ -0,0 +1,125 @@
+package bulletinboard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	beaconTypes "github.com/bsn-eng/pon-golang-types/beaconclient"
+)
+
+const (
+	deliveredRoot = "0x0000000000000000000000000000000000000000000000000000000000000a01"
+	otherRoot     = "0x0000000000000000000000000000000000000000000000000000000000000a02"
+	unknownRoot   = "0x0000000000000000000000000000000000000000000000000000000000000a03"
+)
+
+var (
+	deliveredHash = phase0.Hash32{0x01}
+	otherHash     = phase0.Hash32{0x02}
+)
+
+// testTimeline is a bulletin board watching a beacon node that knows the
+// execution block hashes of the delivered and the other block
+func testTimeline(t *testing.T) *BulletinBoard {
+	t.Helper()
+	relayClient := testBulletinBoard(t, NewMemoryPublisher(0), Options{Publish: PublishOptions{Buffer: 8}})
+	relayClient.Channel.TimelineChannel = make(chan timelineEntry, 8)
+	relayClient.delivered = &deliveredPayloads{payloads: make(map[uint64]PayloadServed)}
+	relayClient.headBlockHash = func(ctx context.Context, blockRoot string) (phase0.Hash32, error) {
+		switch blockRoot {
+		case deliveredRoot:
+			return deliveredHash, nil
+		case otherRoot:
+			return otherHash, nil
+		}
+		return phase0.Hash32{}, errors.New("block not found")
+	}
+	return relayClient
+}
+
+// timelineEvents takes the queued events off the timeline
+func timelineEvents(relayClient *BulletinBoard) []TimelineEvent {
+	var events []TimelineEvent
+	for {
+		select {
+		case entry := <-relayClient.Channel.TimelineChannel:
+			events = append(events, entry.event)
+		default:
+			return events
+		}
+	}
+}
+
+func deliver(relayClient *BulletinBoard, slot uint64) PayloadServed {
+	payload := PayloadServed{Slot: slot, Proposer: "0x8a", BlockHash: deliveredHash, Delivered: true}
+	relayClient.SendTimeline(payload)
+	timelineEvents(relayClient)
+	return payload
+}
+
+func TestBlockPublishedChecksHeadBlock(t *testing.T) {
+	relayClient := testTimeline(t)
+	payload := deliver(relayClient, 10)
+
+	// the proposer published another block, or the node doesn't know it
+	for _, root := range []string{otherRoot, unknownRoot} {
+		relayClient.blockPublished(payload, beaconTypes.HeadEventData{Slot: 10, Block: root})
+		if events := timelineEvents(relayClient); len(events) != 0 {
+			t.Fatalf("head %s published %+v", root, events)
+		}
+	}
+
+	// a later head of the slot contains the payload
+	relayClient.blockPublished(payload, beaconTypes.HeadEventData{Slot: 10, Block: deliveredRoot})
+	events := timelineEvents(relayClient)
+	if len(events) != 1 {
+		t.Fatalf("published %+v, want the block", events)
+	}
+	published, ok := events[0].(BlockPublished)
+	if !ok || published.Slot != 10 || published.BlockHash != deliveredHash || published.BlockRoot != (phase0.Root{31: 0x01, 30: 0x0a}) {
+		t.Fatalf("published %+v", events[0])
+	}
+
+	// the block is published once
+	relayClient.blockPublished(payload, beaconTypes.HeadEventData{Slot: 10, Block: deliveredRoot})
+	if events := timelineEvents(relayClient); len(events) != 0 {
+		t.Fatalf("published again %+v", events)
+	}
+}
+
+func TestHeadUpdated(t *testing.T) {
+	relayClient := testTimeline(t)
+	deliver(relayClient, 10)
+
+	relayClient.headUpdated(beaconTypes.HeadEventData{Slot: 10, Block: deliveredRoot})
+	select {
+	case entry := <-relayClient.Channel.TimelineChannel:
+		if published, ok := entry.event.(BlockPublished); !ok || published.Slot != 10 {
+			t.Fatalf("published %+v, want the block of slot 10", entry.event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("block not published")
+	}
+
+	// payloads that never reached the proposer don't wait for their block
+	relayClient.SendTimeline(PayloadServed{Slot: 11, BlockHash: deliveredHash})
+	relayClient.delivered.mu.Lock()
+	_, waiting := relayClient.delivered.payloads[11]
+	relayClient.delivered.mu.Unlock()
+	if waiting {
+		t.Fatal("undelivered payload waits for its block")
+	}
+
+	// payloads whose block never became head are dropped eventually
+	deliver(relayClient, 12)
+	relayClient.headUpdated(beaconTypes.HeadEventData{Slot: 13 + deliveredSlots, Block: otherRoot})
+	relayClient.delivered.mu.Lock()
+	_, waiting = relayClient.delivered.payloads[12]
+	relayClient.delivered.mu.Unlock()
+	if waiting {
+		t.Fatal("old payload still waits for its block")
+	}
+}
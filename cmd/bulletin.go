@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/pon-pbs/bbRelay/bulletinboard"
+	"github.com/pon-pbs/bbRelay/bulletinboard/subscriber"
+)
+
+// Time given to --relay-api to return its config
+var watchRelayConfigTimeout = 10 * time.Second
+
+func init() {
+	relayCmd.AddCommand(bulletinCmd)
+	bulletinCmd.AddCommand(bulletinWatchCmd)
+
+	bulletinWatchCmd.Flags().StringVar(&watchRelayAPI, "relay-api", watchRelayAPIDefault, "Relay Whose /relay/config Gives The Broker, Relay Keys And Signing Domain")
+	bulletinWatchCmd.Flags().StringSliceVar(&watchTopics, "topics", watchTopicsDefault, "Bulletin Board Topics To Follow")
+	bulletinWatchCmd.Flags().StringSliceVar(&watchTrustedRelays, "trusted-relays", nil, "Relay Keys Messages Must Be Signed With, Added To The Keys Of --relay-api")
+	bulletinWatchCmd.Flags().StringVar(&watchDomain, "domain", watchDomainDefault, "Bulletin Board Signing Domain, Taken From --relay-api When Empty")
+	bulletinWatchCmd.Flags().StringVar(&watchClientID, "client-id", watchClientIDDefault, "MQTT Client ID, Random When Empty")
+	bulletinWatchCmd.Flags().StringVar(&watchRetained, "retained", watchRetainedDefault, "Also Follow The Latest Message Retained Per Slot")
+	bulletinWatchCmd.Flags().StringVar(&watchSkipVerify, "skip-verify", watchSkipVerifyDefault, "Print Messages Without Checking Their Signature")
+}
+
+var bulletinCmd = &cobra.Command{
+	Use:   "bulletin",
+	Short: "Follow the relay bulletin board",
+}
+
+var bulletinWatchCmd = &cobra.Command{
+	Use:           "watch",
+	Short:         "Print the verified bulletin board messages as JSON lines, connecting with the --bulletinBoard-* flags",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		config, err := loadWatchConfig(ctx, cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		log := logrus.NewEntry(logrus.StandardLogger())
+		bulletinSubscriber, err := subscriber.New(*config, log)
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		return bulletinSubscriber.Run(ctx, func(event subscriber.Event) error {
+			return encoder.Encode(event)
+		})
+	},
+}
+
+// loadWatchConfig builds the subscriber config from the bulletin board
+// flags the relay publishes with and the flags of the watch command
+func loadWatchConfig(ctx context.Context, flags *pflag.FlagSet) (*subscriber.Config, error) {
+	if err := applyConfigSources(flags); err != nil {
+		return nil, err
+	}
+	errs := readSecretFiles(flags)
+
+	p := configParser{}
+	config := &subscriber.Config{
+		Broker: bulletinBoardTypes.RelayMQTTOpts{
+			Broker:   bulletinBoardBroker,
+			Port:     p.uint("bulletinBoard-port", bulletinBoardPort),
+			UserName: bulletinBoardUserName,
+			Password: bulletinBoardPassword,
+		},
+		Connection: bulletinboard.ConnectionOptions{
+			Transport:            bulletinBoardTransport,
+			WebSocketPath:        bulletinBoardWSPath,
+			TLSCAFile:            bulletinBoardTLSCA,
+			TLSCertFile:          bulletinBoardTLSCert,
+			TLSKeyFile:           bulletinBoardTLSKey,
+			RequireBroker:        p.bool("bulletinBoard-require-broker", bulletinBoardRequire),
+			MaxReconnectInterval: p.duration("bulletinBoard-max-reconnect-interval", bulletinBoardReconnect),
+		},
+		Timeout:    p.duration("bulletinBoard-publish-timeout", bulletinBoardTimeout),
+		SSZ:        p.bool("bulletinBoard-ssz", bulletinBoardSSZ),
+		Retained:   p.bool("retained", watchRetained),
+		SkipVerify: p.bool("skip-verify", watchSkipVerify),
+		Buffer:     p.int("bulletinBoard-buffer", bulletinBoardBuffer),
+	}
+	qos := p.uint("bulletinBoard-qos", bulletinBoardQoS)
+	config.QoS = byte(qos)
+	errs = append(errs, p.errs...)
+
+	if qos > 2 {
+		errs = append(errs, errors.New("--bulletinBoard-qos must be 0, 1 or 2"))
+	}
+	if config.Timeout <= 0 {
+		errs = append(errs, errors.New("--bulletinBoard-publish-timeout must be positive"))
+	}
+	if config.Buffer < 1 {
+		errs = append(errs, errors.New("--bulletinBoard-buffer must be at least 1"))
+	}
+	if !bulletinboard.ValidTransport(config.Connection.Transport) {
+		errs = append(errs, errors.New("--bulletinBoard-transport must be tcp, ssl, ws or wss"))
+	}
+	if config.Connection.MaxReconnectInterval <= 0 {
+		errs = append(errs, errors.New("--bulletinBoard-max-reconnect-interval must be positive"))
+	}
+
+	for _, name := range watchTopics {
+		topic := bulletinBoardTypes.MQTTTopic("topic/" + name)
+		if bulletinboard.NewMessage(topic) == nil {
+			errs = append(errs, fmt.Errorf("unknown --topics %s", name))
+			continue
+		}
+		config.Topics = append(config.Topics, topic)
+	}
+
+	trusted, err := subscriber.ParseKeys(watchTrustedRelays)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid --trusted-relays: %w", err))
+	}
+	config.TrustedRelays = trusted
+
+	domain := watchDomain
+	if watchRelayAPI != "" {
+		relayConfig, err := fetchWatchRelayConfig(ctx, watchRelayAPI)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't get --relay-api config: %w", err)
+		}
+		if config.Broker.Broker == "" {
+			config.Broker.Broker = relayConfig.MQTTBroker
+		}
+		if config.Broker.Port == 0 {
+			config.Broker.Port = uint64(relayConfig.MQTTPort)
+		}
+		if domain == "" {
+			domain = relayConfig.BulletinBoardDomain
+		}
+		keys, err := relayConfig.Keys()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("--relay-api announced %w", err))
+		}
+		config.TrustedRelays = append(config.TrustedRelays, keys...)
+	}
+
+	if config.Broker.Broker == "" {
+		errs = append(errs, errors.New("--bulletinBoard-broker or --relay-api is required"))
+	}
+	if !config.SkipVerify {
+		if domain == "" {
+			errs = append(errs, errors.New("--domain or --relay-api is needed to verify messages, or set --skip-verify"))
+		} else if config.Domain, err = subscriber.ParseDomain(domain); err != nil {
+			errs = append(errs, err)
+		}
+		if len(config.TrustedRelays) == 0 {
+			errs = append(errs, errors.New("--trusted-relays or --relay-api is needed to verify messages, or set --skip-verify"))
+		}
+	}
+
+	// The relay's client id from a shared config would take over its connection
+	config.Broker.ClientID = watchClientID
+	if config.Broker.ClientID == "" {
+		suffix := make([]byte, 4)
+		if _, err := rand.Read(suffix); err != nil {
+			return nil, err
+		}
+		config.Broker.ClientID = "pon-watch-" + hexutil.Encode(suffix)[2:]
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid watch config:\n%w", errors.Join(errs...))
+	}
+	return config, nil
+}
+
+func fetchWatchRelayConfig(ctx context.Context, relayAPI string) (*subscriber.RelayConfig, error) {
+	ctx, cancel := context.WithTimeout(ctx, watchRelayConfigTimeout)
+	defer cancel()
+	return subscriber.FetchRelayConfig(ctx, http.DefaultClient, relayAPI)
+}
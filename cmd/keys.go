@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+
+	"github.com/pon-pbs/bbRelay/bls"
+)
+
+func init() {
+	relayCmd.AddCommand(keysCmd)
+	keysCmd.AddCommand(keysGenerateCmd)
+}
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the relay BLS key",
+}
+
+var keysGenerateCmd = &cobra.Command{
+	Use:           "generate",
+	Short:         "Generate a relay BLS key into the --keystore file, encrypted with --keystore-password-file",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfigSources(cmd.Flags()); err != nil {
+			return err
+		}
+		if keystoreFile == "" || keystorePasswordFile == "" {
+			return errors.New("--keystore and --keystore-password-file are required")
+		}
+
+		password, err := os.ReadFile(keystorePasswordFile)
+		if err != nil {
+			return fmt.Errorf("couldn't read keystore password: %w", err)
+		}
+
+		sk, pk, err := bls.GenerateNewKeypair()
+		if err != nil {
+			return err
+		}
+		keystore, err := bls.EncryptKeystore(sk, string(password))
+		if err != nil {
+			return err
+		}
+
+		// never overwrite a keystore, even one created while generating
+		file, err := os.OpenFile(keystoreFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("keystore %s already exists", keystoreFile)
+		} else if err != nil {
+			return fmt.Errorf("couldn't create keystore: %w", err)
+		}
+		if _, err := file.Write(keystore); err != nil {
+			file.Close()
+			return fmt.Errorf("couldn't write keystore: %w", err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("couldn't write keystore: %w", err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Keystore written to %s\nRelay public key: %s\n", keystoreFile, hexutil.Encode(pk.Compress()))
+		return nil
+	},
+}
@@ -1,55 +1,126 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"strconv"
-	"time"
+	"os"
+	"os/signal"
+	"syscall"
 
 	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
 	databaseTypes "github.com/bsn-eng/pon-golang-types/database"
-	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
-	"github.com/pon-pbs/bbRelay/bls"
+	beaconclient "github.com/pon-pbs/bbRelay/beaconinterface"
+	"github.com/pon-pbs/bbRelay/builderclient"
+	"github.com/pon-pbs/bbRelay/bulletinboard"
+	"github.com/pon-pbs/bbRelay/executionclient"
 	"github.com/pon-pbs/bbRelay/relay"
+	"github.com/pon-pbs/bbRelay/signing"
 )
 
 func init() {
 	rootCmd.AddCommand(relayCmd)
 
-	relayCmd.Flags().StringVar(&relayURL, "relay-url", relayDefaultURL, "listen address for webserver")
-	relayCmd.Flags().StringSliceVar(&beaconNodeURIs, "beacon-uris", defaultBeaconURIs, "beacon endpoints")
-	relayCmd.Flags().StringVar(&redisURI, "redis-uri", defaultRedisURI, "redis uri")
-	relayCmd.Flags().StringVar(&postgresURL, "db", defaultPostgresURL, "PostgreSQL DSN")
-	relayCmd.Flags().StringVar(&apiSecretKey, "secret-key", apiDefaultSecretKey, "secret key for signing bids")
-	relayCmd.Flags().StringVar(&network, "network", defaultNetwork, "Which network to use")
-
-	relayCmd.Flags().StringVar(&maxDBConnections, "max-db-connections", maxDBConnectionsDefault, "Maximum DB Connections")
-	relayCmd.Flags().StringVar(&maxIdleConnections, "max-idle-connections", maxIdleConnectionsDefault, "Maximum Idle Connections")
-	relayCmd.Flags().StringVar(&maxTimeConnection, "max-idle-timeout", maxTimeConnectionDefault, "Maximum Idle Timeout")
-	relayCmd.Flags().StringVar(&dbDriver, "db-driver", dbDriverDefault, "Database Driver")
-	relayCmd.Flags().BoolVar(&deleteTables, "purge", deleteTablesDefault, "Delete Database")
-
-	relayCmd.Flags().StringVar(&ponPoolURL, "pon-pool", ponPoolURLDefault, "Pon Pool URL")
-	relayCmd.Flags().StringVar(&ponPoolAPIKey, "pon-pool-API-Key", ponPoolAPIKeyDefault, "Pon Pool API Key")
-
-	relayCmd.Flags().StringVar(&bulletinBoardBroker, "bulletinBoard-broker", bulletinBoardBrokerDefault, "Bulletin Board Broker URL")
-	relayCmd.Flags().StringVar(&bulletinBoardPort, "bulletinBoard-port", bulletinBoardPortDefault, "Bulletin Board Port")
-	relayCmd.Flags().StringVar(&bulletinBoardClient, "bulletinBoard-client", bulletinBoardClientDefault, "Pon Pool URL")
-	relayCmd.Flags().StringVar(&bulletinBoardUserName, "bulletinBoard-username", bulletinBoardUsernameDefault, "Pon Pool URL")
-	relayCmd.Flags().StringVar(&bulletinBoardPassword, "bulletinBoard-password", bulletinBoardPasswordDefault, "Pon Pool URL")
-
-	relayCmd.Flags().StringVar(&reporterURL, "reporter-url", reporterURLDefault, "Reporter Server URL")
-
-	relayCmd.Flags().StringVar(&bidTimeout, "bid-timeout", bidTimeoutDefault, "Bid Timeout")
-
-	relayCmd.Flags().StringVar(&readTimeout, "relay-read-timeout", readTimeoutDefault, "Relay Read Timeout")
-	relayCmd.Flags().StringVar(&readHeaderTimeout, "relay-read-header-timeout", readHeaderTimeoutDefault, "Relay Read Header Timeout")
-	relayCmd.Flags().StringVar(&writeTimeout, "relay-write-timeout", writeTimeoutDefault, "Relay Write Timeout")
-	relayCmd.Flags().StringVar(&idleTimeout, "relay-idle-timeout", idleTimeoutDefault, "Relay Idle Timeout")
-
-	relayCmd.Flags().StringVar(&discordWebhook, "discord-webhook", discordWebhookDefault, "Discord Webhook For Relay")
+	relayCmd.PersistentFlags().StringVar(&configFile, "config", configFileDefault, "Config File (.yaml, .json or .toml) Keyed By Flag Name")
+	for _, name := range sortedKeys(secretFlags) {
+		relayCmd.PersistentFlags().String(name+"-file", "", fmt.Sprintf("File To Read --%s From", name))
+	}
+
+	relayCmd.PersistentFlags().StringVar(&relayURL, "relay-url", relayDefaultURL, "listen address for webserver")
+	relayCmd.PersistentFlags().StringSliceVar(&beaconNodeURIs, "beacon-uris", defaultBeaconURIs, "beacon endpoints")
+	relayCmd.PersistentFlags().StringVar(&beaconTimeout, "beacon-timeout", beaconTimeoutDefault, "Time A Beacon Node Gets To Answer A Call, Retries Included")
+	relayCmd.PersistentFlags().StringVar(&beaconRetries, "beacon-retries", beaconRetriesDefault, "Retries Of A Beacon Node Call After A Network Error Or 5xx")
+	relayCmd.PersistentFlags().StringVar(&beaconBackoff, "beacon-retry-backoff", beaconBackoffDefault, "Wait Before The First Beacon Node Retry, Doubled On Every Further Retry")
+	relayCmd.PersistentFlags().StringVar(&redisURI, "redis-uri", defaultRedisURI, "redis uri")
+	relayCmd.PersistentFlags().StringVar(&postgresURL, "db", defaultPostgresURL, "PostgreSQL DSN")
+	relayCmd.PersistentFlags().StringVar(&apiSecretKey, "secret-key", apiDefaultSecretKey, "secret key for signing bids")
+	relayCmd.PersistentFlags().StringVar(&keystoreFile, "keystore", keystoreFileDefault, "EIP-2335 Keystore With The Relay BLS Key")
+	relayCmd.PersistentFlags().StringVar(&keystorePasswordFile, "keystore-password-file", "", "File With The Keystore Password")
+	relayCmd.PersistentFlags().StringVar(&remoteSignerURL, "remote-signer-url", remoteSignerURLDefault, "Remote Signer URL, Used Instead Of A Local Key")
+	relayCmd.PersistentFlags().StringVar(&remoteSignerPubKey, "remote-signer-pubkey", remoteSignerPubKeyDefault, "Public Key To Sign With On The Remote Signer")
+	relayCmd.PersistentFlags().StringVar(&remoteSignerTimeout, "remote-signer-timeout", remoteSignerTimeoutDefault, "Remote Signer Request Timeout")
+	relayCmd.PersistentFlags().StringSliceVar(&nextKeystoreFiles, "next-keystores", nil, "Keystores Of Announced Next Relay Keys, Decrypted With --keystore-password-file")
+	relayCmd.PersistentFlags().StringSliceVar(&nextRemotePubKeys, "next-remote-signer-pubkeys", nil, "Announced Next Relay Keys On The Remote Signer")
+	relayCmd.PersistentFlags().StringVar(&keyCutoverSlot, "key-cutover-slot", keyCutoverSlotDefault, "Slot From Which The First Next Key Signs Bids (0 For No Cutover)")
+	relayCmd.PersistentFlags().StringVar(&network, "network", defaultNetwork, "Which network to use")
+
+	relayCmd.PersistentFlags().StringVar(&maxDBConnections, "max-db-connections", maxDBConnectionsDefault, "Maximum DB Connections")
+	relayCmd.PersistentFlags().StringVar(&maxIdleConnections, "max-idle-connections", maxIdleConnectionsDefault, "Maximum Idle Connections")
+	relayCmd.PersistentFlags().StringVar(&maxTimeConnection, "max-idle-timeout", maxTimeConnectionDefault, "Maximum Idle Timeout")
+	relayCmd.PersistentFlags().StringVar(&dbDriver, "db-driver", dbDriverDefault, "Database Driver")
+	relayCmd.PersistentFlags().BoolVar(&deleteTables, "purge", deleteTablesDefault, "Delete Database")
+
+	relayCmd.PersistentFlags().StringVar(&ponPoolURL, "pon-pool", ponPoolURLDefault, "Pon Pool URL")
+	relayCmd.PersistentFlags().StringVar(&ponPoolAPIKey, "pon-pool-API-Key", ponPoolAPIKeyDefault, "Pon Pool API Key")
+
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardBroker, "bulletinBoard-broker", bulletinBoardBrokerDefault, "Bulletin Board Broker URL")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardPort, "bulletinBoard-port", bulletinBoardPortDefault, "Bulletin Board Port")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardClient, "bulletinBoard-client", bulletinBoardClientDefault, "Pon Pool URL")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardUserName, "bulletinBoard-username", bulletinBoardUsernameDefault, "Pon Pool URL")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardPassword, "bulletinBoard-password", bulletinBoardPasswordDefault, "Pon Pool URL")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardLegacy, "bulletinBoard-legacy", bulletinBoardLegacyDefault, "Keep Publishing Legacy Text Messages On The Unversioned Topics")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardSSZ, "bulletinBoard-ssz", bulletinBoardSSZDefault, "Also Publish SSZ Encoded Messages")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardQoS, "bulletinBoard-qos", bulletinBoardQoSDefault, "MQTT QoS Of Bulletin Board Messages (0, 1 Or 2)")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardTimeout, "bulletinBoard-publish-timeout", bulletinBoardTimeoutDefault, "Time Waited For The Broker To Acknowledge A Message")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardBuffer, "bulletinBoard-buffer", bulletinBoardBufferDefault, "Messages Queued Per Topic Before The Oldest Is Dropped")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardRetain, "bulletinBoard-retain-slots", bulletinBoardRetainDefault, "Slots The Latest Message Per Slot Stays Retained (0 Disables)")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardTransport, "bulletinBoard-transport", bulletinBoardTransportDefault, "Bulletin Board Broker Transport (tcp, ssl, ws Or wss)")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardWSPath, "bulletinBoard-ws-path", bulletinBoardWSPathDefault, "Bulletin Board Broker WebSocket Path")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardTLSCA, "bulletinBoard-tls-ca", bulletinBoardTLSCADefault, "CA Certificate Verifying The Broker (System Roots When Empty)")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardTLSCert, "bulletinBoard-tls-cert", bulletinBoardTLSCertDefault, "Client Certificate For Mutual TLS")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardTLSKey, "bulletinBoard-tls-key", bulletinBoardTLSKeyDefault, "Client Key For Mutual TLS")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardRequire, "bulletinBoard-require-broker", bulletinBoardRequireDefault, "Fail Startup When The Broker Is Unreachable")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardReconnect, "bulletinBoard-max-reconnect-interval", bulletinBoardReconnectDefault, "Maximum Backoff Between Broker Reconnect Attempts")
+	relayCmd.PersistentFlags().StringSliceVar(&bulletinBoardPublishers, "bulletinBoard-publishers", bulletinBoardPublishersDefault, "Bulletin Board Publishers (mqtt, nats, kafka, fanout, memory)")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardNATSURL, "bulletinBoard-nats-url", bulletinBoardNATSURLDefault, "NATS Server Of The nats Publisher")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardNATSNKey, "bulletinBoard-nats-nkey", bulletinBoardNATSNKeyDefault, "File With The User NKey Seed The nats Publisher Authenticates With")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardKafkaURL, "bulletinBoard-kafka-rest-url", bulletinBoardKafkaURLDefault, "Kafka REST Proxy Of The kafka Publisher")
+	relayCmd.PersistentFlags().StringVar(&bulletinBoardFanOutMax, "bulletinBoard-fanout-max-subscribers", bulletinBoardFanOutMaxDefault, "WebSocket And SSE Subscribers Of The fanout Publisher (0 Is Unlimited)")
+
+	relayCmd.PersistentFlags().StringVar(&reporterURL, "reporter-url", reporterURLDefault, "Reporter Server URL")
+
+	relayCmd.PersistentFlags().StringVar(&bidTimeout, "bid-timeout", bidTimeoutDefault, "Bid Timeout")
+
+	relayCmd.PersistentFlags().StringVar(&readTimeout, "relay-read-timeout", readTimeoutDefault, "Relay Read Timeout")
+	relayCmd.PersistentFlags().StringVar(&readHeaderTimeout, "relay-read-header-timeout", readHeaderTimeoutDefault, "Relay Read Header Timeout")
+	relayCmd.PersistentFlags().StringVar(&writeTimeout, "relay-write-timeout", writeTimeoutDefault, "Relay Write Timeout")
+	relayCmd.PersistentFlags().StringVar(&idleTimeout, "relay-idle-timeout", idleTimeoutDefault, "Relay Idle Timeout")
+	relayCmd.PersistentFlags().StringVar(&shutdownTimeout, "relay-shutdown-timeout", shutdownTimeoutDefault, "Time Given To In-Flight Requests On Shutdown")
+
+	relayCmd.PersistentFlags().StringVar(&maxHeadSlotLag, "max-head-slot-lag", maxHeadSlotLagDefault, "Slots Beacon Head May Lag Wall Clock Before Relay Is Not Ready")
+	relayCmd.PersistentFlags().StringVar(&maxPonPoolSyncAge, "max-ponpool-sync-age", maxPonPoolSyncAgeDefault, "Age Of Last PON Pool Sync Before Relay Is Degraded")
+
+	relayCmd.PersistentFlags().StringVar(&adminURL, "admin-url", adminURLDefault, "Listen Address For The Admin Server (Disabled If Empty)")
+	relayCmd.PersistentFlags().StringVar(&adminToken, "admin-token", adminTokenDefault, "Bearer Token For The Admin Server")
+
+	relayCmd.PersistentFlags().StringVar(&builderAllowlist, "builder-allowlist", builderAllowlistDefault, "Only Accept Builders With An Allow Policy")
+	relayCmd.PersistentFlags().StringVar(&requireBuilderEndpoints, "require-builder-endpoints", requireBuilderEndpointsDefault, "Only Accept Bids Using Registered And Healthy Builder Endpoints")
+
+	relayCmd.PersistentFlags().StringVar(&maxSubmissionSize, "max-submission-size", maxSubmissionSizeDefault, "Max Builder Submission Body Size In Bytes")
+	relayCmd.PersistentFlags().StringVar(&maxInflightSubmissions, "max-inflight-submissions", maxInflightSubmissionsDefault, "Submissions Handled At Once Before Shedding Load (0 Disables)")
+	relayCmd.PersistentFlags().StringVar(&submissionIPRate, "submission-ip-rate", submissionIPRateDefault, "Submissions Per Second Per IP (0 Disables)")
+	relayCmd.PersistentFlags().StringVar(&submissionIPBurst, "submission-ip-burst", submissionIPBurstDefault, "Submission Burst Per IP")
+	relayCmd.PersistentFlags().StringVar(&submissionBuilderRate, "submission-builder-rate", submissionBuilderRateDefault, "Submissions Per Second Per Builder Address (0 Disables)")
+	relayCmd.PersistentFlags().StringVar(&submissionBuilderBurst, "submission-builder-burst", submissionBuilderBurstDefault, "Submission Burst Per Builder Address")
+	relayCmd.PersistentFlags().StringVar(&trustForwardedFor, "trust-forwarded-for", trustForwardedForDefault, "Rate Limit By X-Forwarded-For, Only Behind A Trusted Proxy")
+
+	relayCmd.PersistentFlags().StringVar(&builderTimeout, "builder-timeout", builderTimeoutDefault, "Timeout Of A Single getPayload Request To A Builder")
+	relayCmd.PersistentFlags().StringVar(&builderRetries, "builder-retries", builderRetriesDefault, "getPayload Retries After All Builder Endpoints Failed")
+	relayCmd.PersistentFlags().StringVar(&builderHedgeDelay, "builder-hedge-delay", builderHedgeDelayDefault, "Wait Before Also Asking The Builder's Next Fallback Endpoint")
+	relayCmd.PersistentFlags().StringVar(&builderPayloadDeadline, "builder-payload-deadline", builderPayloadDeadlineDefault, "Time Into The Slot After Which getPayload Stops Retrying")
+	relayCmd.PersistentFlags().StringVar(&builderMaxIdleConns, "builder-max-idle-conns", builderMaxIdleConnsDefault, "Idle Connections Kept Open Per Builder")
+	relayCmd.PersistentFlags().StringVar(&builderTLSCert, "builder-tls-cert", builderTLSCertDefault, "Client Certificate Presented To Builders")
+	relayCmd.PersistentFlags().StringVar(&builderTLSKey, "builder-tls-key", builderTLSKeyDefault, "Key Of The Builder Client Certificate")
+	relayCmd.PersistentFlags().StringVar(&builderTLSCA, "builder-tls-ca", builderTLSCADefault, "CA Verifying Builder Certificates (System Roots If Empty)")
+
+	relayCmd.PersistentFlags().StringVar(&payoutPoolAddress, "payout-pool-address", payoutPoolAddressDefault, "PON Payout Pool Contract Bid Payouts Must Be Sent To (Payouts Not Verified If Empty)")
+	relayCmd.PersistentFlags().StringVar(&chainID, "chain-id", chainIDDefault, "Execution Chain ID Of Payout Transactions (0 Derives It From --network)")
+	relayCmd.PersistentFlags().StringVar(&executionRPC, "execution-rpc", executionRPCDefault, "Execution Node JSON-RPC Checking Builder Wallets Can Pay (Not Checked If Empty)")
+	relayCmd.PersistentFlags().StringVar(&executionRPCTimeout, "execution-rpc-timeout", executionRPCTimeoutDefault, "Timeout Of Execution Node Requests")
+
+	relayCmd.PersistentFlags().StringVar(&discordWebhook, "discord-webhook", discordWebhookDefault, "Discord Webhook For Relay")
 }
 
 var relayCmd = &cobra.Command{
@@ -61,104 +132,197 @@ var relayCmd = &cobra.Command{
 			"version": RelayVersion,
 		})
 
-		if len(beaconNodeURIs) == 0 {
-			log.Fatal("no beacon endpoints specified")
-		}
-
-		if redisURI == "" {
-			log.Fatal("No Redis URL Specified")
-		}
-
-		if postgresURL == "" {
-			log.Fatal("couldn't read db URL")
-		}
-		maxConnections, _ := strconv.ParseInt(maxDBConnections, 10, 64)
-		maxIdle, _ := strconv.ParseInt(maxIdleConnections, 10, 64)
-		maxTimeIdle, _ := time.ParseDuration(maxTimeConnection)
-		databaseOpts := &databaseTypes.DatabaseOpts{
-			MaxConnections:        int(maxConnections),
-			MaxIdleConnections:    int(maxIdle),
-			MaxIdleTimeConnection: maxTimeIdle,
-		}
-		if ponPoolURL == "" {
-			log.Fatal("couldn't read PON Pool URL")
-		}
-
-		bulletinPort, _ := strconv.ParseInt(bulletinBoardPort, 10, 64)
-		bulletinBoardParams := &bulletinBoardTypes.RelayMQTTOpts{
-			Broker:   bulletinBoardBroker,
-			Port:     uint64(bulletinPort),
-			ClientID: bulletinBoardClient,
-			UserName: bulletinBoardUserName,
-			Password: bulletinBoardPassword,
+		config, err := loadRelayConfig(cmd.Flags())
+		if err != nil {
+			log.WithError(err).Fatal("couldn't load config")
 		}
 
-		bid, _ := time.ParseDuration(bidTimeout)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
-		if apiSecretKey == "" {
-			log.Fatal("No secret key specified")
-		} else {
-			envSkBytes, err := hexutil.Decode(apiSecretKey)
-			if err != nil {
-				log.WithError(err).Fatal("incorrect secret key provided")
-			}
-			secretKey, err = bls.SecretKeyFromBytes(envSkBytes[:])
-			if err != nil {
-				log.WithError(err).Fatal("incorrect builder API secret key provided")
-			}
+		keys, err := newKeySet(ctx, config)
+		if err != nil {
+			log.WithError(err).Fatal("couldn't create signer")
 		}
 
 		opts := &relay.RelayParams{
-			DbURL:          postgresURL,
-			DatabaseParams: *databaseOpts,
-			DbDriver:       databaseTypes.DatabaseDriver(dbDriver),
-			DeleteTables:   deleteTables,
-
-			PonPoolURL:    ponPoolURL,
-			PonPoolAPIKey: ponPoolAPIKey,
-
-			BulletinBoardParams: *bulletinBoardParams,
-
-			BeaconClientUrls: beaconNodeURIs,
-
-			ReporterURL: reporterURL,
-
-			URL: relayURL,
-
-			Network: network,
-
-			RedisURI: redisURI,
-
-			BidTimeOut: bid,
-
-			Sk: secretKey,
+			DbURL: config.PostgresURL,
+			DatabaseParams: databaseTypes.DatabaseOpts{
+				MaxConnections:        config.MaxDBConnections,
+				MaxIdleConnections:    config.MaxIdleConnections,
+				MaxIdleTimeConnection: config.MaxIdleTimeout,
+			},
+			DbDriver:     databaseTypes.DatabaseDriver(config.DBDriver),
+			DeleteTables: config.DeleteTables,
+
+			PonPoolURL:    config.PonPoolURL,
+			PonPoolAPIKey: config.PonPoolAPIKey,
+
+			BulletinBoardParams: bulletinBoardTypes.RelayMQTTOpts{
+				Broker:   config.BulletinBoardBroker,
+				Port:     config.BulletinBoardPort,
+				ClientID: config.BulletinBoardClient,
+				UserName: config.BulletinBoardUserName,
+				Password: config.BulletinBoardPassword,
+			},
+
+			BulletinBoard: bulletinboard.Options{
+				Messages: bulletinboard.MessageOptions{
+					Legacy: config.BulletinBoardLegacy,
+					SSZ:    config.BulletinBoardSSZ,
+				},
+				Publish: bulletinboard.PublishOptions{
+					QoS:         byte(config.BulletinBoardQoS),
+					Timeout:     config.BulletinBoardTimeout,
+					Buffer:      config.BulletinBoardBuffer,
+					RetainSlots: config.BulletinBoardRetainSlots,
+				},
+				Connection: bulletinboard.ConnectionOptions{
+					Transport:            config.BulletinBoardTransport,
+					WebSocketPath:        config.BulletinBoardWSPath,
+					TLSCAFile:            config.BulletinBoardTLSCA,
+					TLSCertFile:          config.BulletinBoardTLSCert,
+					TLSKeyFile:           config.BulletinBoardTLSKey,
+					RequireBroker:        config.BulletinBoardRequire,
+					MaxReconnectInterval: config.BulletinBoardReconnect,
+				},
+				Transports: bulletinboard.TransportOptions{
+					Publishers:           config.BulletinBoardPublishers,
+					NATSURL:              config.BulletinBoardNATSURL,
+					NATSNKeySeedFile:     config.BulletinBoardNATSNKey,
+					KafkaRESTURL:         config.BulletinBoardKafkaURL,
+					FanOutMaxSubscribers: config.BulletinBoardFanOutMax,
+				},
+			},
+
+			BeaconClientUrls: config.BeaconURIs,
+			BeaconNode: beaconclient.NodeOptions{
+				Timeout: config.BeaconTimeout,
+				Retries: config.BeaconRetries,
+				Backoff: config.BeaconBackoff,
+			},
+
+			ReporterURL: config.ReporterURL,
+
+			URL: config.RelayURL,
+
+			Network: config.Network,
+
+			RedisURI: config.RedisURI,
+
+			BidTimeOut: config.BidTimeout,
+
+			Keys: keys,
 
 			Version: RelayVersion,
 
-			DiscordWebhook: discordWebhook,
+			DiscordWebhook: config.DiscordWebhook,
+
+			Health: relay.HealthParams{
+				MaxHeadSlotLag:    config.MaxHeadSlotLag,
+				MaxPonPoolSyncAge: config.MaxPonPoolSyncAge,
+			},
+
+			Admin: relay.AdminParams{
+				URL:   config.AdminURL,
+				Token: config.AdminToken,
+			},
+
+			BuilderAllowlist:        config.BuilderAllowlist,
+			RequireBuilderEndpoints: config.RequireBuilderEndpoints,
+
+			Limits: relay.SubmissionLimits{
+				MaxBodySize:       int64(config.MaxSubmissionSize),
+				MaxInflight:       config.MaxInflightSubmissions,
+				IPRate:            config.SubmissionIPRate,
+				IPBurst:           config.SubmissionIPBurst,
+				BuilderRate:       config.SubmissionBuilderRate,
+				BuilderBurst:      config.SubmissionBuilderBurst,
+				TrustForwardedFor: config.TrustForwardedFor,
+			},
+
+			BuilderClient: builderclient.Params{
+				Timeout:                config.BuilderTimeout,
+				Retries:                config.BuilderRetries,
+				HedgeDelay:             config.BuilderHedgeDelay,
+				TLSCertFile:            config.BuilderTLSCert,
+				TLSKeyFile:             config.BuilderTLSKey,
+				TLSCAFile:              config.BuilderTLSCA,
+				MaxIdleConnsPerBuilder: config.BuilderMaxIdleConns,
+			},
+			PayloadTimeout: config.BuilderPayloadDeadline,
+
+			Payout: relay.PayoutParams{
+				PoolAddress: config.PayoutPoolAddress,
+				ChainID:     config.ChainID,
+			},
+
+			Execution: executionclient.Params{
+				URL:     config.ExecutionRPC,
+				Timeout: config.ExecutionRPCTimeout,
+			},
 		}
 
-		srv, err := relay.NewRelayAPI(opts, log)
+		srv, err := relay.NewRelayAPI(ctx, opts, log)
 		if err != nil {
 			log.WithError(err).Fatal("failed to create service")
 		}
 
-		readTimeoutTime, _ := time.ParseDuration(readTimeout)
-		readHeaderTimeoutTime, _ := time.ParseDuration(readHeaderTimeout)
-		writeTimeoutTime, _ := time.ParseDuration(writeTimeout)
-		idleTimeoutTime, _ := time.ParseDuration(idleTimeout)
 		serverParams := &relay.RelayServerParams{
-			ReadTimeout:       readTimeoutTime,
-			ReadHeaderTimeout: readHeaderTimeoutTime,
-			WriteTimeout:      writeTimeoutTime,
-			IdleTimeout:       idleTimeoutTime,
+			ReadTimeout:       config.ReadTimeout,
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			WriteTimeout:      config.WriteTimeout,
+			IdleTimeout:       config.IdleTimeout,
+			ShutdownTimeout:   config.ShutdownTimeout,
 		}
 
 		fmt.Println(pon_painiting)
 		log.Infof("Webserver starting on %s ...", srv.URL)
-		err = srv.StartServer(serverParams)
+		err = srv.StartServer(ctx, serverParams)
 		if err != nil {
 			log.WithError(err).Fatal("server error")
 		}
 	},
 }
+
+func newKeySet(ctx context.Context, config *RelayConfig) (*signing.KeySet, error) {
+	var (
+		primary signing.Signer
+		next    []signing.Signer
+		err     error
+	)
+
+	if config.RemoteSignerURL != "" {
+		primary, err = signing.NewRemoteSigner(ctx, config.RemoteSignerURL, config.RemoteSignerPubKey, config.RemoteSignerTimeout)
+		if err != nil {
+			return nil, err
+		}
+		for _, pubkey := range config.NextRemoteSignerPubKeys {
+			signer, err := signing.NewRemoteSigner(ctx, config.RemoteSignerURL, pubkey, config.RemoteSignerTimeout)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, signer)
+		}
+	} else {
+		primary, err = signing.NewLocalSigner(config.SecretKey)
+		if err != nil {
+			return nil, err
+		}
+		for _, sk := range config.NextSecretKeys {
+			signer, err := signing.NewLocalSigner(sk)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, signer)
+		}
+	}
+
+	keys := signing.NewKeySet(primary, next...)
+	if config.KeyCutoverSlot != 0 {
+		if err := keys.ScheduleCutover(next[0].PublicKey(), config.KeyCutoverSlot, 0); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
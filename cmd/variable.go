@@ -1,66 +1,193 @@
 package cmd
 
-import (
-	"github.com/pon-pbs/bbRelay/bls"
-)
-
 var (
-	beaconNodeURIs        []string
-	redisURI              string
-	postgresURL           string
-	ponSubgraph           string
-	network               string
-	relayURL              string
-	apiSecretKey          string
-	maxDBConnections      string
-	maxIdleConnections    string
-	maxTimeConnection     string
-	dbDriver              string
-	ponPoolURL            string
-	ponPoolAPIKey         string
-	bulletinBoardBroker   string
-	bulletinBoardPort     string
-	bulletinBoardClient   string
-	bulletinBoardUserName string
-	bulletinBoardPassword string
-	reporterURL           string
-	bidTimeout            string
-	secretKey             *bls.SecretKey
-	readTimeout           string
-	readHeaderTimeout     string
-	writeTimeout          string
-	idleTimeout           string
-	deleteTables          bool
-	discordWebhook        string
+	beaconNodeURIs          []string
+	beaconTimeout           string
+	beaconRetries           string
+	beaconBackoff           string
+	redisURI                string
+	postgresURL             string
+	ponSubgraph             string
+	network                 string
+	relayURL                string
+	apiSecretKey            string
+	maxDBConnections        string
+	maxIdleConnections      string
+	maxTimeConnection       string
+	dbDriver                string
+	ponPoolURL              string
+	ponPoolAPIKey           string
+	bulletinBoardBroker     string
+	bulletinBoardPort       string
+	bulletinBoardClient     string
+	bulletinBoardUserName   string
+	bulletinBoardPassword   string
+	bulletinBoardLegacy     string
+	bulletinBoardSSZ        string
+	bulletinBoardQoS        string
+	bulletinBoardTimeout    string
+	bulletinBoardBuffer     string
+	bulletinBoardRetain     string
+	bulletinBoardTransport  string
+	bulletinBoardWSPath     string
+	bulletinBoardTLSCA      string
+	bulletinBoardTLSCert    string
+	bulletinBoardTLSKey     string
+	bulletinBoardRequire    string
+	bulletinBoardReconnect  string
+	bulletinBoardPublishers []string
+	bulletinBoardNATSURL    string
+	bulletinBoardNATSNKey   string
+	bulletinBoardKafkaURL   string
+	bulletinBoardFanOutMax  string
+	reporterURL             string
+	bidTimeout              string
+	readTimeout             string
+	readHeaderTimeout       string
+	writeTimeout            string
+	idleTimeout             string
+	shutdownTimeout         string
+	maxHeadSlotLag          string
+	maxPonPoolSyncAge       string
+	deleteTables            bool
+	discordWebhook          string
+	configFile              string
+	keystoreFile            string
+	keystorePasswordFile    string
+	remoteSignerURL         string
+	remoteSignerPubKey      string
+	remoteSignerTimeout     string
+	nextKeystoreFiles       []string
+	nextRemotePubKeys       []string
+	keyCutoverSlot          string
+	adminURL                string
+	adminToken              string
+	builderAllowlist        string
+	requireBuilderEndpoints string
+
+	maxSubmissionSize      string
+	maxInflightSubmissions string
+	submissionIPRate       string
+	submissionIPBurst      string
+	submissionBuilderRate  string
+	submissionBuilderBurst string
+	trustForwardedFor      string
+
+	builderTimeout         string
+	builderRetries         string
+	builderHedgeDelay      string
+	builderPayloadDeadline string
+	builderMaxIdleConns    string
+	builderTLSCert         string
+	builderTLSKey          string
+	builderTLSCA           string
+
+	payoutPoolAddress string
+	chainID           string
+
+	executionRPC        string
+	executionRPCTimeout string
+
+	watchRelayAPI      string
+	watchTopics        []string
+	watchTrustedRelays []string
+	watchDomain        string
+	watchClientID      string
+	watchRetained      string
+	watchSkipVerify    string
 )
 
 var (
-	relayDefaultURL              = "localhost:9062"
-	apiDefaultSecretKey          = ""
-	defaultNetwork               = "Ethereum"
-	defaultPostgresURL           = ""
-	defaultRedisURI              = "redis://localhost:6379"
-	defaultBeaconURIs            = []string{"http://localhost:3500"}
-	maxDBConnectionsDefault      = "100"
-	maxIdleConnectionsDefault    = "100"
-	maxTimeConnectionDefault     = "100s"
-	dbDriverDefault              = "postgres"
-	ponPoolURLDefault            = ""
-	ponPoolAPIKeyDefault         = ""
-	bulletinBoardBrokerDefault   = ""
-	bulletinBoardPortDefault     = ""
-	bulletinBoardClientDefault   = ""
-	bulletinBoardUserNameDefault = ""
-	bulletinBoardPasswordDefault = ""
-	bulletinBoardUsernameDefault = ""
-	reporterURLDefault           = "localhost:9001"
-	bidTimeoutDefault            = "15s"
-	readTimeoutDefault           = "10s"
-	readHeaderTimeoutDefault     = "10s"
-	writeTimeoutDefault          = "10s"
-	idleTimeoutDefault           = "10s"
-	deleteTablesDefault          = false
-	discordWebhookDefault        = ""
+	relayDefaultURL                = "localhost:9062"
+	apiDefaultSecretKey            = ""
+	defaultNetwork                 = "Ethereum"
+	defaultPostgresURL             = ""
+	defaultRedisURI                = "redis://localhost:6379"
+	defaultBeaconURIs              = []string{"http://localhost:3500"}
+	beaconTimeoutDefault           = "2s"
+	beaconRetriesDefault           = "1"
+	beaconBackoffDefault           = "100ms"
+	maxDBConnectionsDefault        = "100"
+	maxIdleConnectionsDefault      = "100"
+	maxTimeConnectionDefault       = "100s"
+	dbDriverDefault                = "postgres"
+	ponPoolURLDefault              = ""
+	ponPoolAPIKeyDefault           = ""
+	bulletinBoardBrokerDefault     = ""
+	bulletinBoardPortDefault       = ""
+	bulletinBoardClientDefault     = ""
+	bulletinBoardUserNameDefault   = ""
+	bulletinBoardPasswordDefault   = ""
+	bulletinBoardUsernameDefault   = ""
+	bulletinBoardLegacyDefault     = "true"
+	bulletinBoardSSZDefault        = "false"
+	bulletinBoardQoSDefault        = "1"
+	bulletinBoardTimeoutDefault    = "1s"
+	bulletinBoardBufferDefault     = "64"
+	bulletinBoardRetainDefault     = "32"
+	bulletinBoardTransportDefault  = "tcp"
+	bulletinBoardWSPathDefault     = "/mqtt"
+	bulletinBoardTLSCADefault      = ""
+	bulletinBoardTLSCertDefault    = ""
+	bulletinBoardTLSKeyDefault     = ""
+	bulletinBoardRequireDefault    = "false"
+	bulletinBoardReconnectDefault  = "30s"
+	bulletinBoardPublishersDefault = []string{"mqtt"}
+	bulletinBoardNATSURLDefault    = ""
+	bulletinBoardNATSNKeyDefault   = ""
+	bulletinBoardKafkaURLDefault   = ""
+	bulletinBoardFanOutMaxDefault  = "1000"
+	reporterURLDefault             = "localhost:9001"
+	bidTimeoutDefault              = "15s"
+	readTimeoutDefault             = "10s"
+	readHeaderTimeoutDefault       = "10s"
+	writeTimeoutDefault            = "10s"
+	idleTimeoutDefault             = "10s"
+	shutdownTimeoutDefault         = "30s"
+	maxHeadSlotLagDefault          = "2"
+	maxPonPoolSyncAgeDefault       = "12m48s"
+	deleteTablesDefault            = false
+	discordWebhookDefault          = ""
+	configFileDefault              = ""
+	keystoreFileDefault            = ""
+	remoteSignerURLDefault         = ""
+	remoteSignerPubKeyDefault      = ""
+	remoteSignerTimeoutDefault     = "1s"
+	keyCutoverSlotDefault          = "0"
+	adminURLDefault                = ""
+	adminTokenDefault              = ""
+	builderAllowlistDefault        = "false"
+	requireBuilderEndpointsDefault = "false"
+
+	maxSubmissionSizeDefault      = "10485760"
+	maxInflightSubmissionsDefault = "256"
+	submissionIPRateDefault       = "50"
+	submissionIPBurstDefault      = "100"
+	submissionBuilderRateDefault  = "20"
+	submissionBuilderBurstDefault = "40"
+	trustForwardedForDefault      = "false"
+
+	builderTimeoutDefault         = "1s"
+	builderRetriesDefault         = "2"
+	builderHedgeDelayDefault      = "300ms"
+	builderPayloadDeadlineDefault = "4s"
+	builderMaxIdleConnsDefault    = "4"
+	builderTLSCertDefault         = ""
+	builderTLSKeyDefault          = ""
+	builderTLSCADefault           = ""
+
+	payoutPoolAddressDefault = ""
+	chainIDDefault           = "0"
+
+	executionRPCDefault        = ""
+	executionRPCTimeoutDefault = "500ms"
+
+	watchRelayAPIDefault   = ""
+	watchTopicsDefault     = []string{"HighestBid", "ProposerSlotHeaderRequest", "ProposerPayloadRequest", "BountyBidWon"}
+	watchDomainDefault     = ""
+	watchClientIDDefault   = ""
+	watchRetainedDefault   = "false"
+	watchSkipVerifyDefault = "false"
 )
 
 var RelayVersion = "dev"
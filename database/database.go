@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 
 	databaseTypes "github.com/bsn-eng/pon-golang-types/database"
 	ponPoolTypes "github.com/bsn-eng/pon-golang-types/ponPool"
@@ -39,12 +40,13 @@ func NewDatabase(url string,
 		}
 	}
 
-	// Apply migration
+	// Apply migrations
 	currentDir, err := os.Getwd()
-	migrationFilePath := filepath.Join(currentDir, "database", "migrations", "0001_initialize_tables.up.sql")
-
-	if err := dbInterface.applyMigration(migrationFilePath); err != nil {
-		return nil, err
+	for _, migration := range upMigrations {
+		migrationFilePath := filepath.Join(currentDir, "database", "migrations", migration)
+		if err := dbInterface.applyMigration(migrationFilePath); err != nil {
+			return nil, err
+		}
 	}
 
 	dbInterface.NewDatabaseOpts()
@@ -60,15 +62,14 @@ func NewDatabase(url string,
 func (db *DatabaseInterface) purgeDatabase() error {
 	db.Log.Info("Deleting Tables")
 	currentDir, err := os.Getwd()
-	migrationFilePath := filepath.Join(currentDir, "database", "migrations", "0001_remove_tables.down.sql")
-	migrationSQL, err := os.ReadFile(migrationFilePath)
 	if err != nil {
 		return err
 	}
-
-	_, err = db.DB.Exec(string(migrationSQL))
-	if err != nil {
-		return err
+	for _, migration := range downMigrations {
+		migrationFilePath := filepath.Join(currentDir, "database", "migrations", migration)
+		if err := db.applyMigration(migrationFilePath); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -391,3 +392,204 @@ func (database *DatabaseInterface) GetValidatorDeliveredPayloadReporter(ctx cont
 
 	return &returnedValidatorBlocks, nil
 }
+
+// Builder Policy Functions
+
+func (database *DatabaseInterface) GetBuilderPolicies(ctx context.Context) ([]BuilderPolicy, error) {
+
+	query := `SELECT builder_pubkey, policy, reason, expires_at, updated_by, updated_at
+	FROM builder_policy`
+
+	rows, err := database.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []BuilderPolicy{}
+	for rows.Next() {
+		policy := BuilderPolicy{}
+		var expiresAt sql.NullTime
+		err = rows.Scan(&policy.BuilderPubkey, &policy.Policy, &policy.Reason, &expiresAt, &policy.UpdatedBy, &policy.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			policy.ExpiresAt = &expiresAt.Time
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, rows.Err()
+}
+
+// PutBuilderPolicy sets the policy of a builder and records it in the audit table
+func (database *DatabaseInterface) PutBuilderPolicy(ctx context.Context, policy BuilderPolicy) error {
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO builder_policy
+		(builder_pubkey, policy, reason, expires_at, updated_by, updated_at) VALUES
+		($1, $2, $3, $4, $5, current_timestamp) ON CONFLICT (builder_pubkey) DO UPDATE SET
+		policy = $2, reason = $3, expires_at = $4, updated_by = $5, updated_at = current_timestamp`
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		policy.BuilderPubkey,
+		policy.Policy,
+		policy.Reason,
+		policy.ExpiresAt,
+		policy.UpdatedBy,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = putBuilderPolicyAudit(ctx, tx, BuilderPolicyAudit{
+		BuilderPubkey: policy.BuilderPubkey,
+		Action:        BuilderPolicyActionSet,
+		Policy:        policy.Policy,
+		Reason:        policy.Reason,
+		ExpiresAt:     policy.ExpiresAt,
+		Actor:         policy.UpdatedBy,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteBuilderPolicy removes the policy of a builder and records it in the audit table
+func (database *DatabaseInterface) DeleteBuilderPolicy(ctx context.Context, builderPubkey string, reason string, actor string) error {
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var policy string
+	query := `DELETE FROM builder_policy WHERE builder_pubkey = $1 RETURNING policy`
+	err = tx.QueryRowContext(ctx, query, builderPubkey).Scan(&policy)
+	if err != nil {
+		return err
+	}
+
+	err = putBuilderPolicyAudit(ctx, tx, BuilderPolicyAudit{
+		BuilderPubkey: builderPubkey,
+		Action:        BuilderPolicyActionRemove,
+		Policy:        policy,
+		Reason:        reason,
+		Actor:         actor,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func putBuilderPolicyAudit(ctx context.Context, tx *sql.Tx, audit BuilderPolicyAudit) error {
+
+	query := `INSERT INTO builder_policy_audit
+		(builder_pubkey, action, policy, reason, expires_at, actor) VALUES
+		($1, $2, $3, $4, $5, $6)`
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		audit.BuilderPubkey,
+		audit.Action,
+		audit.Policy,
+		audit.Reason,
+		audit.ExpiresAt,
+		audit.Actor,
+	)
+
+	return err
+}
+
+func (database *DatabaseInterface) GetBuilderPolicyAudit(ctx context.Context, builderPubkey string) ([]BuilderPolicyAudit, error) {
+
+	query := `SELECT id, inserted_at, builder_pubkey, action, policy, reason, expires_at, actor
+	FROM builder_policy_audit
+	WHERE builder_pubkey = $1
+	ORDER BY id DESC`
+
+	rows, err := database.DB.QueryContext(ctx, query, builderPubkey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	audits := []BuilderPolicyAudit{}
+	for rows.Next() {
+		audit := BuilderPolicyAudit{}
+		var expiresAt sql.NullTime
+		err = rows.Scan(&audit.ID, &audit.InsertedAt, &audit.BuilderPubkey, &audit.Action, &audit.Policy, &audit.Reason, &expiresAt, &audit.Actor)
+		if err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			audit.ExpiresAt = &expiresAt.Time
+		}
+		audits = append(audits, audit)
+	}
+
+	return audits, rows.Err()
+}
+
+// Builder Endpoint Functions
+
+// PutBuilderEndpoints stores the registration unless the stored one is as new
+// or newer, in which case sql.ErrNoRows is returned. Relay instances only
+// know their own registrations, the database decides which one is the latest.
+func (database *DatabaseInterface) PutBuilderEndpoints(ctx context.Context, endpoints BuilderEndpoints) error {
+
+	query := `INSERT INTO builder_endpoints
+		(builder_wallet_address, endpoints, registered_timestamp, signature, updated_at) VALUES
+		($1, $2, $3, $4, current_timestamp) ON CONFLICT (builder_wallet_address) DO UPDATE SET
+		endpoints = EXCLUDED.endpoints, registered_timestamp = EXCLUDED.registered_timestamp,
+		signature = EXCLUDED.signature, updated_at = current_timestamp
+		WHERE builder_endpoints.registered_timestamp < EXCLUDED.registered_timestamp
+		RETURNING registered_timestamp`
+	var registered uint64
+	return database.DB.QueryRowContext(
+		ctx,
+		query,
+		endpoints.BuilderWalletAddress,
+		strings.Join(endpoints.Endpoints, ","),
+		endpoints.Timestamp,
+		endpoints.Signature,
+	).Scan(&registered)
+}
+
+func (database *DatabaseInterface) GetBuilderEndpoints(ctx context.Context) ([]BuilderEndpoints, error) {
+
+	query := `SELECT builder_wallet_address, endpoints, registered_timestamp, signature, updated_at
+	FROM builder_endpoints`
+
+	rows, err := database.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	registrations := []BuilderEndpoints{}
+	for rows.Next() {
+		registration := BuilderEndpoints{}
+		var endpoints string
+		err = rows.Scan(&registration.BuilderWalletAddress, &endpoints, &registration.Timestamp, &registration.Signature, &registration.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		registration.Endpoints = strings.Split(endpoints, ",")
+		registrations = append(registrations, registration)
+	}
+
+	return registrations, rows.Err()
+}
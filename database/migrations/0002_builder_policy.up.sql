@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS builder_policy (
+	builder_pubkey      VARCHAR(98) NOT NULL PRIMARY KEY,
+	policy              VARCHAR(10) NOT NULL,
+	reason              TEXT NOT NULL,
+	expires_at          TIMESTAMP,
+	updated_by          TEXT NOT NULL,
+	updated_at          TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE TABLE IF NOT EXISTS builder_policy_audit (
+	id                  BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	inserted_at         TIMESTAMP NOT NULL DEFAULT current_timestamp,
+	builder_pubkey      VARCHAR(98) NOT NULL,
+	action              VARCHAR(10) NOT NULL,
+	policy              VARCHAR(10) NOT NULL,
+	reason              TEXT NOT NULL,
+	expires_at          TIMESTAMP,
+	actor               TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_builder_policy_audit ON builder_policy_audit(builder_pubkey);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS builder_policy_audit;
+DROP TABLE IF EXISTS builder_policy;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS builder_endpoints (
+	builder_wallet_address  VARCHAR(42) NOT NULL PRIMARY KEY,
+	endpoints               TEXT NOT NULL,
+	registered_timestamp    BIGINT NOT NULL,
+	signature               TEXT NOT NULL,
+	updated_at              TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
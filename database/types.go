@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"time"
 
 	databaseTypes "github.com/bsn-eng/pon-golang-types/database"
 	migrate "github.com/golang-migrate/migrate/v4"
@@ -9,6 +10,57 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	upMigrations = []string{
+		"0001_initialize_tables.up.sql",
+		"0002_builder_policy.up.sql",
+		"0003_builder_endpoints.up.sql",
+	}
+	downMigrations = []string{
+		"0003_remove_builder_endpoints.down.sql",
+		"0002_remove_builder_policy.down.sql",
+		"0001_remove_tables.down.sql",
+	}
+)
+
+const (
+	BuilderPolicyDeny  = "deny"
+	BuilderPolicyAllow = "allow"
+
+	BuilderPolicyActionSet    = "set"
+	BuilderPolicyActionRemove = "remove"
+)
+
+type BuilderPolicy struct {
+	BuilderPubkey string     `json:"builder"`
+	Policy        string     `json:"policy"`
+	Reason        string     `json:"reason"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	UpdatedBy     string     `json:"updated_by"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// BuilderEndpoints are the payload endpoints a builder registered, signed
+// with its wallet key
+type BuilderEndpoints struct {
+	BuilderWalletAddress string    `json:"builder_wallet_address"`
+	Endpoints            []string  `json:"endpoints"`
+	Timestamp            uint64    `json:"timestamp,string"`
+	Signature            string    `json:"signature"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+type BuilderPolicyAudit struct {
+	ID            uint64     `json:"id"`
+	InsertedAt    time.Time  `json:"inserted_at"`
+	BuilderPubkey string     `json:"builder"`
+	Action        string     `json:"action"`
+	Policy        string     `json:"policy"`
+	Reason        string     `json:"reason"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	Actor         string     `json:"actor"`
+}
+
 type DatabaseInterface struct {
 	DB     *sql.DB // Function so we have functions on top of it
 	Opts   databaseTypes.DatabaseOpts
@@ -26,6 +78,10 @@ func (database *DatabaseInterface) NewDatabaseOpts() {
 	database.DB.SetConnMaxIdleTime(database.Opts.MaxIdleTimeConnection)
 }
 
+func (database *DatabaseInterface) Close() error {
+	return database.DB.Close()
+}
+
 func (database *DatabaseInterface) DBMigrate() error {
 	migrationOpts, err := iofs.New(databaseTypes.Content, "migrations/")
 	if err != nil {
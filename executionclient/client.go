@@ -0,0 +1,131 @@
+package executionclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/sirupsen/logrus"
+)
+
+func NewClient(params Params) *Client {
+	return &Client{
+		url:    params.URL,
+		client: &http.Client{Timeout: params.Timeout},
+		cache:  make(map[common.Hash]map[common.Address]*AccountState),
+		log: logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+			"package": "ExecutionClient",
+		}),
+	}
+}
+
+func (c *Client) Logger() *logrus.Logger {
+	return c.log.Logger
+}
+
+// CheckPayout checks the builder wallet can pay the value and the max gas fee
+// of the payout transaction after the parent block and hasn't used its nonce yet
+func (c *Client) CheckPayout(ctx context.Context, parentHash common.Hash, builder common.Address, payout *types.Transaction) error {
+	state, err := c.Account(ctx, parentHash, builder)
+	if err != nil {
+		return err
+	}
+	if state.Nonce > payout.Nonce() {
+		return fmt.Errorf("%w: payout nonce %d already used, wallet nonce %d", ErrInsufficientFunds, payout.Nonce(), state.Nonce)
+	}
+	if state.Balance.Cmp(payout.Cost()) < 0 {
+		return fmt.Errorf("%w: balance %d, payout cost %d", ErrInsufficientFunds, state.Balance, payout.Cost())
+	}
+	return nil
+}
+
+// Account returns the state of the account after the block, fetching it once per block
+func (c *Client) Account(ctx context.Context, blockHash common.Hash, address common.Address) (*AccountState, error) {
+	c.mu.Lock()
+	state, ok := c.cache[blockHash][address]
+	c.mu.Unlock()
+	if ok {
+		return state, nil
+	}
+
+	block := blockReference{BlockHash: blockHash}
+	var balance hexutil.Big
+	if err := c.call(ctx, "eth_getBalance", &balance, address, block); err != nil {
+		return nil, err
+	}
+	var nonce hexutil.Uint64
+	if err := c.call(ctx, "eth_getTransactionCount", &nonce, address, block); err != nil {
+		return nil, err
+	}
+	state = &AccountState{Balance: (*big.Int)(&balance), Nonce: uint64(nonce)}
+	c.log.WithFields(logrus.Fields{
+		"block":   blockHash.String(),
+		"address": address.String(),
+		"balance": state.Balance.String(),
+		"nonce":   state.Nonce,
+	}).Debug("Fetched Account State")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	accounts, ok := c.cache[blockHash]
+	if !ok {
+		accounts = make(map[common.Address]*AccountState)
+		c.cache[blockHash] = accounts
+		c.blocks = append(c.blocks, blockHash)
+		if len(c.blocks) > cachedBlocks {
+			delete(c.cache, c.blocks[0])
+			c.blocks = c.blocks[1:]
+		}
+	}
+	accounts[address] = state
+	return state, nil
+}
+
+func (c *Client) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      atomic.AddUint64(&c.nextID, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("execution rpc %s responded %d: %s", method, resp.StatusCode, string(respBody))
+	}
+
+	var response rpcResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	return json.Unmarshal(response.Result, result)
+}
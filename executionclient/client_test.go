@@ -0,0 +1,158 @@
+package executionclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/pon-pbs/bbRelay/executionclient/executiontest"
+)
+
+// payoutTransaction costs value and 21000 wei of gas
+func payoutTransaction(nonce uint64, value int64) *types.Transaction {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	return types.NewTx(&types.LegacyTx{Nonce: nonce, To: &to, Value: big.NewInt(value), Gas: 21000, GasPrice: big.NewInt(1)})
+}
+
+var (
+	testBlock   = common.HexToHash("0x01")
+	testBuilder = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+)
+
+func TestCheckPayout(t *testing.T) {
+	node := executiontest.NewNode(t)
+	node.SetAccount(testBlock, testBuilder, big.NewInt(22000), 5)
+	client := NewClient(Params{URL: node.URL, Timeout: time.Second})
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		payout  *types.Transaction
+		wantErr error
+	}{
+		{"funded", payoutTransaction(5, 1000), nil},
+		{"later nonce", payoutTransaction(7, 10), nil},
+		{"balance too low", payoutTransaction(5, 1001), ErrInsufficientFunds},
+		{"gas not covered", payoutTransaction(5, 22000), ErrInsufficientFunds},
+		{"nonce used", payoutTransaction(4, 10), ErrInsufficientFunds},
+	}
+	for _, test := range tests {
+		err := client.CheckPayout(ctx, testBlock, testBuilder, test.payout)
+		if !errors.Is(err, test.wantErr) {
+			t.Fatalf("%s: error %v, want %v", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestAccountCachedPerBlock(t *testing.T) {
+	node := executiontest.NewNode(t)
+	node.SetAccount(testBlock, testBuilder, big.NewInt(1000), 5)
+	client := NewClient(Params{URL: node.URL, Timeout: time.Second})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		state, err := client.Account(ctx, testBlock, testBuilder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Balance.Int64() != 1000 || state.Nonce != 5 {
+			t.Fatalf("account %+v, want balance 1000 nonce 5", state)
+		}
+	}
+	if node.Calls("eth_getBalance") != 1 || node.Calls("eth_getTransactionCount") != 1 {
+		t.Fatal("account of a block fetched more than once")
+	}
+
+	// the state of another block is fetched, even for the same account
+	otherBlock := common.HexToHash("0x02")
+	node.SetAccount(otherBlock, testBuilder, big.NewInt(10), 6)
+	state, err := client.Account(ctx, otherBlock, testBuilder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Balance.Int64() != 10 || state.Nonce != 6 {
+		t.Fatalf("account %+v of the other block, want balance 10 nonce 6", state)
+	}
+	if node.Calls("eth_getBalance") != 2 {
+		t.Fatalf("account of a new block served from the cache")
+	}
+
+	// only the latest cachedBlocks blocks are kept
+	for i := 3; i < 3+cachedBlocks; i++ {
+		block := common.BigToHash(big.NewInt(int64(i)))
+		node.SetAccount(block, testBuilder, big.NewInt(1), 0)
+		if _, err := client.Account(ctx, block, testBuilder); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.Account(ctx, testBlock, testBuilder); err != nil {
+		t.Fatal(err)
+	}
+	if calls := node.Calls("eth_getBalance"); calls != 3+cachedBlocks {
+		t.Fatalf("%d balance calls, want the evicted block fetched again", calls)
+	}
+}
+
+func TestCheckPayoutRPCError(t *testing.T) {
+	node := executiontest.NewNode(t)
+	node.SetError(-32000, "missing trie node")
+	client := NewClient(Params{URL: node.URL, Timeout: time.Second})
+
+	err := client.CheckPayout(context.Background(), testBlock, testBuilder, payoutTransaction(0, 1))
+	var rpcErr *rpcError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32000 {
+		t.Fatalf("error %v, want the rpc error", err)
+	}
+	if errors.Is(err, ErrInsufficientFunds) {
+		t.Fatal("rpc error reported as insufficient funds")
+	}
+
+	// failed lookups aren't cached
+	node.SetError(0, "")
+	node.SetAccount(testBlock, testBuilder, big.NewInt(21001), 0)
+	if err := client.CheckPayout(context.Background(), testBlock, testBuilder, payoutTransaction(0, 1)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckPayoutTransportError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(Params{URL: server.URL, Timeout: time.Second})
+	err := client.CheckPayout(context.Background(), testBlock, testBuilder, payoutTransaction(0, 1))
+	if err == nil || errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("error %v for an unavailable node", err)
+	}
+
+	server.Close()
+	err = client.CheckPayout(context.Background(), testBlock, testBuilder, payoutTransaction(0, 1))
+	if err == nil || errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("error %v for a closed node", err)
+	}
+
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the request ends when the client goes away once the body is read
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer hung.Close()
+	client = NewClient(Params{URL: hung.URL, Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	if err := client.CheckPayout(context.Background(), testBlock, testBuilder, payoutTransaction(0, 1)); err == nil {
+		t.Fatal("no error from a hung node")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("hung node answered after %s", elapsed)
+	}
+}
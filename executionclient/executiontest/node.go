@@ -0,0 +1,121 @@
+// Package executiontest provides a local JSON-RPC execution node for tests of
+// the execution client and the packages using it.
+package executiontest
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Node answers eth_getBalance and eth_getTransactionCount from the accounts
+// set for every block, and header not found for any other block
+type Node struct {
+	URL string
+
+	mu       sync.Mutex
+	accounts map[common.Hash]map[common.Address]account
+	calls    map[string]int
+
+	// rpcError is answered instead of the account state when set
+	rpcError *rpcError
+}
+
+type account struct {
+	balance *big.Int
+	nonce   uint64
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewNode starts a node that is closed with the test
+func NewNode(t *testing.T) *Node {
+	t.Helper()
+	node := &Node{
+		accounts: make(map[common.Hash]map[common.Address]account),
+		calls:    make(map[string]int),
+	}
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	node.URL = server.URL
+	return node
+}
+
+// SetAccount sets the balance and nonce of the address after the block
+func (node *Node) SetAccount(block common.Hash, address common.Address, balance *big.Int, nonce uint64) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	if node.accounts[block] == nil {
+		node.accounts[block] = make(map[common.Address]account)
+	}
+	node.accounts[block][address] = account{balance: balance, nonce: nonce}
+}
+
+// SetError answers every call with the JSON-RPC error, an empty message
+// answers calls normally again
+func (node *Node) SetError(code int, message string) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.rpcError = nil
+	if message != "" {
+		node.rpcError = &rpcError{Code: code, Message: message}
+	}
+}
+
+// Calls returns how often the method was called
+func (node *Node) Calls(method string) int {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.calls[method]
+}
+
+func (node *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ID     uint64            `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Params) != 2 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	var address common.Address
+	var block struct {
+		BlockHash common.Hash `json:"blockHash"`
+	}
+	if json.Unmarshal(request.Params[0], &address) != nil || json.Unmarshal(request.Params[1], &block) != nil {
+		http.Error(w, "bad params", http.StatusBadRequest)
+		return
+	}
+
+	node.mu.Lock()
+	node.calls[request.Method]++
+	state, found := node.accounts[block.BlockHash][address]
+	rpcErr := node.rpcError
+	node.mu.Unlock()
+
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+	switch {
+	case rpcErr != nil:
+		response["error"] = rpcErr
+	case !found:
+		response["error"] = rpcError{Code: -32000, Message: "header not found"}
+	case request.Method == "eth_getBalance":
+		response["result"] = (*hexutil.Big)(state.balance)
+	case request.Method == "eth_getTransactionCount":
+		response["result"] = hexutil.Uint64(state.nonce)
+	default:
+		response["error"] = rpcError{Code: -32601, Message: "method not found"}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
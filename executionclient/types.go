@@ -0,0 +1,74 @@
+package executionclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrInsufficientFunds = errors.New("builder wallet can't pay the payout")
+
+// cachedBlocks is how many parent blocks account states are kept for, bids
+// of a slot build on the head or a block just before it
+const cachedBlocks = 4
+
+// Client reads builder wallet state from an execution node over JSON-RPC,
+// caching it per block since state of a block never changes
+type Client struct {
+	url    string
+	client *http.Client
+	nextID uint64
+
+	mu     sync.Mutex
+	blocks []common.Hash
+	cache  map[common.Hash]map[common.Address]*AccountState
+
+	log *logrus.Entry
+}
+
+// AccountState is the balance and nonce of an account after a block
+type AccountState struct {
+	Balance *big.Int `json:"balance"`
+	Nonce   uint64   `json:"nonce"`
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (err *rpcError) Error() string {
+	return fmt.Sprintf("execution rpc error %d: %s", err.Code, err.Message)
+}
+
+// blockReference selects a block by hash as in EIP-1898, so reorgs can't
+// mix state of different blocks
+type blockReference struct {
+	BlockHash        common.Hash `json:"blockHash"`
+	RequireCanonical bool        `json:"requireCanonical"`
+}
+
+type Params struct {
+	URL     string
+	Timeout time.Duration
+}
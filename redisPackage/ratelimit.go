@@ -0,0 +1,49 @@
+package redisPackage
+
+import (
+	"context"
+	"time"
+
+	redis "github.com/go-redis/redis/v9"
+)
+
+// tokenBucket refills the bucket in KEYS[1] at ARGV[1] tokens per second up
+// to ARGV[2] tokens and takes one token if available. It returns whether a
+// token was taken and the milliseconds until the next token otherwise.
+var tokenBucket = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tokens, "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, wait}
+`)
+
+// TakeToken takes a token from the bucket stored under key, the bucket is
+// shared by every relay instance using the same Redis
+func (r *RedisInterface) TakeToken(ctx context.Context, key string, rate uint64, burst uint64) (allowed bool, retryAfter time.Duration, err error) {
+	result, err := tokenBucket.Run(ctx, r.Client, []string{key}, rate, burst, time.Now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
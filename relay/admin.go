@@ -0,0 +1,338 @@
+package relay
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/pon-pbs/bbRelay/database"
+	"github.com/pon-pbs/bbRelay/signing"
+	relayUtils "github.com/pon-pbs/bbRelay/utils"
+)
+
+func (relay *Relay) AdminRoutes() http.Handler {
+	r := mux.NewRouter()
+
+	r.HandleFunc("/admin/builders", relay.handleAdminBuilders).Methods(http.MethodGet)
+	r.HandleFunc("/admin/builders/{builder}/policy", relay.handleAdminSetBuilderPolicy).Methods(http.MethodPut)
+	r.HandleFunc("/admin/builders/{builder}/policy", relay.handleAdminRemoveBuilderPolicy).Methods(http.MethodDelete)
+	r.HandleFunc("/admin/builders/{builder}/policy/audit", relay.handleAdminBuilderPolicyAudit).Methods(http.MethodGet)
+
+	r.HandleFunc("/admin/builders/latency", relay.handleAdminBuilderLatency).Methods(http.MethodGet)
+	r.HandleFunc("/admin/beacon-nodes", relay.handleAdminBeaconNodes).Methods(http.MethodGet)
+
+	r.HandleFunc("/admin/ponpool/resync", relay.handleAdminResync).Methods(http.MethodPost)
+
+	r.HandleFunc("/admin/pauses", relay.handleAdminPauses).Methods(http.MethodGet)
+	r.HandleFunc("/admin/pauses", relay.handleAdminAddPause).Methods(http.MethodPost)
+	r.HandleFunc("/admin/pauses/{id:[0-9]+}", relay.handleAdminRemovePause).Methods(http.MethodDelete)
+
+	r.HandleFunc("/admin/auctions/{slot:[0-9]+}", relay.handleAdminAuction).Methods(http.MethodGet)
+
+	r.HandleFunc("/admin/log-level", relay.handleAdminLogLevels).Methods(http.MethodGet)
+	r.HandleFunc("/admin/log-level", relay.handleAdminSetLogLevel).Methods(http.MethodPut)
+
+	r.HandleFunc("/admin/keys", relay.handleAdminKeys).Methods(http.MethodGet)
+	r.HandleFunc("/admin/keys/cutover", relay.handleAdminScheduleCutover).Methods(http.MethodPost)
+	r.HandleFunc("/admin/keys/cutover", relay.handleAdminCancelCutover).Methods(http.MethodDelete)
+
+	return relay.adminAuthMiddleware(loggingMiddleware(r, *relay.log))
+}
+
+// adminAuthMiddleware only lets requests with the admin bearer token through
+func (relay *Relay) adminAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(relay.admin.Token)) != 1 {
+			relay.log.WithField("remote", r.RemoteAddr).Warn("Unauthorized Admin Request")
+			relay.RespondError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bidsPaused returns the pause blocking bid submissions for the slot, if any
+func (relay *Relay) bidsPaused(slot uint64) *SlotPause {
+	return relay.pauses.find(slot, func(pause SlotPause) bool { return pause.Bids })
+}
+
+// headersPaused returns the pause blocking getHeader for the slot, if any
+func (relay *Relay) headersPaused(slot uint64) *SlotPause {
+	return relay.pauses.find(slot, func(pause SlotPause) bool { return pause.Headers })
+}
+
+func (p *slotPauses) find(slot uint64, applies func(SlotPause) bool) *SlotPause {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, pause := range p.pauses {
+		if slot >= pause.FromSlot && slot <= pause.ToSlot && applies(pause) {
+			return &pause
+		}
+	}
+	return nil
+}
+
+func (relay *Relay) handleAdminBuilders(w http.ResponseWriter, req *http.Request) {
+	relay.RespondOK(w, AdminBuilders{
+		Builders:  relay.relayutils.Builders(),
+		Policies:  relay.relayutils.BuilderPolicies(),
+		Allowlist: relay.relayutils.BuilderAllowlist(),
+	})
+}
+
+func (relay *Relay) handleAdminSetBuilderPolicy(w http.ResponseWriter, req *http.Request) {
+	builder := mux.Vars(req)["builder"]
+
+	request := new(AdminBuilderPolicyRequest)
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	policy := database.BuilderPolicy{
+		BuilderPubkey: builder,
+		Policy:        request.Policy,
+		Reason:        request.Reason,
+		UpdatedBy:     adminActor(request.Actor, req),
+	}
+	if request.Duration != "" {
+		duration, err := time.ParseDuration(request.Duration)
+		if err != nil || duration <= 0 {
+			relay.RespondError(w, http.StatusBadRequest, fmt.Sprintf("invalid duration %s", request.Duration))
+			return
+		}
+		expiresAt := time.Now().Add(duration).UTC()
+		policy.ExpiresAt = &expiresAt
+	}
+
+	err := relay.relayutils.SetBuilderPolicy(req.Context(), policy)
+	if errors.Is(err, relayUtils.ErrInvalidBuilderPolicy) {
+		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		relay.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	relay.log.WithFields(logrus.Fields{
+		"builder": builder,
+		"policy":  policy.Policy,
+		"reason":  policy.Reason,
+		"expires": policy.ExpiresAt,
+		"actor":   policy.UpdatedBy,
+	}).Warn("Admin Set Builder Policy")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (relay *Relay) handleAdminRemoveBuilderPolicy(w http.ResponseWriter, req *http.Request) {
+	builder := mux.Vars(req)["builder"]
+	reason := req.URL.Query().Get("reason")
+	actor := adminActor(req.URL.Query().Get("actor"), req)
+
+	err := relay.relayutils.RemoveBuilderPolicy(req.Context(), builder, reason, actor)
+	if errors.Is(err, sql.ErrNoRows) {
+		relay.RespondError(w, http.StatusNotFound, fmt.Sprintf("no policy for builder %s", builder))
+		return
+	} else if err != nil {
+		relay.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	relay.log.WithFields(logrus.Fields{
+		"builder": builder,
+		"reason":  reason,
+		"actor":   actor,
+	}).Warn("Admin Removed Builder Policy")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (relay *Relay) handleAdminBuilderPolicyAudit(w http.ResponseWriter, req *http.Request) {
+	audit, err := relay.relayutils.BuilderPolicyAudit(req.Context(), mux.Vars(req)["builder"])
+	if err != nil {
+		relay.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	relay.RespondOK(w, audit)
+}
+
+// adminActor names who made a change for the audit log, falling back to the
+// remote address when the request doesn't say
+func adminActor(actor string, req *http.Request) string {
+	if actor != "" {
+		return actor
+	}
+	return req.RemoteAddr
+}
+
+func (relay *Relay) handleAdminBuilderLatency(w http.ResponseWriter, req *http.Request) {
+	relay.RespondOK(w, relay.builderClient.Stats())
+}
+
+func (relay *Relay) handleAdminBeaconNodes(w http.ResponseWriter, req *http.Request) {
+	relay.RespondOK(w, relay.beaconClient.NodeScores())
+}
+
+func (relay *Relay) handleAdminResync(w http.ResponseWriter, req *http.Request) {
+	relay.log.Warn("Admin Forced PON Pool Resync")
+	relay.relayutils.Resync()
+	relay.RespondOK(w, map[string]time.Time{"last_sync": relay.relayutils.LastPonPoolSync()})
+}
+
+func (relay *Relay) handleAdminPauses(w http.ResponseWriter, req *http.Request) {
+	relay.pauses.mu.RLock()
+	defer relay.pauses.mu.RUnlock()
+	relay.RespondOK(w, append([]SlotPause{}, relay.pauses.pauses...))
+}
+
+func (relay *Relay) handleAdminAddPause(w http.ResponseWriter, req *http.Request) {
+	pause := SlotPause{}
+	if err := json.NewDecoder(req.Body).Decode(&pause); err != nil {
+		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if pause.ToSlot < pause.FromSlot {
+		relay.RespondError(w, http.StatusBadRequest, "to_slot before from_slot")
+		return
+	}
+	if !pause.Bids && !pause.Headers {
+		relay.RespondError(w, http.StatusBadRequest, "pause needs bids or headers set")
+		return
+	}
+
+	relay.pauses.mu.Lock()
+	relay.pauses.nextID++
+	pause.ID = relay.pauses.nextID
+	relay.pauses.pauses = append(relay.pauses.pauses, pause)
+	relay.pauses.mu.Unlock()
+
+	relay.log.WithFields(logrus.Fields{
+		"id":        pause.ID,
+		"from_slot": pause.FromSlot,
+		"to_slot":   pause.ToSlot,
+		"bids":      pause.Bids,
+		"headers":   pause.Headers,
+		"reason":    pause.Reason,
+	}).Warn("Admin Paused Slots")
+	relay.RespondOK(w, pause)
+}
+
+func (relay *Relay) handleAdminRemovePause(w http.ResponseWriter, req *http.Request) {
+	id, _ := strconv.ParseUint(mux.Vars(req)["id"], 10, 64)
+
+	relay.pauses.mu.Lock()
+	defer relay.pauses.mu.Unlock()
+	for i, pause := range relay.pauses.pauses {
+		if pause.ID == id {
+			relay.pauses.pauses = append(relay.pauses.pauses[:i], relay.pauses.pauses[i+1:]...)
+			relay.log.WithField("id", id).Warn("Admin Removed Slot Pause")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+	relay.RespondError(w, http.StatusNotFound, fmt.Sprintf("no pause %d", id))
+}
+
+func (relay *Relay) handleAdminAuction(w http.ResponseWriter, req *http.Request) {
+	slot, _ := strconv.ParseUint(mux.Vars(req)["slot"], 10, 64)
+	state, err := relay.bidBoard.AuctionState(slot)
+	if err != nil {
+		relay.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	relay.RespondOK(w, state)
+}
+
+func (relay *Relay) handleAdminLogLevels(w http.ResponseWriter, req *http.Request) {
+	levels := make(map[string]string, len(relay.loggers))
+	for name, loggers := range relay.loggers {
+		levels[name] = loggers[0].GetLevel().String()
+	}
+	relay.RespondOK(w, levels)
+}
+
+func (relay *Relay) handleAdminSetLogLevel(w http.ResponseWriter, req *http.Request) {
+	request := new(AdminLogLevelRequest)
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	level, err := logrus.ParseLevel(request.Level)
+	if err != nil {
+		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, ok := relay.loggers[request.Logger]; request.Logger != "" && !ok {
+		relay.RespondError(w, http.StatusNotFound, fmt.Sprintf("unknown logger %s", request.Logger))
+		return
+	}
+
+	for name, loggers := range relay.loggers {
+		if request.Logger != "" && request.Logger != name {
+			continue
+		}
+		for _, logger := range loggers {
+			logger.SetLevel(level)
+		}
+	}
+	relay.log.WithFields(logrus.Fields{
+		"logger": request.Logger,
+		"level":  level.String(),
+	}).Warn("Admin Changed Log Level")
+	relay.handleAdminLogLevels(w, req)
+}
+
+func (relay *Relay) handleAdminKeys(w http.ResponseWriter, req *http.Request) {
+	relay.handleRelayConfig(w, req)
+}
+
+func (relay *Relay) handleAdminScheduleCutover(w http.ResponseWriter, req *http.Request) {
+	request := new(AdminCutoverRequest)
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	publicKeyBytes, err := hexutil.Decode(request.PublicKey)
+	var publicKey phase0.BLSPubKey
+	if err != nil || len(publicKeyBytes) != len(publicKey) {
+		relay.RespondError(w, http.StatusBadRequest, fmt.Sprintf("invalid public key %s", request.PublicKey))
+		return
+	}
+	copy(publicKey[:], publicKeyBytes)
+
+	err = relay.keys.ScheduleCutover(publicKey, request.Slot, relay.currentSlot())
+	if errors.Is(err, signing.ErrUnknownKey) || errors.Is(err, signing.ErrCutoverSlotPast) {
+		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		relay.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	relay.log.WithFields(logrus.Fields{
+		"publicKey": request.PublicKey,
+		"slot":      request.Slot,
+	}).Warn("Admin Scheduled Key Cutover")
+	relay.handleRelayConfig(w, req)
+}
+
+func (relay *Relay) handleAdminCancelCutover(w http.ResponseWriter, req *http.Request) {
+	err := relay.keys.CancelCutover(relay.currentSlot())
+	if errors.Is(err, signing.ErrNoCutoverPending) {
+		relay.RespondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	relay.log.Warn("Admin Cancelled Key Cutover")
+	relay.handleRelayConfig(w, req)
+}
@@ -0,0 +1,152 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/mux"
+
+	"github.com/pon-pbs/bbRelay/database"
+	"github.com/pon-pbs/bbRelay/utils"
+)
+
+const (
+	maxBuilderEndpoints       = 4
+	endpointRegistrationDrift = 5 * time.Minute
+)
+
+// SigningText is the text the builder wallet signs to register its endpoints
+func (message *BuilderEndpointsMessage) SigningText() string {
+	return fmt.Sprintf("PON Relay Builder Endpoints\nBuilder: %s\nEndpoints: %s\nTimestamp: %d",
+		strings.ToLower(message.BuilderWalletAddress.String()),
+		strings.Join(message.Endpoints, ","),
+		message.Timestamp,
+	)
+}
+
+// signer recovers the address that personal_signed the registration
+func (request *BuilderEndpointsRequest) signer() (string, error) {
+	if len(request.Signature) != crypto.SignatureLength {
+		return "", fmt.Errorf("signature must be %d bytes", crypto.SignatureLength)
+	}
+	signature := make([]byte, crypto.SignatureLength)
+	copy(signature, request.Signature)
+	if signature[crypto.RecoveryIDOffset] >= 27 {
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pubkey, err := crypto.SigToPub(accounts.TextHash([]byte(request.Message.SigningText())), signature)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(crypto.PubkeyToAddress(*pubkey).String()), nil
+}
+
+func validateBuilderEndpoints(endpoints []string) error {
+	if len(endpoints) == 0 || len(endpoints) > maxBuilderEndpoints {
+		return fmt.Errorf("between 1 and %d endpoints required", maxBuilderEndpoints)
+	}
+	for _, endpoint := range endpoints {
+		parsed, err := url.Parse(endpoint)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("endpoint %q must be an absolute http(s) URL", endpoint)
+		}
+		if strings.Contains(endpoint, ",") {
+			return fmt.Errorf("endpoint %q must not contain commas", endpoint)
+		}
+	}
+	return nil
+}
+
+func (relay *Relay) handleRegisterBuilderEndpoints(w http.ResponseWriter, req *http.Request) {
+	var request BuilderEndpointsRequest
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		relay.log.WithError(err).Warn("Couldn't Decode Builder Endpoints")
+		relay.decodeError(w, err)
+		return
+	}
+	builder := strings.ToLower(request.Message.BuilderWalletAddress.String())
+
+	registered := time.Unix(int64(request.Message.Timestamp), 0)
+	if drift := time.Since(registered); drift > endpointRegistrationDrift || drift < -endpointRegistrationDrift {
+		relay.RespondError(w, http.StatusBadRequest, "Registration Timestamp Too Far From Now")
+		return
+	}
+
+	if err := validateBuilderEndpoints(request.Message.Endpoints); err != nil {
+		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	signer, err := request.signer()
+	if err != nil {
+		relay.log.WithError(err).Warn("Couldn't Recover Builder Endpoints Signer")
+		relay.RespondError(w, http.StatusBadRequest, "Invalid Signature")
+		return
+	}
+	if signer != builder {
+		relay.log.Warnf("Endpoints Signed By %s, Builder- %s", signer, builder)
+		relay.RespondError(w, http.StatusBadRequest, "Signature Does Not Match Builder Wallet Address")
+		return
+	}
+
+	if relay.builderRateLimited(w, req, builder) {
+		return
+	}
+
+	status, reason, err := relay.relayutils.BuilderStatus(request.Message.BuilderWalletAddress.String())
+	if err != nil {
+		relay.log.WithError(err).Warn("Couldn' Get Builder Status")
+		relay.RespondError(w, http.StatusBadRequest, "Failed To Get Builder")
+		return
+	}
+	if !status {
+		relay.RespondError(w, http.StatusBadRequest, reason)
+		return
+	}
+
+	// Probing runs with the registration, don't let the builder's
+	// connection going away cut it short
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(maxBuilderEndpoints+1)*relay.builderClient.Timeout())
+	defer cancel()
+
+	err = relay.relayutils.RegisterBuilderEndpoints(ctx, database.BuilderEndpoints{
+		BuilderWalletAddress: builder,
+		Endpoints:            request.Message.Endpoints,
+		Timestamp:            request.Message.Timestamp,
+		Signature:            request.Signature.String(),
+	})
+	if errors.Is(err, utils.ErrStaleEndpointRegistration) {
+		relay.RespondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		relay.log.WithError(err).Error("Couldn't Store Builder Endpoints")
+		relay.RespondError(w, http.StatusInternalServerError, "Couldn't Store Builder Endpoints")
+		return
+	}
+
+	relay.log.WithField("builder", builder).Infof("Builder Registered Endpoints %s", strings.Join(request.Message.Endpoints, ","))
+	relay.respondBuilderEndpoints(w, builder)
+}
+
+func (relay *Relay) handleBuilderEndpoints(w http.ResponseWriter, req *http.Request) {
+	relay.respondBuilderEndpoints(w, mux.Vars(req)["builder"])
+}
+
+func (relay *Relay) respondBuilderEndpoints(w http.ResponseWriter, builder string) {
+	registration, health := relay.relayutils.BuilderEndpoints(builder)
+	if registration == nil {
+		relay.RespondError(w, http.StatusNotFound, "Builder Has No Registered Endpoints")
+		return
+	}
+	relay.RespondOK(w, BuilderEndpointsResponse{Registration: registration, Health: health})
+}
@@ -0,0 +1,167 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	healthCheckTimeout = 500 * time.Millisecond
+)
+
+// HealthReport collects the state of every dependency of the relay. The relay
+// is ready when all critical dependencies are healthy, non critical ones only
+// degrade the reported status.
+func (relay *Relay) HealthReport(ctx context.Context) HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	report := HealthReport{
+		Ready:  true,
+		Status: HealthStatusOK,
+		Checks: make(map[string]DependencyStatus),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	check := func(name string, critical bool, run func(ctx context.Context) (any, error)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			detail, err := run(ctx)
+			status := DependencyStatus{
+				Healthy:  err == nil,
+				Critical: critical,
+				Detail:   detail,
+			}
+			if err != nil {
+				status.Error = err.Error()
+			}
+			mu.Lock()
+			report.Checks[name] = status
+			mu.Unlock()
+		}()
+	}
+
+	check("redis", true, relay.checkRedis)
+	check("database", true, relay.checkDatabase)
+	check("beacon", true, relay.checkBeaconNodes)
+	check("head", true, relay.checkHeadSlot)
+	check("bulletin_board", false, relay.checkBulletinBoard)
+	check("pon_pool", false, relay.checkPonPool)
+	wg.Wait()
+
+	for _, status := range report.Checks {
+		if status.Healthy {
+			continue
+		}
+		if status.Critical {
+			report.Ready = false
+			report.Status = HealthStatusUnavailable
+		} else if report.Ready {
+			report.Status = HealthStatusDegraded
+		}
+	}
+
+	if relay.shuttingDown.Load() {
+		report.Ready = false
+		report.Status = HealthStatusShuttingDown
+	}
+
+	return report
+}
+
+func (relay *Relay) checkRedis(ctx context.Context) (any, error) {
+	return nil, relay.redis.Client.Ping(ctx).Err()
+}
+
+func (relay *Relay) checkDatabase(ctx context.Context) (any, error) {
+	return nil, relay.db.DB.PingContext(ctx)
+}
+
+func (relay *Relay) checkBeaconNodes(ctx context.Context) (any, error) {
+	synced, unsynced := relay.beaconClient.SyncedClients()
+	detail := map[string][]string{
+		"synced":   synced,
+		"unsynced": unsynced,
+	}
+	if len(synced) == 0 {
+		return detail, fmt.Errorf("none of %d beacon nodes is synced", len(unsynced))
+	}
+	return detail, nil
+}
+
+func (relay *Relay) checkHeadSlot(ctx context.Context) (any, error) {
+	relay.beaconClient.BeaconData.Mu.Lock()
+	headSlot := relay.beaconClient.BeaconData.CurrentSlot
+	relay.beaconClient.BeaconData.Mu.Unlock()
+
+	wallSlot := relay.WallClockSlot()
+	var lag uint64
+	if wallSlot > headSlot {
+		lag = wallSlot - headSlot
+	}
+
+	detail := map[string]uint64{
+		"head_slot":       headSlot,
+		"wall_clock_slot": wallSlot,
+		"lag":             lag,
+	}
+	if lag > relay.health.MaxHeadSlotLag {
+		return detail, fmt.Errorf("head is %d slots behind, allowed %d", lag, relay.health.MaxHeadSlotLag)
+	}
+	return detail, nil
+}
+
+func (relay *Relay) checkBulletinBoard(ctx context.Context) (any, error) {
+	return nil, relay.bulletinBoard.Healthy()
+}
+
+func (relay *Relay) checkPonPool(ctx context.Context) (any, error) {
+	lastSync := relay.relayutils.LastPonPoolSync()
+	if lastSync.IsZero() {
+		return nil, fmt.Errorf("pon pool never synced")
+	}
+
+	age := time.Since(lastSync)
+	detail := map[string]string{
+		"last_sync": lastSync.UTC().Format(time.RFC3339),
+		"age":       age.Round(time.Second).String(),
+	}
+	if age > relay.health.MaxPonPoolSyncAge {
+		return detail, fmt.Errorf("last pon pool sync %s ago, allowed %s", age.Round(time.Second), relay.health.MaxPonPoolSyncAge)
+	}
+	return detail, nil
+}
+
+// WallClockSlot is the slot the chain should be at according to genesis time
+func (relay *Relay) WallClockSlot() uint64 {
+	now := uint64(time.Now().Unix())
+	if now < relay.network.GenesisTime {
+		return 0
+	}
+	return (now - relay.network.GenesisTime) / 12
+}
+
+func (relay *Relay) handleHealthz(w http.ResponseWriter, req *http.Request) {
+	relay.RespondOK(w, relay.HealthReport(req.Context()))
+}
+
+func (relay *Relay) handleReadyz(w http.ResponseWriter, req *http.Request) {
+	report := relay.HealthReport(req.Context())
+	if !report.Ready {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			relay.log.WithError(err).Error("Couldn't write readiness response")
+		}
+		return
+	}
+	relay.RespondOK(w, report)
+}
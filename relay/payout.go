@@ -0,0 +1,88 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	builderTypes "github.com/bsn-eng/pon-golang-types/builder"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/pon-pbs/bbRelay/executionclient"
+)
+
+var ErrPayout = errors.New("invalid payout transaction")
+
+func (params PayoutParams) Enabled() bool {
+	return params.PoolAddress != (common.Address{})
+}
+
+// verifyPayoutTransaction checks the bid pays its value from the builder
+// wallet to the PON payout pool on the relay's chain
+func (relay *Relay) verifyPayoutTransaction(bid *builderTypes.BidPayload) error {
+	if !relay.payout.Enabled() {
+		return nil
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(bid.PayoutPoolTransaction); err != nil {
+		return fmt.Errorf("%w: couldn't decode, %s", ErrPayout, err.Error())
+	}
+
+	chainID := new(big.Int).SetUint64(relay.payout.ChainID)
+	if tx.ChainId().Cmp(chainID) != 0 {
+		return fmt.Errorf("%w: chain id %d, expected %d", ErrPayout, tx.ChainId(), chainID)
+	}
+	if tx.To() == nil || *tx.To() != relay.payout.PoolAddress {
+		return fmt.Errorf("%w: not sent to payout pool %s", ErrPayout, relay.payout.PoolAddress.String())
+	}
+	if bid.Value == nil || tx.Value().Cmp(bid.Value) != 0 {
+		return fmt.Errorf("%w: value %d, bid value %d", ErrPayout, tx.Value(), bid.Value)
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return fmt.Errorf("%w: couldn't recover sender, %s", ErrPayout, err.Error())
+	}
+	if !strings.EqualFold(sender.String(), bid.BuilderWalletAddress.String()) {
+		return fmt.Errorf("%w: sent by %s, not the builder wallet", ErrPayout, sender.String())
+	}
+	return nil
+}
+
+// checkPayoutFunds checks the builder wallet can pay the payout on top of the
+// parent block. Execution node errors don't reject bids, the check is skipped
+func (relay *Relay) checkPayoutFunds(ctx context.Context, bid *builderTypes.BidPayload, parentHash common.Hash) error {
+	if relay.execution == nil {
+		return nil
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(bid.PayoutPoolTransaction); err != nil {
+		return fmt.Errorf("%w: couldn't decode, %s", ErrPayout, err.Error())
+	}
+
+	err := relay.execution.CheckPayout(ctx, parentHash, common.Address(bid.BuilderWalletAddress), tx)
+	if err != nil && !errors.Is(err, executionclient.ErrInsufficientFunds) {
+		relay.log.WithError(err).Warn("Couldn't Check Builder Wallet, Accepting Bid")
+		return nil
+	}
+	return err
+}
+
+// verifyPayoutInPayload checks the payout transaction of the bid is the last
+// transaction of the payload the builder delivered
+func verifyPayoutInPayload(payoutTransaction []byte, transactions []bellatrix.Transaction) error {
+	if len(transactions) == 0 {
+		return fmt.Errorf("%w: payload has no transactions", ErrPayout)
+	}
+	if !bytes.Equal(transactions[len(transactions)-1], payoutTransaction) {
+		return fmt.Errorf("%w: not the last transaction of the payload", ErrPayout)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package relay
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	builderTypes "github.com/bsn-eng/pon-golang-types/builder"
+	commonTypes "github.com/bsn-eng/pon-golang-types/common"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sirupsen/logrus"
+
+	"github.com/pon-pbs/bbRelay/executionclient"
+	"github.com/pon-pbs/bbRelay/executionclient/executiontest"
+)
+
+func testPayoutBid(t *testing.T, nonce uint64, value int64) *builderTypes.BidPayload {
+	t.Helper()
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	payout, err := types.NewTx(&types.LegacyTx{Nonce: nonce, To: &to, Value: big.NewInt(value), Gas: 21000, GasPrice: big.NewInt(1)}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &builderTypes.BidPayload{Value: big.NewInt(value), BuilderWalletAddress: commonTypes.Address{19: 0xbb}, PayoutPoolTransaction: payout}
+}
+
+func testLog() *logrus.Entry {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return logrus.NewEntry(log)
+}
+
+func testPayoutRelay(url string) *Relay {
+	return &Relay{
+		execution: executionclient.NewClient(executionclient.Params{URL: url, Timeout: time.Second}),
+		log:       testLog(),
+	}
+}
+
+func TestCheckPayoutFunds(t *testing.T) {
+	node := executiontest.NewNode(t)
+	parent, builder := common.Hash{1}, common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	node.SetAccount(parent, builder, big.NewInt(21100), 2)
+	relay := testPayoutRelay(node.URL)
+	ctx := context.Background()
+
+	// the wallet pays the gas of the payout on top of its value
+	if err := relay.checkPayoutFunds(ctx, testPayoutBid(t, 2, 100), parent); err != nil {
+		t.Fatalf("funded payout rejected: %v", err)
+	}
+	if err := relay.checkPayoutFunds(ctx, testPayoutBid(t, 2, 21100), parent); !errors.Is(err, executionclient.ErrInsufficientFunds) {
+		t.Fatalf("error %v for a payout of the whole balance, want %v", err, executionclient.ErrInsufficientFunds)
+	}
+	if err := relay.checkPayoutFunds(ctx, testPayoutBid(t, 1, 100), parent); !errors.Is(err, executionclient.ErrInsufficientFunds) {
+		t.Fatalf("error %v for a used nonce, want %v", err, executionclient.ErrInsufficientFunds)
+	}
+
+	bid := testPayoutBid(t, 2, 100)
+	bid.PayoutPoolTransaction = []byte{0x01}
+	if err := relay.checkPayoutFunds(ctx, bid, parent); !errors.Is(err, ErrPayout) {
+		t.Fatalf("error %v for an undecodable payout, want %v", err, ErrPayout)
+	}
+
+	// bids are accepted when the node fails
+	node.SetError(-32000, "missing trie node")
+	if err := relay.checkPayoutFunds(ctx, testPayoutBid(t, 0, 1), common.Hash{2}); err != nil {
+		t.Fatalf("bid rejected when the node fails: %v", err)
+	}
+
+	// the check is skipped without an execution node
+	relay = &Relay{log: testLog()}
+	if err := relay.checkPayoutFunds(ctx, testPayoutBid(t, 0, 1), parent); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyPayoutTransaction(t *testing.T) {
+	pool := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	other := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	builderKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := crypto.PubkeyToAddress(builderKey.PublicKey)
+
+	// signedPayout is a payout of value to recipient, signed for the chain
+	signedPayout := func(chainID uint64, recipient *common.Address, value int64, key *ecdsa.PrivateKey) []byte {
+		t.Helper()
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:   new(big.Int).SetUint64(chainID),
+			To:        recipient,
+			Value:     big.NewInt(value),
+			Gas:       21000,
+			GasFeeCap: big.NewInt(1),
+			GasTipCap: big.NewInt(1),
+		})
+		signed, err := types.SignTx(tx, types.LatestSignerForChainID(tx.ChainId()), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payout, err := signed.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return payout
+	}
+
+	tests := []struct {
+		name   string
+		payout []byte
+		value  *big.Int
+	}{
+		{"recipient", signedPayout(5, &other, 100, builderKey), big.NewInt(100)},
+		{"contract creation", signedPayout(5, nil, 100, builderKey), big.NewInt(100)},
+		{"value", signedPayout(5, &pool, 99, builderKey), big.NewInt(100)},
+		{"no bid value", signedPayout(5, &pool, 100, builderKey), nil},
+		{"sender", signedPayout(5, &pool, 100, otherKey), big.NewInt(100)},
+		{"chain id", signedPayout(1, &pool, 100, builderKey), big.NewInt(100)},
+		{"undecodable", []byte{0x02, 0xf8, 0x70}, big.NewInt(100)},
+		{"empty", nil, big.NewInt(100)},
+	}
+
+	relay := &Relay{payout: PayoutParams{PoolAddress: pool, ChainID: 5}}
+	bid := func(payout []byte, value *big.Int) *builderTypes.BidPayload {
+		return &builderTypes.BidPayload{Value: value, BuilderWalletAddress: commonTypes.Address(builder), PayoutPoolTransaction: payout}
+	}
+	if err := relay.verifyPayoutTransaction(bid(signedPayout(5, &pool, 100, builderKey), big.NewInt(100))); err != nil {
+		t.Fatalf("valid payout rejected: %v", err)
+	}
+	for _, test := range tests {
+		if err := relay.verifyPayoutTransaction(bid(test.payout, test.value)); !errors.Is(err, ErrPayout) {
+			t.Fatalf("%s: error %v, want %v", test.name, err, ErrPayout)
+		}
+	}
+
+	// nothing is checked without a payout pool
+	relay = &Relay{}
+	if err := relay.verifyPayoutTransaction(bid([]byte{0x01}, nil)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,110 @@
+package relay
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	keyRateLimitIP      = "rate-limit-ip"
+	keyRateLimitBuilder = "rate-limit-builder"
+)
+
+// limitSubmissions sheds load once too many submissions are in flight, rate
+// limits by client IP and caps the body size before the handler decodes it
+func (relay *Relay) limitSubmissions(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if relay.inflight != nil {
+			select {
+			case relay.inflight <- struct{}{}:
+				defer func() { <-relay.inflight }()
+			default:
+				relay.log.Warn("Too Many Submissions In Flight, Shedding Load")
+				w.Header().Set("Retry-After", "1")
+				relay.RespondError(w, http.StatusServiceUnavailable, "Relay Overloaded, Retry Later")
+				return
+			}
+		}
+
+		ip := relay.clientIP(req)
+		if relay.rateLimited(w, req, keyRateLimitIP, ip, relay.limits.IPRate, relay.limits.IPBurst) {
+			relay.log.WithField("ip", ip).Warn("IP Rate Limited")
+			return
+		}
+
+		if relay.limits.MaxBodySize > 0 {
+			req.Body = http.MaxBytesReader(w, req.Body, relay.limits.MaxBodySize)
+		}
+		next(w, req)
+	}
+}
+
+// builderRateLimited responds 429 and returns true once the builder used up
+// its submissions. Only call it once the signature proved the request comes
+// from the builder, anyone could drain the bucket of a builder otherwise.
+func (relay *Relay) builderRateLimited(w http.ResponseWriter, req *http.Request, builder string) bool {
+	if relay.rateLimited(w, req, keyRateLimitBuilder, strings.ToLower(builder), relay.limits.BuilderRate, relay.limits.BuilderBurst) {
+		relay.log.WithField("builder", builder).Warn("Builder Rate Limited")
+		return true
+	}
+	return false
+}
+
+// rateLimited takes a token from the Redis bucket of id. A zero rate disables
+// the limit and Redis errors let the request through so a Redis hiccup
+// doesn't stop bidding.
+func (relay *Relay) rateLimited(w http.ResponseWriter, req *http.Request, key string, id string, rate uint64, burst uint64) bool {
+	if rate == 0 {
+		return false
+	}
+	if burst == 0 {
+		burst = rate
+	}
+
+	allowed, retryAfter, err := relay.redis.TakeToken(req.Context(), fmt.Sprintf("%s-%s", key, id), rate, burst)
+	if err != nil {
+		relay.log.WithError(err).WithFields(logrus.Fields{
+			"key": key,
+			"id":  id,
+		}).Warn("Rate Limit Check Failed, Allowing Request")
+		return false
+	}
+	if allowed {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	relay.RespondError(w, http.StatusTooManyRequests, fmt.Sprintf("Rate Limited, Retry In %s", retryAfter.Round(time.Millisecond)))
+	return true
+}
+
+// decodeError responds to a submission body that couldn't be decoded
+func (relay *Relay) decodeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		relay.RespondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Submission Larger Than %d Bytes", maxBytesErr.Limit))
+		return
+	}
+	relay.RespondError(w, http.StatusBadRequest, err.Error())
+}
+
+func (relay *Relay) clientIP(req *http.Request) string {
+	if relay.limits.TrustForwardedFor {
+		if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
@@ -7,16 +7,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"math/big"
 	"net/http"
 	"strings"
 	"time"
 
-	builderTypes "github.com/bsn-eng/pon-golang-types/builder"
-	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	commonTypes "github.com/bsn-eng/pon-golang-types/common"
 	databaseTypes "github.com/bsn-eng/pon-golang-types/database"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/go-redis/redis/v9"
@@ -25,9 +24,10 @@ import (
 
 	beaconclient "github.com/pon-pbs/bbRelay/beaconinterface"
 	"github.com/pon-pbs/bbRelay/bids"
-	"github.com/pon-pbs/bbRelay/bls"
+	"github.com/pon-pbs/bbRelay/builderclient"
 	"github.com/pon-pbs/bbRelay/bulletinboard"
 	"github.com/pon-pbs/bbRelay/database"
+	"github.com/pon-pbs/bbRelay/executionclient"
 	ponpool "github.com/pon-pbs/bbRelay/ponPool"
 	"github.com/pon-pbs/bbRelay/redisPackage"
 	reporterServer "github.com/pon-pbs/bbRelay/reporter"
@@ -36,7 +36,7 @@ import (
 	relayUtils "github.com/pon-pbs/bbRelay/utils"
 )
 
-func NewRelayAPI(params *RelayParams, log logrus.Entry) (relay *Relay, err error) {
+func NewRelayAPI(ctx context.Context, params *RelayParams, log logrus.Entry) (relay *Relay, err error) {
 	dataBase, err := database.NewDatabase(params.DbURL, params.DatabaseParams, params.DbDriver, params.DeleteTables)
 	if err != nil {
 		log.WithError(err).Fatal("Failed Database")
@@ -45,21 +45,30 @@ func NewRelayAPI(params *RelayParams, log logrus.Entry) (relay *Relay, err error
 
 	ponPool := ponpool.NewPonPool(params.PonPoolURL, params.PonPoolAPIKey)
 
-	beaconClient, err := beaconclient.NewMultiBeaconClient(params.BeaconClientUrls)
+	beaconClient, err := beaconclient.NewMultiBeaconClient(params.BeaconClientUrls, params.BeaconNode)
 	if err != nil {
 		log.WithError(err).Fatal("Failed Beacon Client")
 		return nil, err
 	}
-	beaconClient.Start()
+	beaconClient.Start(ctx)
 
-	bulletinBoard, err := bulletinboard.NewMQTTClient(params.BulletinBoardParams, beaconClient)
+	// The bulletin board is not tied to the root context, handlers still publish
+	// while the server drains, it is stopped in Shutdown once they are done
+	bulletinBoardCtx, stopBulletinBoard := context.WithCancel(context.Background())
+	bulletinBoard, err := bulletinboard.NewBulletinBoard(bulletinBoardCtx, params.BulletinBoardParams, beaconClient, params.Keys, params.BulletinBoard)
 	if err != nil {
+		stopBulletinBoard()
 		log.WithError(err).Fatal("Failed Bulletin Board")
 		return nil, err
 	}
 
 	reporter := reporterServer.NewReporterServer(params.ReporterURL, dataBase)
-	go reporter.StartServer()
+	go func() {
+		err := reporter.StartServer()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.WithError(err).Error("Reporter Server Failed")
+		}
+	}()
 
 	redisInterface, err := redisPackage.NewRedisInterface(params.RedisURI)
 	if err != nil {
@@ -67,18 +76,37 @@ func NewRelayAPI(params *RelayParams, log logrus.Entry) (relay *Relay, err error
 		return nil, err
 	}
 
-	relayutils := relayUtils.NewRelayUtils(dataBase, beaconClient, *ponPool, *redisInterface, params.DiscordWebhook)
-	go relayutils.StartUtils()
+	builderClient, err := builderclient.NewClient(params.BuilderClient)
+	if err != nil {
+		log.WithError(err).Fatal("Failed Builder Client")
+		return nil, err
+	}
 
-	bidInterface := bids.NewBidBoard(*redisInterface, *bulletinBoard, params.BidTimeOut)
+	var execution *executionclient.Client
+	if params.Execution.URL != "" {
+		execution = executionclient.NewClient(params.Execution)
+	}
 
-	publickey, err := bls.RelayBLSPubKey(*bls.PublicKeyFromSecretKey(params.Sk))
-	if err != nil {
-		log.WithError(err).Fatal("Failed Relay Public Key")
+	if !params.Payout.Enabled() {
+		log.Warn("No Payout Pool Address Set, Payout Transactions Are Not Verified")
+	}
+
+	relayutils := relayUtils.NewRelayUtils(dataBase, beaconClient, *ponPool, *redisInterface, params.DiscordWebhook, params.BuilderAllowlist, builderClient, params.RequireBuilderEndpoints)
+	if err := relayutils.LoadBuilderPolicies(ctx); err != nil {
+		log.WithError(err).Fatal("Failed Loading Builder Policies")
+		return nil, err
+	}
+	if err := relayutils.LoadBuilderEndpoints(ctx); err != nil {
+		log.WithError(err).Fatal("Failed Loading Builder Endpoints")
 		return nil, err
 	}
+	go relayutils.StartUtils(ctx)
 
-	networkInterface, err := NewEthNetworkDetails(params.Network, beaconClient)
+	bidInterface := bids.NewBidBoard(*redisInterface, bulletinBoard, params.BidTimeOut)
+
+	publickey := params.Keys.SignerForSlot(0).PublicKey()
+
+	networkInterface, err := NewEthNetworkDetails(ctx, params.Network, beaconClient)
 	if err != nil {
 		log.WithError(err).Fatal("Error Network")
 	}
@@ -90,17 +118,43 @@ func NewRelayAPI(params *RelayParams, log logrus.Entry) (relay *Relay, err error
 		ponPool:        ponPool,
 		bulletinBoard:  bulletinBoard,
 		beaconClient:   beaconClient,
+		redis:          redisInterface,
+		health:         params.Health,
+		admin:          params.Admin,
+		limits:         params.Limits,
+		builderClient:  builderClient,
+		payloadTimeout: params.PayloadTimeout,
+		payout:         params.Payout,
+		execution:      execution,
 		reporterServer: reporter,
 		bidBoard:       bidInterface,
 		relayutils:     relayutils,
 		URL:            params.URL,
 		network:        *networkInterface,
 
-		client:    &http.Client{Timeout: time.Second},
-		blsSk:     params.Sk,
-		publicKey: publickey,
-		log:       &log,
-		version:   params.Version,
+		client:  &http.Client{Timeout: time.Second},
+		keys:    params.Keys,
+		log:     &log,
+		version: params.Version,
+
+		stopBulletinBoard: stopBulletinBoard,
+	}
+
+	if params.Limits.MaxInflight > 0 {
+		relayAPI.inflight = make(chan struct{}, params.Limits.MaxInflight)
+	}
+
+	beaconClient.OnReorg(relayAPI.reorged)
+
+	relayAPI.loggers = map[string][]*logrus.Logger{
+		"relay":         {log.Logger},
+		"bids":          {bidInterface.Logger()},
+		"bulletinboard": {bulletinBoard.Log.Logger},
+		"utils":         relayutils.Loggers(),
+		"builderclient": {builderClient.Logger()},
+	}
+	if execution != nil {
+		relayAPI.loggers["executionclient"] = []*logrus.Logger{execution.Logger()}
 	}
 
 	return relayAPI, nil
@@ -112,10 +166,17 @@ func (relay *Relay) Routes() http.Handler {
 	r.HandleFunc("/relay", relay.handleLanding).Methods(http.MethodGet)
 	r.HandleFunc("/relay/config", relay.handleRelayConfig).Methods(http.MethodGet)
 	r.HandleFunc("/eth/v1/builder/status", relay.handleStatus).Methods(http.MethodGet)
+	r.HandleFunc("/healthz", relay.handleHealthz).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", relay.handleReadyz).Methods(http.MethodGet)
 	r.HandleFunc("/eth/v1/builder/validators", relay.handleRegisterValidator).Methods(http.MethodPost)
 
-	r.HandleFunc("/relay/v1/builder/blocks", relay.handleSubmitBlock).Methods(http.MethodPost)
-	// r.HandleFunc("/relay/v1/builder/bounty_bids", relay.handleBountyBids).Methods(http.MethodPost)
+	r.HandleFunc("/relay/v1/builder/blocks", relay.limitSubmissions(relay.handleSubmitBlock)).Methods(http.MethodPost)
+	r.HandleFunc("/relay/v1/builder/endpoints", relay.limitSubmissions(relay.handleRegisterBuilderEndpoints)).Methods(http.MethodPost)
+	r.HandleFunc("/relay/v1/builder/endpoints/{builder:0x[a-fA-F0-9]+}", relay.handleBuilderEndpoints).Methods(http.MethodGet)
+	if fanOut := relay.bulletinBoard.FanOut(); fanOut != nil {
+		r.Handle(bulletinBoardStreamPath, fanOut).Methods(http.MethodGet)
+	}
+	// r.HandleFunc("/relay/v1/builder/bounty_bids", relay.limitSubmissions(relay.handleBountyBids)).Methods(http.MethodPost)
 
 	r.HandleFunc("/eth/v1/builder/header/{slot:[0-9]+}/{parent_hash:0x[a-fA-F0-9]+}/{pubkey:0x[a-fA-F0-9]+}", relay.handleProposerHeader).Methods(http.MethodGet)
 	r.HandleFunc("/eth/v1/builder/blinded_blocks", relay.handleProposerPayload).Methods(http.MethodPost)
@@ -125,7 +186,7 @@ func (relay *Relay) Routes() http.Handler {
 	return loggingMiddleware(r, *relay.log)
 }
 
-func (relay *Relay) StartServer(ServerParams *RelayServerParams) (err error) {
+func (relay *Relay) StartServer(ctx context.Context, ServerParams *RelayServerParams) (err error) {
 	relay.log.Info("Relay Server")
 	relay.server = &http.Server{
 		Addr:              relay.URL,
@@ -135,8 +196,79 @@ func (relay *Relay) StartServer(ServerParams *RelayServerParams) (err error) {
 		WriteTimeout:      ServerParams.WriteTimeout,
 		IdleTimeout:       ServerParams.IdleTimeout,
 	}
+	if fanOut := relay.bulletinBoard.FanOut(); fanOut != nil {
+		// Shutdown waits for the streams, so they are ended as soon as it starts
+		relay.server.RegisterOnShutdown(fanOut.Close)
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- relay.server.ListenAndServe()
+	}()
+
+	if relay.admin.URL != "" {
+		relay.adminServer = &http.Server{
+			Addr:              relay.admin.URL,
+			Handler:           relay.AdminRoutes(),
+			ReadHeaderTimeout: ServerParams.ReadHeaderTimeout,
+		}
+		go func() {
+			relay.log.Infof("Admin Server starting on %s ...", relay.admin.URL)
+			err := relay.adminServer.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				relay.log.WithError(err).Error("Admin Server Failed")
+			}
+		}()
+	}
 
-	err = relay.server.ListenAndServe()
+	select {
+	case err = <-serverErr:
+		return err
+	case <-ctx.Done():
+		relay.log.Info("Shutdown Requested, Draining Relay")
+	}
+
+	return relay.Shutdown(ServerParams.ShutdownTimeout)
+}
+
+// Shutdown stops the relay in dependency order. The HTTP servers are drained
+// first so in-flight getPayload calls and their database writes complete, then
+// the bulletin board queues are flushed and the Redis and database
+// connections are closed.
+func (relay *Relay) Shutdown(timeout time.Duration) (err error) {
+	relay.shuttingDown.Store(true)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if relay.server != nil {
+		if err = relay.server.Shutdown(shutdownCtx); err != nil {
+			relay.log.WithError(err).Error("Relay Server Shutdown Failed")
+		}
+	}
+
+	if errReporter := relay.reporterServer.Shutdown(shutdownCtx); errReporter != nil {
+		relay.log.WithError(errReporter).Error("Reporter Server Shutdown Failed")
+	}
+
+	if relay.adminServer != nil {
+		if errAdmin := relay.adminServer.Shutdown(shutdownCtx); errAdmin != nil {
+			relay.log.WithError(errAdmin).Error("Admin Server Shutdown Failed")
+		}
+	}
+
+	relay.stopBulletinBoard()
+	relay.bulletinBoard.Close()
+
+	if errRedis := relay.redis.Client.Close(); errRedis != nil {
+		relay.log.WithError(errRedis).Error("Redis Close Failed")
+	}
+
+	if errDB := relay.db.Close(); errDB != nil {
+		relay.log.WithError(errDB).Error("Database Close Failed")
+	}
+
+	relay.log.Info("Relay Shutdown Complete")
 	return err
 }
 
@@ -163,11 +295,52 @@ func (relay *Relay) BlockSlotTimestamp(slot uint64) uint64 {
 	return relay.network.GenesisTime + (slot * 12)
 }
 
+// payloadDeadline is the latest time the builder payload is useful for the
+// slot, a late request still gets one builder attempt
+func (relay *Relay) payloadDeadline(slot uint64) time.Time {
+	deadline := time.Unix(int64(relay.BlockSlotTimestamp(slot)), 0).Add(relay.payloadTimeout)
+	if earliest := time.Now().Add(relay.builderClient.Timeout()); deadline.Before(earliest) {
+		return earliest
+	}
+	return deadline
+}
+
+func (relay *Relay) currentSlot() uint64 {
+	relay.beaconClient.BeaconData.Mu.Lock()
+	defer relay.beaconClient.BeaconData.Mu.Unlock()
+	return relay.beaconClient.BeaconData.CurrentSlot
+}
+
+// reorged drops the bids of the auctions built on a head replaced by a reorg
+func (relay *Relay) reorged(reorg beaconclient.Reorg) {
+	for _, attrs := range reorg.Invalidated {
+		var parentHash phase0.Hash32
+		parentHashBytes, err := hexutil.Decode(attrs.ParentBlockHash)
+		if err != nil || len(parentHashBytes) != len(parentHash) {
+			relay.log.WithField("slot", attrs.ProposalSlot).Warn("Reorged Auction Without Parent Hash")
+			continue
+		}
+		copy(parentHash[:], parentHashBytes)
+
+		go func(slot uint64) {
+			if _, err := relay.bidBoard.InvalidateAuction(slot, parentHash); err != nil {
+				relay.log.WithError(err).WithField("slot", slot).Error("Failed Dropping Reorged Bids")
+			}
+		}(attrs.ProposalSlot)
+	}
+}
+
 func (relay *Relay) handleLanding(w http.ResponseWriter, req *http.Request) {
 	relay.RespondOK(w, "PON Relay")
 }
 
 func (relay *Relay) handleStatus(w http.ResponseWriter, req *http.Request) {
+	report := relay.HealthReport(req.Context())
+	if !report.Ready {
+		relay.log.WithField("status", report.Status).Warn("Relay Not Ready")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -176,25 +349,45 @@ func (relay *Relay) handleRegisterValidator(w http.ResponseWriter, req *http.Req
 }
 
 func (relay *Relay) handleRelayConfig(w http.ResponseWriter, req *http.Request) {
-	relay.beaconClient.BeaconData.Mu.Lock()
-	defer relay.beaconClient.BeaconData.Mu.Unlock()
+	currentSlot := relay.currentSlot()
+
+	primary, next, cutover, cutoverSlot := relay.keys.Keys(currentSlot)
+	bulletinBoardDomain := relay.bulletinBoard.Domain()
 	relayConfig := RelayConfig{
-		relay.bulletinBoard.Broker,
-		uint16(relay.bulletinBoard.Port),
-		relay.publicKey.String(),
-		relay.network.Network,
-		relay.beaconClient.BeaconData.CurrentSlot,
+		MQTTBroker: relay.bulletinBoard.Broker,
+		MQTTPort:   uint16(relay.bulletinBoard.Port),
+		PublicKey:  primary.String(),
+		PublicKeys: []string{primary.String()},
+		Chain:      relay.network.Network,
+		Slot:       currentSlot,
+
+		BulletinBoardDomain: hexutil.Encode(bulletinBoardDomain[:]),
+	}
+	if relay.bulletinBoard.FanOut() != nil {
+		relayConfig.BulletinBoardStream = bulletinBoardStreamPath
+	}
+	for _, key := range next {
+		relayConfig.PublicKeys = append(relayConfig.PublicKeys, key.String())
+	}
+	if cutover != nil {
+		relayConfig.NextPublicKey = cutover.String()
+		relayConfig.KeyCutoverSlot = cutoverSlot
 	}
 	relay.RespondOK(w, &relayConfig)
 }
 
 func (relay *Relay) handleBountyBids(w http.ResponseWriter, req *http.Request) {
 	blockTimestamp := uint64(time.Now().Unix())
-	builderBlock := new(builderTypes.BuilderBlockBid)
-
-	if err := json.NewDecoder(req.Body).Decode(&builderBlock); err != nil {
+	builderBlock, blockBidMsgBytes, err := relay.decodeSubmission(req)
+	if err != nil {
 		relay.log.WithError(err).Warn("Could Not Convert Payload To Builder Submission")
-		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		relay.decodeError(w, err)
+		return
+	}
+
+	if pause := relay.bidsPaused(builderBlock.Message.Slot); pause != nil {
+		relay.log.WithField("slot", builderBlock.Message.Slot).Warn("Bids Paused For Slot")
+		relay.RespondError(w, http.StatusServiceUnavailable, fmt.Sprintf("Bids Paused For Slot %d: %s", builderBlock.Message.Slot, pause.Reason))
 		return
 	}
 
@@ -241,7 +434,7 @@ func (relay *Relay) handleBountyBids(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	status, err := relay.relayutils.BuilderStatus(builderBlock.Message.BuilderWalletAddress.String())
+	status, reason, err := relay.relayutils.BuilderStatus(builderBlock.Message.BuilderWalletAddress.String())
 	if err != nil {
 		relay.log.WithError(err).Warn("Couldn' Get Builder Status")
 		relay.RespondError(w, http.StatusBadRequest, "Failed To Get Builder")
@@ -249,8 +442,14 @@ func (relay *Relay) handleBountyBids(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if !status {
-		relay.log.Warnf("Builder Not Active In PON, Builder- %s", builderBlock.Message.BuilderWalletAddress.String())
-		relay.RespondError(w, http.StatusBadRequest, "Builder Not Active In PON")
+		relay.log.Warnf("%s, Builder- %s", reason, builderBlock.Message.BuilderWalletAddress.String())
+		relay.RespondError(w, http.StatusBadRequest, reason)
+		return
+	}
+
+	if reason := relay.relayutils.EndpointRejection(builderBlock.Message.BuilderWalletAddress.String(), builderBlock.Message.Endpoint); reason != "" {
+		relay.log.Warnf("%s, Builder- %s", reason, builderBlock.Message.BuilderWalletAddress.String())
+		relay.RespondError(w, http.StatusBadRequest, reason)
 		return
 	}
 
@@ -283,25 +482,6 @@ func (relay *Relay) handleBountyBids(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	builderRPBS, err := rpbs.Verify(*builderBlock)
-	if err != nil {
-		relay.log.WithError(err).Error("RPBS Verify Error")
-		relay.RespondError(w, http.StatusInternalServerError, "RPBS Verify Error")
-		return
-	}
-	if !builderRPBS {
-		relay.log.Error("RPBS Verify Failed")
-		relay.RespondError(w, http.StatusBadRequest, "RPBS Verify Failed")
-		return
-	}
-
-	blockBidMsgBytes, err := builderBlock.Message.HashTreeRoot()
-	if err != nil {
-		relay.log.Error("could get block bid message hash tree root", "err", err)
-		relay.RespondError(w, http.StatusBadRequest, "could get block bid message hash tree root")
-		return
-	}
-
 	pubkey, err := crypto.Ecrecover(blockBidMsgBytes[:], builderBlock.EcdsaSignature[:])
 	if err != nil {
 		relay.log.Error("Could not recover ECDSA pubkey", "err", err)
@@ -323,6 +503,22 @@ func (relay *Relay) handleBountyBids(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if relay.builderRateLimited(w, req, builderBlock.Message.BuilderWalletAddress.String()) {
+		return
+	}
+
+	if err := relay.verifyPayoutTransaction(builderBlock.Message); err != nil {
+		relay.log.WithError(err).Warnf("Bogus Payout, Builder- %s", builderBlock.Message.BuilderWalletAddress.String())
+		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := relay.checkPayoutFunds(req.Context(), builderBlock.Message, common.Hash(baseExecutionPayloadHeader.ParentHash)); err != nil {
+		relay.log.WithError(err).Warnf("Builder Can't Pay, Builder- %s", builderBlock.Message.BuilderWalletAddress.String())
+		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	/* @dev
 	Once the public key is obained and verified from the signature as that
 	of the builder, we can check if this public key signed the block bid message,
@@ -347,6 +543,19 @@ func (relay *Relay) handleBountyBids(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// RPBS needs pairings, it is only checked once the cheaper ECDSA signature holds
+	builderRPBS, err := rpbs.Verify(*builderBlock)
+	if err != nil {
+		relay.log.WithError(err).Error("RPBS Verify Error")
+		relay.RespondError(w, http.StatusInternalServerError, "RPBS Verify Error")
+		return
+	}
+	if !builderRPBS {
+		relay.log.Error("RPBS Verify Failed")
+		relay.RespondError(w, http.StatusBadRequest, "RPBS Verify Failed")
+		return
+	}
+
 	/// @dev Sees if builder submitted another bid while we are working with this Bid.
 	lastBid, err := relay.bidBoard.BuilderBlockLast(builderBlock.Message.Slot, builderBlock.Message.BuilderWalletAddress.String())
 	if err != nil {
@@ -364,8 +573,12 @@ func (relay *Relay) handleBountyBids(w http.ResponseWriter, req *http.Request) {
 	//             SANITY CHECKS END HERE BID GOOD TO GO
 	///////////////////////////////////////////////////////////////////////////
 
-	signedBuilderBid, err := SignedBuilderBid(*builderBlock, relay.blsSk, relay.publicKey, relay.network.DomainBuilder)
-	if err != nil {
+	signedBuilderBid, err := SignedBuilderBid(req.Context(), *builderBlock, relay.keys.SignerForSlot(builderBlock.Message.Slot), relay.network.DomainBuilder)
+	if errors.Is(err, signing.ErrSignerTimeout) {
+		relay.log.WithError(err).Error("signer too slow to sign builder bid")
+		relay.RespondError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	} else if err != nil {
 		relay.log.WithError(err).Error("could not sign builder bid")
 		relay.RespondError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -388,12 +601,13 @@ func (relay *Relay) handleBountyBids(w http.ResponseWriter, req *http.Request) {
 		Data:                 builderBlock.Message.ExecutionPayloadHeader,
 		API:                  builderBlock.Message.Endpoint,
 		BuilderWalletAddress: builderBlock.Message.BuilderWalletAddress.String(),
+		PayoutTransaction:    builderBlock.Message.PayoutPoolTransaction,
 	}
 
 	/// @dev We send builder to store that this builder won the bounty bid.
 	/// @dev It sends false if some builder has already won the bounty bid while we were working with the bid
 	/// @dev If thats not the case it will set this builder as winner of bounty bid
-	bountyBidWon, err := relay.bidBoard.SetBountyBidForSlot(builderBlock.Message.Slot, builderBlock.Message.BuilderWalletAddress.String())
+	bountyBidWon, err := relay.bidBoard.SetBountyBidForSlot(builderBlock.Message.Slot, builderBlock.Message.BuilderWalletAddress.String(), phase0.Hash32(builderBlock.Message.BlockHash))
 	if err != nil {
 		relay.log.WithError(err).Error("Could Not Set Bounty Bid")
 		relay.RespondError(w, http.StatusBadRequest, "Could Not Set Bounty Bid")
@@ -476,11 +690,16 @@ func (relay *Relay) handleBountyBids(w http.ResponseWriter, req *http.Request) {
 func (relay *Relay) handleSubmitBlock(w http.ResponseWriter, req *http.Request) {
 
 	blockTimestamp := time.Now()
-	builderBlock := new(builderTypes.BuilderBlockBid)
-
-	if err := json.NewDecoder(req.Body).Decode(&builderBlock); err != nil {
+	builderBlock, blockBidMsgBytes, err := relay.decodeSubmission(req)
+	if err != nil {
 		relay.log.WithError(err).Warn("Could Not Convert Patload To Builder Submission")
-		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		relay.decodeError(w, err)
+		return
+	}
+
+	if pause := relay.bidsPaused(builderBlock.Message.Slot); pause != nil {
+		relay.log.WithField("slot", builderBlock.Message.Slot).Warn("Bids Paused For Slot")
+		relay.RespondError(w, http.StatusServiceUnavailable, fmt.Sprintf("Bids Paused For Slot %d: %s", builderBlock.Message.Slot, pause.Reason))
 		return
 	}
 
@@ -496,15 +715,21 @@ func (relay *Relay) handleSubmitBlock(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	status, err := relay.relayutils.BuilderStatus(builderBlock.Message.BuilderWalletAddress.String())
+	status, reason, err := relay.relayutils.BuilderStatus(builderBlock.Message.BuilderWalletAddress.String())
 	if err != nil {
 		relay.log.WithError(err).Warn("Couldn' Get Builder Status")
 		relay.RespondError(w, http.StatusBadRequest, "Failed To Get Builder")
 		return
 	}
 	if !status {
-		relay.log.Warnf("Builder Not Active In PON, Builder- %s", builderBlock.Message.BuilderWalletAddress.String())
-		relay.RespondError(w, http.StatusBadRequest, "Builder Not Active In PON")
+		relay.log.Warnf("%s, Builder- %s", reason, builderBlock.Message.BuilderWalletAddress.String())
+		relay.RespondError(w, http.StatusBadRequest, reason)
+		return
+	}
+
+	if reason := relay.relayutils.EndpointRejection(builderBlock.Message.BuilderWalletAddress.String(), builderBlock.Message.Endpoint); reason != "" {
+		relay.log.Warnf("%s, Builder- %s", reason, builderBlock.Message.BuilderWalletAddress.String())
+		relay.RespondError(w, http.StatusBadRequest, reason)
 		return
 	}
 
@@ -544,25 +769,6 @@ func (relay *Relay) handleSubmitBlock(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	builderRPBS, err := rpbs.Verify(*builderBlock)
-	if err != nil {
-		relay.log.WithError(err).Error("RPBS Verify Error")
-		relay.RespondError(w, http.StatusInternalServerError, "RPBS Verify Error")
-		return
-	}
-	if !builderRPBS {
-		relay.log.Error("RPBS Verify Failed")
-		relay.RespondError(w, http.StatusBadRequest, "RPBS Verify Failed")
-		return
-	}
-
-	blockBidMsgBytes, err := builderBlock.Message.HashTreeRoot()
-	if err != nil {
-		relay.log.Error("could get block bid message hash tree root", "err", err)
-		relay.RespondError(w, http.StatusBadRequest, "could get block bid message hash tree root")
-		return
-	}
-
 	pubkey, err := crypto.Ecrecover(blockBidMsgBytes[:], builderBlock.EcdsaSignature[:])
 	if err != nil {
 		relay.log.Error("Could not recover ECDSA pubkey", "err", err)
@@ -583,6 +789,36 @@ func (relay *Relay) handleSubmitBlock(w http.ResponseWriter, req *http.Request)
 		relay.RespondError(w, http.StatusBadRequest, "ECDSA pubkey does not match wallet address")
 		return
 	}
+
+	if relay.builderRateLimited(w, req, builderBlock.Message.BuilderWalletAddress.String()) {
+		return
+	}
+
+	if err := relay.verifyPayoutTransaction(builderBlock.Message); err != nil {
+		relay.log.WithError(err).Warnf("Bogus Payout, Builder- %s", builderBlock.Message.BuilderWalletAddress.String())
+		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := relay.checkPayoutFunds(req.Context(), builderBlock.Message, common.Hash(baseExecutionPayloadHeader.ParentHash)); err != nil {
+		relay.log.WithError(err).Warnf("Builder Can't Pay, Builder- %s", builderBlock.Message.BuilderWalletAddress.String())
+		relay.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// RPBS needs pairings, it is only checked once the cheaper ECDSA signature holds
+	builderRPBS, err := rpbs.Verify(*builderBlock)
+	if err != nil {
+		relay.log.WithError(err).Error("RPBS Verify Error")
+		relay.RespondError(w, http.StatusInternalServerError, "RPBS Verify Error")
+		return
+	}
+	if !builderRPBS {
+		relay.log.Error("RPBS Verify Failed")
+		relay.RespondError(w, http.StatusBadRequest, "RPBS Verify Failed")
+		return
+	}
+
 	rpbsString, err := json.Marshal(*builderBlock.Message.RPBS)
 	if err != nil {
 		relay.log.Errorf("Couldn't Get RPBS String")
@@ -620,8 +856,12 @@ func (relay *Relay) handleSubmitBlock(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	signedBuilderBid, err := SignedBuilderBid(*builderBlock, relay.blsSk, relay.publicKey, relay.network.DomainBuilder)
-	if err != nil {
+	signedBuilderBid, err := SignedBuilderBid(req.Context(), *builderBlock, relay.keys.SignerForSlot(builderBlock.Message.Slot), relay.network.DomainBuilder)
+	if errors.Is(err, signing.ErrSignerTimeout) {
+		relay.log.WithError(err).Error("signer too slow to sign builder bid")
+		relay.RespondError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	} else if err != nil {
 		relay.log.WithError(err).Error("could not sign builder bid")
 		relay.RespondError(w, http.StatusBadRequest, err.Error())
 		return
@@ -644,6 +884,7 @@ func (relay *Relay) handleSubmitBlock(w http.ResponseWriter, req *http.Request)
 		Data:                 builderBlock.Message.ExecutionPayloadHeader,
 		API:                  builderBlock.Message.Endpoint,
 		BuilderWalletAddress: builderBlock.Message.BuilderWalletAddress.String(),
+		PayoutTransaction:    builderBlock.Message.PayoutPoolTransaction,
 	}
 
 	err = relay.bidBoard.SavePayloadUtils(builderBlock.Message.Slot, builderBlock.Message.ProposerPubkey.String(), builderBlock.Message.BlockHash.String(), &getPayloadHeaderResponse)
@@ -701,6 +942,12 @@ func (relay *Relay) handleProposerHeader(w http.ResponseWriter, req *http.Reques
 		"slot": proposerReq.Slot,
 	}).Info("Get Header Requested From Proposer To Relay")
 
+	if pause := relay.headersPaused(proposerReq.Slot); pause != nil {
+		relay.log.WithField("slot", proposerReq.Slot).Warn("Headers Paused For Slot")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
 	bid, err := relay.bidBoard.WinningBid(proposerReq.Slot)
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
@@ -743,25 +990,47 @@ func (relay *Relay) handleProposerHeader(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	proposerBulletinBoard := bulletinBoardTypes.ProposerHeaderRequest{
+	proposerBulletinBoard := bulletinboard.HeaderRequest{
 		Slot:      proposerReq.Slot,
 		Proposer:  proposerReq.ProposerPubKeyHex,
+		BlockHash: baseExecutionPayloadHeader.BlockHash,
 		Timestamp: uint64(time.Now().Unix()),
 	}
-	relay.bulletinBoard.Channel.ProposerHeaderChannel <- proposerBulletinBoard
+	relay.bulletinBoard.SendHeaderRequest(proposerBulletinBoard)
 
 	relay.log.WithFields(logrus.Fields{
 		"value":     builderBidSubmission.Value.String(),
 		"blockHash": baseExecutionPayloadHeader.BlockHash.String(),
 	}).Info("Bid Delivered To Proposer")
 
+	version, err := versionedExcutionPayloadHeader.Version()
+	if err != nil {
+		relay.log.WithError(err).Error("Could Not Get Bid Fork Version")
+		relay.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set(headerConsensusVersion, version)
+
+	defer relay.headerServed(proposerBulletinBoard, builderBidSubmission.Value)
+
+	if acceptsSSZ(req) {
+		bidSSZ, err := marshalSignedBuilderBidSSZ(bid.Bid.Data)
+		if err != nil {
+			relay.log.WithError(err).Error("Could Not Encode Bid As SSZ")
+			relay.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		relay.respondSSZ(w, version, bidSSZ)
+		return
+	}
+
 	relay.RespondOK(w, &bid.Bid)
 }
 
 func (relay *Relay) handleProposerPayload(mevBoost http.ResponseWriter, req *http.Request) {
 
-	payload := new(commonTypes.VersionedSignedBlindedBeaconBlock)
-	if err := json.NewDecoder(req.Body).Decode(payload); err != nil {
+	payload, err := decodeSignedBlindedBeaconBlock(req)
+	if err != nil {
 		relay.log.WithError(err).Warn("Proposer payload request failed to decode")
 		relay.RespondError(mevBoost, http.StatusBadRequest, fmt.Sprintf("Proposer payload request failed to decode. %s", err.Error()))
 		return
@@ -824,6 +1093,17 @@ func (relay *Relay) handleProposerPayload(mevBoost http.ResponseWriter, req *htt
 		return
 	}
 
+	delivered := false
+	defer func() {
+		relay.bulletinBoard.SendTimeline(bulletinboard.PayloadServed{
+			Slot:      uint64(slot),
+			Proposer:  proposerPubkey.String(),
+			Builder:   blockSubmission.BuilderWalletAddress,
+			BlockHash: baseSignedBlindedBeaconBlock.Message.Body.ExecutionPayloadHeader.BlockHash,
+			Delivered: delivered,
+		})
+	}()
+
 	proposerBlock := &databaseTypes.ValidatorReturnedBlockDatabase{
 		Signature:      baseSignedBlindedBeaconBlock.Signature.String(),
 		Slot:           uint64(slot),
@@ -838,30 +1118,18 @@ func (relay *Relay) handleProposerPayload(mevBoost http.ResponseWriter, req *htt
 		return
 	}
 
-	// @dev Get Payload From Builder by sending the buuilder the signed blinded beacon block
-	resp, err := sendHTTPRequest(*relay.client, blockSubmission.API, payload)
+	// @dev Get Payload From Builder by sending the buuilder the signed blinded beacon block,
+	// retrying and hedging to the builder's fallback endpoints until the payload deadline
+	payloadCtx, cancel := context.WithDeadline(req.Context(), relay.payloadDeadline(uint64(slot)))
+	defer cancel()
+	getPayloadResponse := new(commonTypes.VersionedExecutionPayload)
+	err = relay.builderClient.GetPayload(payloadCtx, blockSubmission.BuilderWalletAddress, builderclient.Endpoints(blockSubmission.API), payload, getPayloadResponse)
 	if err != nil {
-		relay.log.WithError(err).Error("getPayload request to builder from relay failed")
+		relay.log.WithError(err).WithField("builder", blockSubmission.BuilderWalletAddress).Error("getPayload request to builder from relay failed")
 		relay.RespondError(mevBoost, http.StatusInternalServerError, fmt.Sprintf("getPayload request to builder failed. %s", err.Error()))
 		return
 	}
 
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		relay.log.WithError(err).Error("getPayload request to builder from relay failed")
-		response, _ := io.ReadAll(resp.Body)
-		relay.RespondError(mevBoost, http.StatusBadRequest, fmt.Sprintf("getPayload request to builder from relay failed. %s", string(response)))
-		return
-	}
-
-	// @dev Decode builder's versioned execution payload response
-	getPayloadResponse := new(commonTypes.VersionedExecutionPayload)
-	if err := json.NewDecoder(resp.Body).Decode(&getPayloadResponse); err != nil {
-		relay.log.WithError(err).Error("getPayload request from builder failed to decode")
-		relay.RespondError(mevBoost, http.StatusBadRequest, fmt.Sprintf("getPayload request from builder failed to decode. %s", err.Error()))
-		return
-	}
-
 	// unpack the obtained versioned execution payload into a base execution payload for access
 	baseExecutionPayload, err := getPayloadResponse.ToBaseExecutionPayload()
 	if err != nil {
@@ -870,6 +1138,17 @@ func (relay *Relay) handleProposerPayload(mevBoost http.ResponseWriter, req *htt
 		return
 	}
 
+	// The proposer already signed the header, withholding the payload would
+	// only cost them the slot so a missing payout is flagged but delivered
+	if relay.payout.Enabled() && len(blockSubmission.PayoutTransaction) > 0 {
+		if err := verifyPayoutInPayload(blockSubmission.PayoutTransaction, baseExecutionPayload.Transactions); err != nil {
+			relay.log.WithError(err).WithFields(logrus.Fields{
+				"slot":    slot,
+				"builder": blockSubmission.BuilderWalletAddress,
+			}).Error("Builder Payload Doesn't End With The Bid Payout")
+		}
+	}
+
 	defer func() {
 		payloadJSON, _ := json.Marshal(getPayloadResponse)
 		if err != nil {
@@ -908,16 +1187,47 @@ func (relay *Relay) handleProposerPayload(mevBoost http.ResponseWriter, req *htt
 		return
 	}
 
-	relay.RespondOK(mevBoost, &executionPayloadResponse)
+	mevBoost.Header().Set(headerConsensusVersion, executionPayloadResponse.VersionName)
 
-	proposerBulletinBoard := bulletinBoardTypes.SlotPayloadRequest{
-		Slot:     uint64(baseSignedBlindedBeaconBlock.Message.Slot),
-		Proposer: proposerPubkey.String(),
+	if acceptsSSZ(req) {
+		payloadSSZ, err := getPayloadResponse.MarshalSSZ()
+		if err != nil {
+			relay.log.WithError(err).Error("could not encode payload as SSZ")
+			relay.RespondError(mevBoost, http.StatusInternalServerError, err.Error())
+			return
+		}
+		delivered = true
+		relay.respondSSZ(mevBoost, executionPayloadResponse.VersionName, payloadSSZ)
+	} else {
+		delivered = true
+		relay.RespondOK(mevBoost, &executionPayloadResponse)
 	}
 
-	relay.bulletinBoard.Channel.SlotPayloadChannel <- proposerBulletinBoard
+	proposerBulletinBoard := bulletinboard.PayloadRequest{
+		Slot:      uint64(baseSignedBlindedBeaconBlock.Message.Slot),
+		Proposer:  proposerPubkey.String(),
+		BlockHash: baseSignedBlindedBeaconBlock.Message.Body.ExecutionPayloadHeader.BlockHash,
+	}
+
+	relay.bulletinBoard.SendPayloadRequest(proposerBulletinBoard)
 
 	relay.log.WithFields(logrus.Fields{
 		"Slot": slot,
 	}).Info("Payload Delivered To Proposer!")
 }
+
+// headerServed publishes the bid served to the proposer on the auction timeline
+func (relay *Relay) headerServed(request bulletinboard.HeaderRequest, value *big.Int) {
+	payloadUtils, err := relay.bidBoard.PayloadUtils(request.Slot, request.BlockHash.String())
+	if err != nil {
+		relay.log.WithError(err).WithField("slot", request.Slot).Warn("Could Not Get Builder Of Served Header")
+	}
+
+	relay.bulletinBoard.SendTimeline(bulletinboard.HeaderServed{
+		Slot:      request.Slot,
+		Proposer:  request.Proposer,
+		Builder:   payloadUtils.BuilderWalletAddress,
+		Value:     value,
+		BlockHash: request.BlockHash,
+	})
+}
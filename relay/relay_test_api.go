@@ -13,13 +13,14 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/altair"
 	capella "github.com/attestantio/go-eth2-client/spec/capella"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
-	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
 	commonTypes "github.com/bsn-eng/pon-golang-types/common"
 	databaseTypes "github.com/bsn-eng/pon-golang-types/database"
 	"github.com/go-redis/redis/v9"
 	"github.com/gorilla/mux"
 	"github.com/prysmaticlabs/go-bitfield"
 	"github.com/sirupsen/logrus"
+
+	"github.com/pon-pbs/bbRelay/bulletinboard"
 )
 
 func (relay *Relay) handleProposerTestPayload(w http.ResponseWriter, req *http.Request) {
@@ -105,11 +106,12 @@ func (relay *Relay) handleProposerTestPayload(w http.ResponseWriter, req *http.R
 		}
 	}()
 
-	proposerBulletinBoard := bulletinBoardTypes.SlotPayloadRequest{
-		Slot:     uint64(baseSignedBlindedBeaconBlock.Message.Slot),
-		Proposer: proposerPubkey,
+	proposerBulletinBoard := bulletinboard.PayloadRequest{
+		Slot:      uint64(baseSignedBlindedBeaconBlock.Message.Slot),
+		Proposer:  proposerPubkey,
+		BlockHash: baseSignedBlindedBeaconBlock.Message.Body.ExecutionPayloadHeader.BlockHash,
 	}
-	relay.bulletinBoard.Channel.SlotPayloadChannel <- proposerBulletinBoard
+	relay.bulletinBoard.SendPayloadRequest(proposerBulletinBoard)
 
 	relay.RespondOK(w, &getPayloadResponse)
 	relay.log.WithFields(logrus.Fields{
@@ -163,12 +165,12 @@ func (relay *Relay) TESThandleProposerHeader(w http.ResponseWriter, req *http.Re
 		return
 	}
 
-	proposerBulletinBoard := bulletinBoardTypes.ProposerHeaderRequest{
+	proposerBulletinBoard := bulletinboard.HeaderRequest{
 		Slot:      proposerReq.Slot,
 		Proposer:  proposerReq.ProposerPubKeyHex,
 		Timestamp: uint64(time.Now().Unix()),
 	}
-	relay.bulletinBoard.Channel.ProposerHeaderChannel <- proposerBulletinBoard
+	relay.bulletinBoard.SendHeaderRequest(proposerBulletinBoard)
 
 	relay.log.WithFields(logrus.Fields{
 		"value":     builderBidSubmission.Value.String(),
@@ -0,0 +1,422 @@
+package relay
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apiBellatrix "github.com/attestantio/go-eth2-client/api/v1/bellatrix"
+	apiCapella "github.com/attestantio/go-eth2-client/api/v1/capella"
+	apiDeneb "github.com/attestantio/go-eth2-client/api/v1/deneb"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	builderTypes "github.com/bsn-eng/pon-golang-types/builder"
+	commonTypes "github.com/bsn-eng/pon-golang-types/common"
+	relayTypes "github.com/bsn-eng/pon-golang-types/relay"
+	rpbsTypes "github.com/bsn-eng/pon-golang-types/rpbs"
+)
+
+const (
+	mediaTypeJSON = "application/json"
+	mediaTypeSSZ  = "application/octet-stream"
+
+	headerConsensusVersion = "Eth-Consensus-Version"
+)
+
+var (
+	ErrSSZ        = errors.New("invalid ssz")
+	ErrSubmission = errors.New("incomplete builder submission")
+)
+
+// BuilderBlockBid is encoded as the SSZ container
+//
+//	signature: Bytes96, message: BidPayload, ecdsa_signature: Bytes65
+//
+// and BidPayload as
+//
+//	slot: uint64, parent_hash: Bytes32, block_hash: Bytes32,
+//	builder_pubkey: Bytes48, proposer_pubkey: Bytes48,
+//	proposer_fee_recipient: Bytes20, gas_limit: uint64, gas_used: uint64,
+//	value: uint256, execution_payload_header: ExecutionPayloadHeader,
+//	endpoint: ByteList, builder_wallet_address: Bytes20,
+//	payout_pool_transaction: ByteList, rpbs: RPBSSignature, rpbs_pubkey: ByteList
+//
+// where RPBSSignature holds z1_hat, c1_hat, s1_hat, c2_hat, s2_hat and m1_hat
+// as ByteLists.
+const (
+	builderBlockBidFixedSize = 96 + 4 + 65
+	bidPayloadFixedSize      = 8 + 32 + 32 + 48 + 48 + 20 + 8 + 8 + 32 + 4 + 4 + 20 + 4 + 4 + 4
+	rpbsSignatureFixedSize   = 6 * 4
+
+	signedBuilderBidFixedSize = 4 + 96
+	builderBidFixedSize       = 4 + 32 + 48
+)
+
+// decodeSubmission decodes a builder submission as SSZ when sent as
+// application/octet-stream and as JSON otherwise, gzip bodies are inflated
+// up to the max submission size. The hash tree root of the message is
+// returned along, the builder's ECDSA signature is over it.
+func (relay *Relay) decodeSubmission(req *http.Request) (*builderTypes.BuilderBlockBid, [32]byte, error) {
+	builderBlock, err := relay.decodeSubmissionBody(req)
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	message := builderBlock.Message
+	if message == nil || message.ExecutionPayloadHeader == nil || message.Value == nil || message.RPBS == nil {
+		return nil, [32]byte{}, ErrSubmission
+	}
+	root, err := message.HashTreeRoot()
+	if err != nil {
+		return nil, [32]byte{}, fmt.Errorf("could not get block bid message hash tree root: %w", err)
+	}
+	return builderBlock, root, nil
+}
+
+func (relay *Relay) decodeSubmissionBody(req *http.Request) (*builderTypes.BuilderBlockBid, error) {
+	body := req.Body
+	if strings.EqualFold(req.Header.Get("Content-Encoding"), "gzip") {
+		gzipReader, err := gzip.NewReader(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		body = io.NopCloser(gzipReader)
+		if relay.limits.MaxBodySize > 0 {
+			body = http.MaxBytesReader(nil, body, relay.limits.MaxBodySize)
+		}
+	}
+
+	builderBlock := new(builderTypes.BuilderBlockBid)
+	if requestMediaType(req) != mediaTypeSSZ {
+		if err := json.NewDecoder(body).Decode(builderBlock); err != nil {
+			return nil, err
+		}
+		return builderBlock, nil
+	}
+
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := UnmarshalBuilderBlockBidSSZ(buf, builderBlock, req.Header.Get(headerConsensusVersion)); err != nil {
+		return nil, err
+	}
+	return builderBlock, nil
+}
+
+// acceptsSSZ reports whether the client prefers SSZ over JSON in its Accept
+// header, JSON wins ties and is used when nothing is said
+func acceptsSSZ(req *http.Request) bool {
+	var sszQuality, jsonQuality float64
+	for _, accepted := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case mediaTypeSSZ:
+			sszQuality = quality
+		case mediaTypeJSON, "*/*":
+			if quality > jsonQuality {
+				jsonQuality = quality
+			}
+		}
+	}
+	return sszQuality > 0 && sszQuality > jsonQuality
+}
+
+// respondSSZ writes an SSZ encoded response for the consensus version
+func (relay *Relay) respondSSZ(w http.ResponseWriter, version string, data []byte) {
+	w.Header().Set("Content-Type", mediaTypeSSZ)
+	w.Header().Set(headerConsensusVersion, version)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		relay.log.WithError(err).Error("Couldn't write SSZ response")
+	}
+}
+
+// decodeSignedBlindedBeaconBlock decodes the getPayload request body as SSZ
+// when sent as application/octet-stream and as JSON otherwise
+func decodeSignedBlindedBeaconBlock(req *http.Request) (*commonTypes.VersionedSignedBlindedBeaconBlock, error) {
+	block := new(commonTypes.VersionedSignedBlindedBeaconBlock)
+	if requestMediaType(req) != mediaTypeSSZ {
+		if err := json.NewDecoder(req.Body).Decode(block); err != nil {
+			return nil, err
+		}
+		return block, nil
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch version := req.Header.Get(headerConsensusVersion); strings.ToLower(version) {
+	case "":
+		err = block.UnmarshalSSZ(buf)
+	case "bellatrix":
+		block.Bellatrix = new(apiBellatrix.SignedBlindedBeaconBlock)
+		err = block.Bellatrix.UnmarshalSSZ(buf)
+	case "capella":
+		block.Capella = new(apiCapella.SignedBlindedBeaconBlock)
+		err = block.Capella.UnmarshalSSZ(buf)
+	case "deneb":
+		block.Deneb = new(apiDeneb.SignedBlindedBeaconBlock)
+		err = block.Deneb.UnmarshalSSZ(buf)
+	default:
+		err = fmt.Errorf("unsupported consensus version %s", version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// marshalSignedBuilderBidSSZ encodes the bid as the builder API
+// SignedBuilderBid container {message: BuilderBid, signature: BLSSignature}
+// with BuilderBid {header: ExecutionPayloadHeader, value: uint256, pubkey: BLSPubkey}
+func marshalSignedBuilderBidSSZ(bid *relayTypes.SignedBuilderBlockBid) ([]byte, error) {
+	if bid.Message == nil || bid.Message.ExecutionPayloadHeader == nil || bid.Message.Value == nil {
+		return nil, fmt.Errorf("%w: incomplete builder bid", ErrSSZ)
+	}
+	if bid.Message.Value.Sign() < 0 || bid.Message.Value.BitLen() > 256 {
+		return nil, fmt.Errorf("%w: value does not fit uint256", ErrSSZ)
+	}
+	header, err := bid.Message.ExecutionPayloadHeader.MarshalSSZ()
+	if err != nil {
+		return nil, err
+	}
+	value := make([]byte, 32)
+	bid.Message.Value.FillBytes(value)
+
+	buf := make([]byte, 0, signedBuilderBidFixedSize+builderBidFixedSize+len(header))
+	buf = binary.LittleEndian.AppendUint32(buf, signedBuilderBidFixedSize)
+	buf = append(buf, bid.Signature[:]...)
+	buf = binary.LittleEndian.AppendUint32(buf, builderBidFixedSize)
+	buf = append(buf, reverse(value)...)
+	buf = append(buf, bid.Message.Pubkey[:]...)
+	return append(buf, header...), nil
+}
+
+func requestMediaType(req *http.Request) string {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return mediaTypeJSON
+	}
+	return mediaType
+}
+
+// UnmarshalBuilderBlockBidSSZ decodes an SSZ encoded submission. The execution
+// payload header is decoded for the given fork, or the newest fork it fits if
+// version is empty.
+func UnmarshalBuilderBlockBidSSZ(buf []byte, bid *builderTypes.BuilderBlockBid, version string) error {
+	fields, err := sszVariableFields(buf, builderBlockBidFixedSize, 96)
+	if err != nil {
+		return fmt.Errorf("builder block bid: %w", err)
+	}
+	copy(bid.Signature[:], buf[0:96])
+	copy(bid.EcdsaSignature[:], buf[100:165])
+
+	bid.Message = new(builderTypes.BidPayload)
+	return unmarshalBidPayloadSSZ(fields[0], bid.Message, version)
+}
+
+func unmarshalBidPayloadSSZ(buf []byte, payload *builderTypes.BidPayload, version string) error {
+	fields, err := sszVariableFields(buf, bidPayloadFixedSize, 236, 240, 264, 268, 272)
+	if err != nil {
+		return fmt.Errorf("bid payload: %w", err)
+	}
+
+	payload.Slot = binary.LittleEndian.Uint64(buf[0:8])
+	copy(payload.ParentHash[:], buf[8:40])
+	copy(payload.BlockHash[:], buf[40:72])
+	copy(payload.BuilderPubkey[:], buf[72:120])
+	copy(payload.ProposerPubkey[:], buf[120:168])
+	copy(payload.ProposerFeeRecipient[:], buf[168:188])
+	payload.GasLimit = binary.LittleEndian.Uint64(buf[188:196])
+	payload.GasUsed = binary.LittleEndian.Uint64(buf[196:204])
+	payload.Value = new(big.Int).SetBytes(reverse(buf[204:236]))
+	copy(payload.BuilderWalletAddress[:], buf[244:264])
+
+	payload.ExecutionPayloadHeader = new(commonTypes.VersionedExecutionPayloadHeader)
+	if err := unmarshalExecutionPayloadHeaderSSZ(fields[0], payload.ExecutionPayloadHeader, version); err != nil {
+		return fmt.Errorf("execution payload header: %w", err)
+	}
+	payload.Endpoint = string(fields[1])
+	payload.PayoutPoolTransaction = append([]byte{}, fields[2]...)
+
+	rpbsFields, err := sszVariableFields(fields[3], rpbsSignatureFixedSize, 0, 4, 8, 12, 16, 20)
+	if err != nil {
+		return fmt.Errorf("rpbs: %w", err)
+	}
+	payload.RPBS = &rpbsTypes.EncodedRPBSSignature{
+		Z1Hat: string(rpbsFields[0]),
+		C1Hat: string(rpbsFields[1]),
+		S1Hat: string(rpbsFields[2]),
+		C2Hat: string(rpbsFields[3]),
+		S2Hat: string(rpbsFields[4]),
+		M1Hat: string(rpbsFields[5]),
+	}
+	payload.RPBSPubkey = string(fields[4])
+
+	return nil
+}
+
+func unmarshalExecutionPayloadHeaderSSZ(buf []byte, header *commonTypes.VersionedExecutionPayloadHeader, version string) error {
+	switch strings.ToLower(version) {
+	case "":
+		return header.UnmarshalSSZ(buf)
+	case "bellatrix":
+		header.Bellatrix = new(bellatrix.ExecutionPayloadHeader)
+		return header.Bellatrix.UnmarshalSSZ(buf)
+	case "capella":
+		header.Capella = new(capella.ExecutionPayloadHeader)
+		return header.Capella.UnmarshalSSZ(buf)
+	case "deneb":
+		header.Deneb = new(deneb.ExecutionPayloadHeader)
+		return header.Deneb.UnmarshalSSZ(buf)
+	default:
+		return fmt.Errorf("unsupported consensus version %s", version)
+	}
+}
+
+// MarshalBuilderBlockBidSSZ encodes a submission the way
+// UnmarshalBuilderBlockBidSSZ decodes it, for builders submitting SSZ
+func MarshalBuilderBlockBidSSZ(bid *builderTypes.BuilderBlockBid) ([]byte, error) {
+	if bid.Message == nil {
+		return nil, fmt.Errorf("%w: missing message", ErrSSZ)
+	}
+	message, err := marshalBidPayloadSSZ(bid.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, builderBlockBidFixedSize+len(message))
+	buf = append(buf, bid.Signature[:]...)
+	buf = binary.LittleEndian.AppendUint32(buf, builderBlockBidFixedSize)
+	buf = append(buf, bid.EcdsaSignature[:]...)
+	return append(buf, message...), nil
+}
+
+func marshalBidPayloadSSZ(payload *builderTypes.BidPayload) ([]byte, error) {
+	if payload.ExecutionPayloadHeader == nil || payload.RPBS == nil || payload.Value == nil {
+		return nil, fmt.Errorf("%w: incomplete bid payload", ErrSSZ)
+	}
+	if payload.Value.Sign() < 0 || payload.Value.BitLen() > 256 {
+		return nil, fmt.Errorf("%w: value does not fit uint256", ErrSSZ)
+	}
+	header, err := payload.ExecutionPayloadHeader.MarshalSSZ()
+	if err != nil {
+		return nil, err
+	}
+	rpbs := marshalSSZVariableFields(
+		[]byte(payload.RPBS.Z1Hat),
+		[]byte(payload.RPBS.C1Hat),
+		[]byte(payload.RPBS.S1Hat),
+		[]byte(payload.RPBS.C2Hat),
+		[]byte(payload.RPBS.S2Hat),
+		[]byte(payload.RPBS.M1Hat),
+	)
+
+	value := make([]byte, 32)
+	payload.Value.FillBytes(value)
+
+	variable := [][]byte{header, []byte(payload.Endpoint), payload.PayoutPoolTransaction, rpbs, []byte(payload.RPBSPubkey)}
+	offsets := sszOffsets(bidPayloadFixedSize, variable...)
+
+	buf := make([]byte, 0, int(offsets[len(offsets)-1])+len(variable[len(variable)-1]))
+	buf = binary.LittleEndian.AppendUint64(buf, payload.Slot)
+	buf = append(buf, payload.ParentHash[:]...)
+	buf = append(buf, payload.BlockHash[:]...)
+	buf = append(buf, payload.BuilderPubkey[:]...)
+	buf = append(buf, payload.ProposerPubkey[:]...)
+	buf = append(buf, payload.ProposerFeeRecipient[:]...)
+	buf = binary.LittleEndian.AppendUint64(buf, payload.GasLimit)
+	buf = binary.LittleEndian.AppendUint64(buf, payload.GasUsed)
+	buf = append(buf, reverse(value)...)
+	buf = binary.LittleEndian.AppendUint32(buf, offsets[0])
+	buf = binary.LittleEndian.AppendUint32(buf, offsets[1])
+	buf = append(buf, payload.BuilderWalletAddress[:]...)
+	buf = binary.LittleEndian.AppendUint32(buf, offsets[2])
+	buf = binary.LittleEndian.AppendUint32(buf, offsets[3])
+	buf = binary.LittleEndian.AppendUint32(buf, offsets[4])
+	for _, field := range variable {
+		buf = append(buf, field...)
+	}
+	return buf, nil
+}
+
+// sszVariableFields checks the offsets found at offsetPositions of the fixed
+// part and returns the variable size fields they point to
+func sszVariableFields(buf []byte, fixedSize int, offsetPositions ...int) ([][]byte, error) {
+	if len(buf) < fixedSize {
+		return nil, fmt.Errorf("%w: %d bytes, expected at least %d", ErrSSZ, len(buf), fixedSize)
+	}
+
+	offsets := make([]int, len(offsetPositions)+1)
+	for i, position := range offsetPositions {
+		offsets[i] = int(binary.LittleEndian.Uint32(buf[position : position+4]))
+		if offsets[i] > len(buf) {
+			return nil, fmt.Errorf("%w: offset %d out of range", ErrSSZ, offsets[i])
+		}
+	}
+	offsets[len(offsetPositions)] = len(buf)
+
+	if offsets[0] != fixedSize {
+		return nil, fmt.Errorf("%w: first offset %d, expected %d", ErrSSZ, offsets[0], fixedSize)
+	}
+	fields := make([][]byte, len(offsetPositions))
+	for i := range fields {
+		if offsets[i] > offsets[i+1] {
+			return nil, fmt.Errorf("%w: offset %d out of order", ErrSSZ, offsets[i])
+		}
+		fields[i] = buf[offsets[i]:offsets[i+1]]
+	}
+	return fields, nil
+}
+
+// marshalSSZVariableFields encodes a container made only of variable size fields
+func marshalSSZVariableFields(fields ...[]byte) []byte {
+	offsets := sszOffsets(4*len(fields), fields...)
+	buf := make([]byte, 0, int(offsets[len(offsets)-1])+len(fields[len(fields)-1]))
+	for _, offset := range offsets {
+		buf = binary.LittleEndian.AppendUint32(buf, offset)
+	}
+	for _, field := range fields {
+		buf = append(buf, field...)
+	}
+	return buf
+}
+
+func sszOffsets(fixedSize int, fields ...[]byte) []uint32 {
+	offsets := make([]uint32, len(fields))
+	offset := fixedSize
+	for i, field := range fields {
+		offsets[i] = uint32(offset)
+		offset += len(field)
+	}
+	return offsets
+}
+
+// reverse returns a reversed copy, converting between big and little endian
+func reverse(b []byte) []byte {
+	reversed := make([]byte, len(b))
+	for i := range b {
+		reversed[len(b)-1-i] = b[i]
+	}
+	return reversed
+}
@@ -0,0 +1,236 @@
+package relay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	builderTypes "github.com/bsn-eng/pon-golang-types/builder"
+	commonTypes "github.com/bsn-eng/pon-golang-types/common"
+	rpbsTypes "github.com/bsn-eng/pon-golang-types/rpbs"
+)
+
+// testBuilderBlockBid is a capella submission with every field set
+func testBuilderBlockBid() *builderTypes.BuilderBlockBid {
+	header := &capella.ExecutionPayloadHeader{
+		ParentHash:       phase0.Hash32{0x01},
+		FeeRecipient:     [20]byte{0x02},
+		StateRoot:        [32]byte{0x03},
+		ReceiptsRoot:     [32]byte{0x04},
+		PrevRandao:       [32]byte{0x05},
+		BlockNumber:      17000000,
+		GasLimit:         30000000,
+		GasUsed:          12000000,
+		Timestamp:        1695200004,
+		ExtraData:        []byte("pon"),
+		BaseFeePerGas:    [32]byte{0x06},
+		BlockHash:        phase0.Hash32{0x07},
+		TransactionsRoot: phase0.Root{0x08},
+		WithdrawalsRoot:  phase0.Root{0x09},
+	}
+	value, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	return &builderTypes.BuilderBlockBid{
+		Signature: commonTypes.Signature{0xaa, 0xbb},
+		Message: &builderTypes.BidPayload{
+			Slot:                   6541234,
+			ParentHash:             commonTypes.Hash{0x01},
+			BlockHash:              commonTypes.Hash{0x07},
+			BuilderPubkey:          commonTypes.PublicKey{0x8a},
+			ProposerPubkey:         commonTypes.PublicKey{0x8b},
+			ProposerFeeRecipient:   commonTypes.Address{0x02},
+			GasLimit:               30000000,
+			GasUsed:                12000000,
+			Value:                  value,
+			ExecutionPayloadHeader: &commonTypes.VersionedExecutionPayloadHeader{Capella: header},
+			Endpoint:               "https://builder.example.com",
+			BuilderWalletAddress:   commonTypes.Address{0xbb},
+			PayoutPoolTransaction:  []byte{0x02, 0xf8, 0x70},
+			RPBS: &rpbsTypes.EncodedRPBSSignature{
+				Z1Hat: "z1", C1Hat: "c1", S1Hat: "s1", C2Hat: "c2", S2Hat: "s2", M1Hat: "m1",
+			},
+			RPBSPubkey: "rpbs-pubkey",
+		},
+		EcdsaSignature: commonTypes.EcdsaSignature{0x1c},
+	}
+}
+
+func TestBuilderBlockBidSSZRoundTrip(t *testing.T) {
+	bid := testBuilderBlockBid()
+	buf, err := MarshalBuilderBlockBidSSZ(bid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, version := range []string{"capella", "Capella", ""} {
+		decoded := new(builderTypes.BuilderBlockBid)
+		if err := UnmarshalBuilderBlockBidSSZ(buf, decoded, version); err != nil {
+			t.Fatalf("version %q: %v", version, err)
+		}
+		if !reflect.DeepEqual(decoded, bid) {
+			t.Fatalf("version %q: decoded %+v, want %+v", version, decoded.Message, bid.Message)
+		}
+	}
+
+	if err := UnmarshalBuilderBlockBidSSZ(buf, new(builderTypes.BuilderBlockBid), "deneb"); err == nil {
+		t.Fatal("capella header decoded as deneb")
+	}
+	if err := UnmarshalBuilderBlockBidSSZ(buf, new(builderTypes.BuilderBlockBid), "phase0"); err == nil {
+		t.Fatal("decoded for an unsupported version")
+	}
+
+	// values that don't fit uint256 can't be encoded
+	bid.Message.Value = new(big.Int).Lsh(big.NewInt(1), 256)
+	if _, err := MarshalBuilderBlockBidSSZ(bid); !errors.Is(err, ErrSSZ) {
+		t.Fatalf("error %v for a value over uint256, want %v", err, ErrSSZ)
+	}
+}
+
+func submissionRequest(t *testing.T, body []byte, contentType string, gzipped bool) *http.Request {
+	t.Helper()
+	if gzipped {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		writer.Write(body)
+		writer.Close()
+		body = compressed.Bytes()
+	}
+	req := httptest.NewRequest(http.MethodPost, "/relay/v1/builder/blocks", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	return req
+}
+
+func TestDecodeSubmission(t *testing.T) {
+	bid := testBuilderBlockBid()
+	wantRoot, err := bid.Message.HashTreeRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sszBody, err := MarshalBuilderBlockBidSSZ(bid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonBody, err := json.Marshal(bid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		gzipped     bool
+	}{
+		{"ssz", sszBody, mediaTypeSSZ, false},
+		{"gzip ssz", sszBody, mediaTypeSSZ, true},
+		{"json", jsonBody, mediaTypeJSON, false},
+		{"gzip json", jsonBody, mediaTypeJSON + "; charset=utf-8", true},
+	}
+	relay := &Relay{}
+	for _, test := range tests {
+		decoded, root, err := relay.decodeSubmission(submissionRequest(t, test.body, test.contentType, test.gzipped))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if root != wantRoot {
+			t.Fatalf("%s: message root %x, want %x", test.name, root, wantRoot)
+		}
+		if decoded.Message.Slot != bid.Message.Slot || decoded.Message.Value.Cmp(bid.Message.Value) != 0 || decoded.EcdsaSignature != bid.EcdsaSignature {
+			t.Fatalf("%s: decoded %+v", test.name, decoded.Message)
+		}
+	}
+}
+
+func TestDecodeSubmissionRejects(t *testing.T) {
+	sszBody, err := MarshalBuilderBlockBidSSZ(testBuilderBlockBid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// gzip bodies are limited after inflating them
+	relay := &Relay{limits: SubmissionLimits{MaxBodySize: int64(len(sszBody) - 1)}}
+	_, _, err = relay.decodeSubmission(submissionRequest(t, sszBody, mediaTypeSSZ, true))
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		t.Fatalf("error %v for an inflated body over the limit, want %T", err, maxBytesErr)
+	}
+
+	relay = &Relay{}
+	req := submissionRequest(t, sszBody, mediaTypeSSZ, false)
+	req.Header.Set("Content-Encoding", "gzip")
+	if _, _, err := relay.decodeSubmission(req); err == nil {
+		t.Fatal("body claiming gzip decoded")
+	}
+
+	for _, body := range []string{`{}`, `{"message":{"slot":"1"}}`} {
+		if _, _, err := relay.decodeSubmission(submissionRequest(t, []byte(body), mediaTypeJSON, false)); !errors.Is(err, ErrSubmission) {
+			t.Fatalf("error %v for %s, want %v", err, body, ErrSubmission)
+		}
+	}
+}
+
+func TestUnmarshalBuilderBlockBidSSZMalformed(t *testing.T) {
+	buf, err := MarshalBuilderBlockBidSSZ(testBuilderBlockBid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decode := func(buf []byte) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				t.Fatalf("decoding panicked: %v", recovered)
+			}
+		}()
+		return UnmarshalBuilderBlockBidSSZ(buf, new(builderTypes.BuilderBlockBid), "")
+	}
+
+	// every truncation before the last field fails, cutting into the last
+	// field only shortens the rpbs pubkey
+	message := builderBlockBidFixedSize
+	rpbsPubkey := message + int(binary.LittleEndian.Uint32(buf[message+272:]))
+	for size := 0; size < rpbsPubkey; size++ {
+		if decode(buf[:size]) == nil {
+			t.Fatalf("decoded %d of %d bytes", size, len(buf))
+		}
+	}
+
+	// every offset of the submission, the bid payload and the rpbs signature
+	// pointing anywhere else fails
+	rpbs := message + int(binary.LittleEndian.Uint32(buf[message+268:]))
+	positions := []int{96}
+	for _, position := range []int{236, 240, 264, 268, 272} {
+		positions = append(positions, message+position)
+	}
+	for position := 0; position < rpbsSignatureFixedSize; position += 4 {
+		positions = append(positions, rpbs+position)
+	}
+	for _, position := range positions {
+		for _, offset := range []uint32{0, 1, uint32(len(buf)), uint32(len(buf)) + 1, 0xffffffff} {
+			malformed := append([]byte{}, buf...)
+			binary.LittleEndian.PutUint32(malformed[position:], offset)
+			if err := decode(malformed); err == nil {
+				t.Fatalf("decoded offset %d at %d", offset, position)
+			}
+		}
+	}
+
+	// offsets out of order
+	malformed := append([]byte{}, buf...)
+	first, second := malformed[message+240:message+244], malformed[message+264:message+268]
+	binary.LittleEndian.PutUint32(first, binary.LittleEndian.Uint32(second)+1)
+	if err := decode(malformed); !errors.Is(err, ErrSSZ) {
+		t.Fatalf("error %v for offsets out of order, want %v", err, ErrSSZ)
+	}
+}
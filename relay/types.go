@@ -1,23 +1,29 @@
 package relay
 
 import (
+	"context"
 	"encoding/json"
 	"math/big"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	bulletinBoardTypes "github.com/bsn-eng/pon-golang-types/bulletinBoard"
 	databaseTypes "github.com/bsn-eng/pon-golang-types/database"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/sirupsen/logrus"
 
 	beaconclient "github.com/pon-pbs/bbRelay/beaconinterface"
 	bidBoard "github.com/pon-pbs/bbRelay/bids"
-	"github.com/pon-pbs/bbRelay/bls"
+	"github.com/pon-pbs/bbRelay/builderclient"
 	"github.com/pon-pbs/bbRelay/bulletinboard"
 	"github.com/pon-pbs/bbRelay/database"
+	"github.com/pon-pbs/bbRelay/executionclient"
 	ponpool "github.com/pon-pbs/bbRelay/ponPool"
+	"github.com/pon-pbs/bbRelay/redisPackage"
 	"github.com/pon-pbs/bbRelay/reporter"
 	"github.com/pon-pbs/bbRelay/signing"
 	"github.com/pon-pbs/bbRelay/utils"
@@ -50,19 +56,33 @@ func (t Transaction) String() string {
 type Relay struct {
 	db             *database.DatabaseInterface
 	ponPool        *ponpool.PonRegistrySubgraph
-	bulletinBoard  *bulletinboard.RelayMQTT
+	bulletinBoard  *bulletinboard.BulletinBoard
 	beaconClient   *beaconclient.MultiBeaconClient
 	bidBoard       *bidBoard.BidBoard
 	URL            string
-	blsSk          *bls.SecretKey
+	keys           *signing.KeySet
 	log            *logrus.Entry
 	reporterServer *reporter.ReporterServer
 	network        EthNetwork
-	publicKey      phase0.BLSPubKey
 	client         *http.Client
 	server         *http.Server
 	relayutils     *utils.RelayUtils
 	version        string
+	redis          *redisPackage.RedisInterface
+	health         HealthParams
+	admin          AdminParams
+	limits         SubmissionLimits
+	inflight       chan struct{}
+	builderClient  *builderclient.Client
+	payloadTimeout time.Duration
+	payout         PayoutParams
+	execution      *executionclient.Client
+	adminServer    *http.Server
+	pauses         slotPauses
+	loggers        map[string][]*logrus.Logger
+
+	stopBulletinBoard context.CancelFunc
+	shuttingDown      atomic.Bool
 }
 
 type RelayParams struct {
@@ -79,6 +99,7 @@ type RelayParams struct {
 	BulletinBoardParams bulletinBoardTypes.RelayMQTTOpts
 
 	BeaconClientUrls []string
+	BeaconNode       beaconclient.NodeOptions
 
 	ReporterURL string
 
@@ -88,11 +109,57 @@ type RelayParams struct {
 
 	BidTimeOut time.Duration
 
-	Sk *bls.SecretKey
+	Keys *signing.KeySet
 
 	Version string
 
 	DiscordWebhook string
+
+	Health HealthParams
+
+	Admin AdminParams
+
+	BuilderAllowlist        bool
+	RequireBuilderEndpoints bool
+
+	Limits SubmissionLimits
+
+	BuilderClient  builderclient.Params
+	PayloadTimeout time.Duration
+
+	Payout PayoutParams
+
+	Execution executionclient.Params
+
+	BulletinBoard bulletinboard.Options
+}
+
+// PayoutParams configures payout transaction verification, a zero pool
+// address disables it
+type PayoutParams struct {
+	PoolAddress common.Address
+	ChainID     uint64
+}
+
+// SubmissionLimits protects the submission endpoints, zero values disable a limit
+type SubmissionLimits struct {
+	MaxBodySize       int64
+	MaxInflight       uint64
+	IPRate            uint64
+	IPBurst           uint64
+	BuilderRate       uint64
+	BuilderBurst      uint64
+	TrustForwardedFor bool
+}
+
+type AdminParams struct {
+	URL   string
+	Token string
+}
+
+type HealthParams struct {
+	MaxHeadSlotLag    uint64
+	MaxPonPoolSyncAge time.Duration
 }
 
 type EthNetwork struct {
@@ -107,6 +174,7 @@ type RelayServerParams struct {
 	ReadHeaderTimeout time.Duration
 	WriteTimeout      time.Duration
 	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
 }
 
 type ProposerReqParams struct {
@@ -122,9 +190,96 @@ type BuilderWinningBid struct {
 }
 
 type RelayConfig struct {
-	MQTTBroker string `json:"mqtt_broker"`
-	MQTTPort   uint16 `json:"mqtt_port"`
-	PublicKey  string `json:"public_key"`
-	Chain      uint64 `json:"chain"`
-	Slot       uint64 `json:"current_slot"`
+	MQTTBroker     string   `json:"mqtt_broker"`
+	MQTTPort       uint16   `json:"mqtt_port"`
+	PublicKey      string   `json:"public_key"`
+	PublicKeys     []string `json:"public_keys"`
+	NextPublicKey  string   `json:"next_public_key,omitempty"`
+	KeyCutoverSlot uint64   `json:"key_cutover_slot,omitempty"`
+	Chain          uint64   `json:"chain"`
+	Slot           uint64   `json:"current_slot"`
+
+	BulletinBoardDomain string `json:"bulletin_board_domain"`
+	BulletinBoardStream string `json:"bulletin_board_stream,omitempty"`
+}
+
+// bulletinBoardStreamPath serves the bulletin board over WebSocket and SSE
+// when the fanout publisher is enabled
+const bulletinBoardStreamPath = "/relay/v1/bulletin_board/stream"
+
+const (
+	HealthStatusOK           = "ok"
+	HealthStatusDegraded     = "degraded"
+	HealthStatusUnavailable  = "unavailable"
+	HealthStatusShuttingDown = "shutting_down"
+)
+
+type DependencyStatus struct {
+	Healthy  bool   `json:"healthy"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+	Detail   any    `json:"detail,omitempty"`
+}
+
+type HealthReport struct {
+	Ready  bool                        `json:"ready"`
+	Status string                      `json:"status"`
+	Checks map[string]DependencyStatus `json:"checks"`
+}
+
+type SlotPause struct {
+	ID       uint64 `json:"id"`
+	FromSlot uint64 `json:"from_slot"`
+	ToSlot   uint64 `json:"to_slot"`
+	Bids     bool   `json:"bids"`
+	Headers  bool   `json:"headers"`
+	Reason   string `json:"reason"`
+}
+
+type slotPauses struct {
+	mu     sync.RWMutex
+	nextID uint64
+	pauses []SlotPause
+}
+
+type AdminBuilders struct {
+	Builders  map[string]bool          `json:"builders"`
+	Policies  []database.BuilderPolicy `json:"policies"`
+	Allowlist bool                     `json:"allowlist"`
+}
+
+type AdminBuilderPolicyRequest struct {
+	Policy   string `json:"policy"`
+	Reason   string `json:"reason"`
+	Duration string `json:"duration"`
+	Actor    string `json:"actor"`
+}
+
+type AdminLogLevelRequest struct {
+	Logger string `json:"logger"`
+	Level  string `json:"level"`
+}
+
+type AdminCutoverRequest struct {
+	PublicKey string `json:"public_key"`
+	Slot      uint64 `json:"slot"`
+}
+
+// BuilderEndpointsRequest registers the endpoints a builder serves payloads
+// from, signed by the builder wallet with personal_sign over
+// BuilderEndpointsMessage.SigningText()
+type BuilderEndpointsRequest struct {
+	Message   BuilderEndpointsMessage `json:"message"`
+	Signature hexutil.Bytes           `json:"signature"`
+}
+
+type BuilderEndpointsMessage struct {
+	BuilderWalletAddress common.Address `json:"builder_wallet_address"`
+	Endpoints            []string       `json:"endpoints"`
+	Timestamp            uint64         `json:"timestamp,string"`
+}
+
+type BuilderEndpointsResponse struct {
+	Registration *database.BuilderEndpoints      `json:"registration"`
+	Health       map[string]utils.EndpointHealth `json:"health"`
 }
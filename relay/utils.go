@@ -9,13 +9,11 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/attestantio/go-eth2-client/spec/phase0"
 	builderTypes "github.com/bsn-eng/pon-golang-types/builder"
 	relayTypes "github.com/bsn-eng/pon-golang-types/relay"
 	"github.com/sirupsen/logrus"
 
 	beaconclient "github.com/pon-pbs/bbRelay/beaconinterface"
-	"github.com/pon-pbs/bbRelay/bls"
 	"github.com/pon-pbs/bbRelay/constants"
 	"github.com/pon-pbs/bbRelay/signing"
 )
@@ -79,15 +77,15 @@ func proposerParameters(payload map[string]string) (ProposerReqParams, error) {
 	return ProposerReqParams{Slot: uint64(slot), ProposerPubKeyHex: proposerPubkey, ParentHashHex: parentHash}, nil
 }
 
-func SignedBuilderBid(builderBid builderTypes.BuilderBlockBid, sk *bls.SecretKey, publicKey phase0.BLSPubKey, domain signing.Domain) (*relayTypes.SignedBuilderBlockBid, error) {
+func SignedBuilderBid(ctx context.Context, builderBid builderTypes.BuilderBlockBid, signer signing.Signer, domain signing.Domain) (*relayTypes.SignedBuilderBlockBid, error) {
 
 	message := &relayTypes.BuilderBlockBid{
 		Value:                  builderBid.Message.Value,
-		Pubkey:                 publicKey,
+		Pubkey:                 signer.PublicKey(),
 		ExecutionPayloadHeader: builderBid.Message.ExecutionPayloadHeader,
 	}
 
-	sig, err := signing.SignMessage(message, domain, sk)
+	sig, err := signer.Sign(ctx, message, domain)
 	if err != nil {
 		return nil, err
 	}
@@ -98,9 +96,9 @@ func SignedBuilderBid(builderBid builderTypes.BuilderBlockBid, sk *bls.SecretKey
 	}, nil
 }
 
-func NewEthNetworkDetails(network string, beaconClient *beaconclient.MultiBeaconClient) (*EthNetwork, error) {
+func NewEthNetworkDetails(ctx context.Context, network string, beaconClient *beaconclient.MultiBeaconClient) (*EthNetwork, error) {
 
-	genesisNetwork, err := beaconClient.Genesis()
+	genesisNetwork, err := beaconClient.Genesis(ctx)
 	if err != nil {
 		return nil, err
 	}
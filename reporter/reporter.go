@@ -1,6 +1,7 @@
 package reporter
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
@@ -18,13 +19,19 @@ type ReporterServer struct {
 }
 
 func NewReporterServer(URL string, DB *database.DatabaseInterface) *ReporterServer {
-	return &ReporterServer{
+	reporter := &ReporterServer{
 		url: URL,
 		db:  *DB,
 		log: logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
 			"package": "Reporter API",
 			"URL":     URL,
 		})}
+
+	reporter.server = &http.Server{
+		Addr:    reporter.url,
+		Handler: reporter.Routes(),
+	}
+	return reporter
 }
 
 func (reporter *ReporterServer) Routes() http.Handler {
@@ -40,15 +47,14 @@ func (reporter *ReporterServer) Routes() http.Handler {
 
 func (reporter *ReporterServer) StartServer() (err error) {
 	reporter.log.Info("Reporter Server")
-	reporter.server = &http.Server{
-		Addr:    reporter.url,
-		Handler: reporter.Routes(),
-	}
-
 	err = reporter.server.ListenAndServe()
 	return err
 }
 
+func (reporter *ReporterServer) Shutdown(ctx context.Context) error {
+	return reporter.server.Shutdown(ctx)
+}
+
 func (reporter *ReporterServer) RespondError(w http.ResponseWriter, code int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -23,6 +23,9 @@ var (
 
 	DomainTypeBeaconProposer = DomainType{0x00, 0x00, 0x00, 0x00}
 	DomainTypeAppBuilder     = DomainType{0x00, 0x00, 0x00, 0x01}
+	// DomainTypeAppBulletinBoard signs bulletin board messages, keeping them
+	// from ever being valid as builder API messages
+	DomainTypeAppBulletinBoard = DomainType{0x50, 0x4f, 0x4e, 0x01}
 )
 
 type SigningData struct {
@@ -0,0 +1,122 @@
+package signing
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+var (
+	ErrUnknownKey       = errors.New("key is not announced in the key set")
+	ErrCutoverSlotPast  = errors.New("cutover slot must be in the future")
+	ErrNoCutoverPending = errors.New("no key cutover scheduled")
+)
+
+// KeySet holds the primary signer together with the announced next signers.
+// A cutover makes one of the next signers primary from a given slot onwards,
+// bids for earlier slots keep being signed with the current primary.
+type KeySet struct {
+	mu sync.RWMutex
+
+	primary Signer
+	next    []Signer
+
+	cutoverSigner Signer
+	cutoverSlot   uint64
+}
+
+func NewKeySet(primary Signer, next ...Signer) *KeySet {
+	return &KeySet{
+		primary: primary,
+		next:    next,
+	}
+}
+
+// SignerForSlot returns the signer that has to sign bids for the slot
+func (k *KeySet) SignerForSlot(slot uint64) Signer {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.cutoverSigner != nil && slot >= k.cutoverSlot {
+		return k.cutoverSigner
+	}
+	return k.primary
+}
+
+// ScheduleCutover makes the announced key primary from slot onwards. A cutover
+// that already happened before currentSlot is completed first.
+func (k *KeySet) ScheduleCutover(publicKey phase0.BLSPubKey, slot uint64, currentSlot uint64) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.completeCutover(currentSlot)
+
+	if slot <= currentSlot {
+		return fmt.Errorf("%w: slot %d, current slot %d", ErrCutoverSlotPast, slot, currentSlot)
+	}
+
+	for _, signer := range k.next {
+		if signer.PublicKey() == publicKey {
+			k.cutoverSigner = signer
+			k.cutoverSlot = slot
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownKey, publicKey.String())
+}
+
+// CancelCutover drops a scheduled cutover that hasn't happened yet
+func (k *KeySet) CancelCutover(currentSlot uint64) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.completeCutover(currentSlot)
+
+	if k.cutoverSigner == nil {
+		return ErrNoCutoverPending
+	}
+	k.cutoverSigner = nil
+	k.cutoverSlot = 0
+	return nil
+}
+
+// Keys returns the primary key for currentSlot, all announced keys and the
+// pending cutover if there is one
+func (k *KeySet) Keys(currentSlot uint64) (primary phase0.BLSPubKey, next []phase0.BLSPubKey, cutover *phase0.BLSPubKey, cutoverSlot uint64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.completeCutover(currentSlot)
+
+	for _, signer := range k.next {
+		next = append(next, signer.PublicKey())
+	}
+	if k.cutoverSigner != nil {
+		cutoverKey := k.cutoverSigner.PublicKey()
+		cutover = &cutoverKey
+		cutoverSlot = k.cutoverSlot
+	}
+	return k.primary.PublicKey(), next, cutover, cutoverSlot
+}
+
+// completeCutover promotes the cutover signer once currentSlot reached the
+// cutover slot. The old primary is kept as a next key so it can be switched
+// back to.
+func (k *KeySet) completeCutover(currentSlot uint64) {
+	if k.cutoverSigner == nil || currentSlot < k.cutoverSlot {
+		return
+	}
+
+	next := []Signer{k.primary}
+	for _, signer := range k.next {
+		if signer != k.cutoverSigner {
+			next = append(next, signer)
+		}
+	}
+	k.primary = k.cutoverSigner
+	k.next = next
+	k.cutoverSigner = nil
+	k.cutoverSlot = 0
+}
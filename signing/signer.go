@@ -0,0 +1,188 @@
+package signing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	bls "github.com/pon-pbs/bbRelay/bls"
+)
+
+var (
+	ErrSignerTimeout         = errors.New("remote signer timed out")
+	ErrRemoteSignerPublicKey = errors.New("public key not available on remote signer")
+	ErrRemoteSignature       = errors.New("remote signer returned invalid signature")
+)
+
+// Signer signs relay messages, keeping the relay agnostic of where the key lives
+type Signer interface {
+	PublicKey() phase0.BLSPubKey
+	Sign(ctx context.Context, obj HashTreeRoot, d Domain) (phase0.BLSSignature, error)
+}
+
+// LocalSigner signs with a secret key held in memory
+type LocalSigner struct {
+	sk        *bls.SecretKey
+	publicKey phase0.BLSPubKey
+}
+
+func NewLocalSigner(sk *bls.SecretKey) (*LocalSigner, error) {
+	publicKey, err := bls.RelayBLSPubKey(*bls.PublicKeyFromSecretKey(sk))
+	if err != nil {
+		return nil, err
+	}
+	return &LocalSigner{sk: sk, publicKey: publicKey}, nil
+}
+
+func (s *LocalSigner) PublicKey() phase0.BLSPubKey {
+	return s.publicKey
+}
+
+func (s *LocalSigner) Sign(ctx context.Context, obj HashTreeRoot, d Domain) (phase0.BLSSignature, error) {
+	return SignMessage(obj, d, s.sk)
+}
+
+// RemoteSigner signs through an HTTP signer holding the key, posting
+// {"signingRoot": "0x.."} to /api/v1/eth2/sign/{pubkey}. The paths follow
+// Web3Signer but the request has no typed payload, relay messages aren't one
+// of its signing types, so the signer must sign bare signing roots. Every
+// signature returned is verified against the public key before it is used.
+type RemoteSigner struct {
+	url       string
+	publicKey phase0.BLSPubKey
+	timeout   time.Duration
+	client    *http.Client
+}
+
+type remoteSignRequest struct {
+	SigningRoot string `json:"signingRoot"`
+}
+
+type remoteSignResponse struct {
+	Signature string `json:"signature"`
+}
+
+// NewRemoteSigner checks the signer holds publicKeyHex. When publicKeyHex is
+// empty the signer must hold exactly one key, which is used.
+func NewRemoteSigner(ctx context.Context, url string, publicKeyHex string, timeout time.Duration) (*RemoteSigner, error) {
+	signer := &RemoteSigner{
+		url:     strings.TrimSuffix(url, "/"),
+		timeout: timeout,
+		client:  &http.Client{},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, signer.url+"/api/v1/eth2/publicKeys", nil)
+	if err != nil {
+		return nil, err
+	}
+	var publicKeys []string
+	if err := signer.do(req, &publicKeys); err != nil {
+		return nil, fmt.Errorf("could not list remote signer keys: %w", err)
+	}
+
+	if publicKeyHex == "" {
+		if len(publicKeys) != 1 {
+			return nil, fmt.Errorf("remote signer holds %d keys, specify which one to use", len(publicKeys))
+		}
+		publicKeyHex = publicKeys[0]
+	}
+
+	found := false
+	for _, key := range publicKeys {
+		if strings.EqualFold(key, publicKeyHex) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrRemoteSignerPublicKey, publicKeyHex)
+	}
+
+	publicKey, err := hexutil.Decode(publicKeyHex)
+	if err != nil || len(publicKey) != bls.BLSPublicKeyLength {
+		return nil, fmt.Errorf("invalid remote signer public key %s", publicKeyHex)
+	}
+	copy(signer.publicKey[:], publicKey)
+
+	return signer, nil
+}
+
+func (s *RemoteSigner) PublicKey() phase0.BLSPubKey {
+	return s.publicKey
+}
+
+func (s *RemoteSigner) Sign(ctx context.Context, obj HashTreeRoot, d Domain) (signature phase0.BLSSignature, err error) {
+	root, err := ComputeSigningRoot(obj, d)
+	if err != nil {
+		return signature, err
+	}
+
+	body, err := json.Marshal(remoteSignRequest{SigningRoot: hexutil.Encode(root[:])})
+	if err != nil {
+		return signature, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/api/v1/eth2/sign/"+s.publicKey.String(), bytes.NewReader(body))
+	if err != nil {
+		return signature, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	response := new(remoteSignResponse)
+	if err := s.do(req, response); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return signature, fmt.Errorf("%w after %s", ErrSignerTimeout, s.timeout)
+		}
+		return signature, err
+	}
+
+	signatureBytes, err := hexutil.Decode(response.Signature)
+	if err != nil {
+		return signature, fmt.Errorf("%w: %v", ErrRemoteSignature, err)
+	}
+	valid, err := bls.VerifySignatureBytes(root[:], signatureBytes, s.publicKey[:])
+	if err != nil || !valid {
+		return signature, ErrRemoteSignature
+	}
+	copy(signature[:], signatureBytes)
+
+	return signature, nil
+}
+
+// do sends the request and decodes the response, accepting both JSON and the
+// plain text signature some signers answer with
+func (s *RemoteSigner) do(req *http.Request, dst interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote signer responded %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if response, ok := dst.(*remoteSignResponse); ok && !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		response.Signature = strings.TrimSpace(string(body))
+		return nil
+	}
+	return json.Unmarshal(body, dst)
+}
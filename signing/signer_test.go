@@ -0,0 +1,222 @@
+package signing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	bls "github.com/pon-pbs/bbRelay/bls"
+)
+
+// stubSigner is a local remote signer server holding one key
+type stubSigner struct {
+	sk        *bls.SecretKey
+	publicKey string
+
+	// sign answers the signing root, the key's signature when nil
+	sign func(w http.ResponseWriter, r *http.Request, root []byte)
+}
+
+func newStubSigner(t *testing.T) (*stubSigner, *httptest.Server) {
+	t.Helper()
+	sk, pk, err := bls.GenerateNewKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &stubSigner{sk: sk, publicKey: hexutil.Encode(pk.Compress())}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/eth2/publicKeys":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode([]string{stub.publicKey})
+
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/eth2/sign/"+stub.publicKey:
+			request := new(remoteSignRequest)
+			if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			root, err := hexutil.Decode(request.SigningRoot)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if stub.sign != nil {
+				stub.sign(w, r, root)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(remoteSignResponse{Signature: hexutil.Encode(bls.Sign(stub.sk, root).Compress())})
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return stub, server
+}
+
+func testSignedObject() HashTreeRoot {
+	return &ForkData{CurrentVersion: ForkVersion{1, 2, 3, 4}}
+}
+
+func TestRemoteSignerPublicKeys(t *testing.T) {
+	stub, server := newStubSigner(t)
+	ctx := context.Background()
+
+	signer, err := NewRemoteSigner(ctx, server.URL, "", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.PublicKey().String() != stub.publicKey {
+		t.Fatalf("public key %s, want the only key %s", signer.PublicKey(), stub.publicKey)
+	}
+
+	if _, err := NewRemoteSigner(ctx, server.URL+"/", "0x"+strings.ToUpper(stub.publicKey[2:]), time.Second); err != nil {
+		t.Fatalf("public key lookup should ignore case: %v", err)
+	}
+
+	_, otherPk, err := bls.GenerateNewKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewRemoteSigner(ctx, server.URL, hexutil.Encode(otherPk.Compress()), time.Second)
+	if !errors.Is(err, ErrRemoteSignerPublicKey) {
+		t.Fatalf("error %v for a key the signer doesn't hold, want %v", err, ErrRemoteSignerPublicKey)
+	}
+}
+
+func TestRemoteSignerSign(t *testing.T) {
+	stub, server := newStubSigner(t)
+	signer, err := NewRemoteSigner(context.Background(), server.URL, stub.publicKey, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	domain := ComputeSSZDomain(DomainType{0, 0, 0, 1}, ForkVersion{}, Root{})
+	signature, err := signer.Sign(context.Background(), testSignedObject(), domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := signer.PublicKey()
+	valid, err := VerifySignature(testSignedObject(), domain, publicKey[:], signature[:])
+	if err != nil || !valid {
+		t.Fatalf("remote signature doesn't verify: %v", err)
+	}
+
+	// the signature of a local signer with the same key is the same
+	local, err := NewLocalSigner(stub.sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	localSignature, err := local.Sign(context.Background(), testSignedObject(), domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if localSignature != signature {
+		t.Fatal("remote and local signatures differ")
+	}
+}
+
+func TestRemoteSignerPlainTextSignature(t *testing.T) {
+	stub, server := newStubSigner(t)
+	stub.sign = func(w http.ResponseWriter, r *http.Request, root []byte) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(hexutil.Encode(bls.Sign(stub.sk, root).Compress()) + "\n"))
+	}
+	signer, err := NewRemoteSigner(context.Background(), server.URL, stub.publicKey, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := signer.Sign(context.Background(), testSignedObject(), Domain{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoteSignerInvalidSignature(t *testing.T) {
+	otherSk, _, err := bls.GenerateNewKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]func(w http.ResponseWriter, sk *bls.SecretKey, root []byte){
+		"other key": func(w http.ResponseWriter, sk *bls.SecretKey, root []byte) {
+			json.NewEncoder(w).Encode(remoteSignResponse{Signature: hexutil.Encode(bls.Sign(otherSk, root).Compress())})
+		},
+		"other root": func(w http.ResponseWriter, sk *bls.SecretKey, root []byte) {
+			root[0] ^= 0xff
+			json.NewEncoder(w).Encode(remoteSignResponse{Signature: hexutil.Encode(bls.Sign(sk, root).Compress())})
+		},
+		"not a signature": func(w http.ResponseWriter, sk *bls.SecretKey, root []byte) {
+			json.NewEncoder(w).Encode(remoteSignResponse{Signature: "0x1234"})
+		},
+		"not hex": func(w http.ResponseWriter, sk *bls.SecretKey, root []byte) {
+			json.NewEncoder(w).Encode(remoteSignResponse{Signature: "signature"})
+		},
+	}
+
+	for name, answer := range tests {
+		t.Run(name, func(t *testing.T) {
+			stub, server := newStubSigner(t)
+			stub.sign = func(w http.ResponseWriter, r *http.Request, root []byte) {
+				w.Header().Set("Content-Type", "application/json")
+				answer(w, stub.sk, root)
+			}
+			signer, err := NewRemoteSigner(context.Background(), server.URL, stub.publicKey, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = signer.Sign(context.Background(), testSignedObject(), Domain{})
+			if !errors.Is(err, ErrRemoteSignature) {
+				t.Fatalf("error %v, want %v", err, ErrRemoteSignature)
+			}
+		})
+	}
+}
+
+func TestRemoteSignerTimeout(t *testing.T) {
+	stub, server := newStubSigner(t)
+	stub.sign = func(w http.ResponseWriter, r *http.Request, root []byte) {
+		<-r.Context().Done()
+	}
+	signer, err := NewRemoteSigner(context.Background(), server.URL, stub.publicKey, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err = signer.Sign(context.Background(), testSignedObject(), Domain{})
+	if !errors.Is(err, ErrSignerTimeout) {
+		t.Fatalf("error %v, want %v", err, ErrSignerTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("hung signer answered after %s", elapsed)
+	}
+}
+
+func TestRemoteSignerError(t *testing.T) {
+	stub, server := newStubSigner(t)
+	stub.sign = func(w http.ResponseWriter, r *http.Request, root []byte) {
+		http.Error(w, "slashing protection", http.StatusPreconditionFailed)
+	}
+	signer, err := NewRemoteSigner(context.Background(), server.URL, stub.publicKey, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = signer.Sign(context.Background(), testSignedObject(), Domain{})
+	if err == nil || !strings.Contains(err.Error(), "412") {
+		t.Fatalf("error %v, want the signer's status", err)
+	}
+}
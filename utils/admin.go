@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Builders returns the builder status last synced from the PON pool
+func (relay *RelayUtils) Builders() map[string]bool {
+	relay.builderUtils.Mu.Lock()
+	defer relay.builderUtils.Mu.Unlock()
+
+	builders := make(map[string]bool, len(relay.builderUtils.BuilderLast))
+	for builder, status := range relay.builderUtils.BuilderLast {
+		builders[builder] = status
+	}
+	return builders
+}
+
+// Resync fetches validators, builders and reporters from the PON pool now
+// instead of waiting for the next epoch
+func (relay *RelayUtils) Resync() {
+	relay.proposerUtils.GetValidators(*relay.ponPool, *relay.db)
+	relay.builderUtils.GetBuilders(*relay.ponPool, *relay.db)
+	relay.reporterUtils.GetReporters(*relay.ponPool, *relay.db)
+}
+
+func (relay *RelayUtils) Loggers() []*logrus.Logger {
+	return []*logrus.Logger{
+		relay.proposerUtils.Log.Logger,
+		relay.builderUtils.Log.Logger,
+		relay.reporterUtils.Log.Logger,
+	}
+}
@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/pon-pbs/bbRelay/builderclient"
+	"github.com/pon-pbs/bbRelay/database"
+)
+
+// unhealthyAfterFailures consecutive failed probes make an endpoint unhealthy
+const unhealthyAfterFailures = 2
+
+func (health EndpointHealth) Healthy() bool {
+	return health.Failures < unhealthyAfterFailures
+}
+
+// LoadBuilderEndpoints replaces the cached endpoint registrations with the
+// ones stored in the database, keeping cached ones that are newer
+func (relay *RelayUtils) LoadBuilderEndpoints(ctx context.Context) error {
+	registrations, err := relay.db.GetBuilderEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	cache := make(map[string]database.BuilderEndpoints, len(registrations))
+	for _, registration := range registrations {
+		cache[strings.ToLower(registration.BuilderWalletAddress)] = registration
+	}
+
+	relay.endpoints.Mu.Lock()
+	for builder, registration := range relay.endpoints.Registrations {
+		if stored, ok := cache[builder]; !ok || stored.Timestamp < registration.Timestamp {
+			cache[builder] = registration
+		}
+	}
+	relay.endpoints.Registrations = cache
+	relay.endpoints.Mu.Unlock()
+	return nil
+}
+
+// RegisterBuilderEndpoints stores a registration whose signature was already
+// verified and probes its endpoints straight away
+func (relay *RelayUtils) RegisterBuilderEndpoints(ctx context.Context, registration database.BuilderEndpoints) error {
+	builder := strings.ToLower(registration.BuilderWalletAddress)
+
+	relay.endpoints.Mu.RLock()
+	existing, ok := relay.endpoints.Registrations[builder]
+	relay.endpoints.Mu.RUnlock()
+	if ok && registration.Timestamp <= existing.Timestamp {
+		return fmt.Errorf("%w: timestamp %d, registered %d", ErrStaleEndpointRegistration, registration.Timestamp, existing.Timestamp)
+	}
+
+	registration.BuilderWalletAddress = builder
+	registration.UpdatedAt = time.Now().UTC()
+	err := relay.db.PutBuilderEndpoints(ctx, registration)
+	if errors.Is(err, sql.ErrNoRows) {
+		// another relay instance stored a newer registration
+		return fmt.Errorf("%w: timestamp %d", ErrStaleEndpointRegistration, registration.Timestamp)
+	} else if err != nil {
+		return err
+	}
+
+	relay.cacheRegistration(registration)
+	relay.announceRegistration(ctx, registration)
+	relay.probeEndpoints(ctx, builder, registration.Endpoints)
+	return nil
+}
+
+// cacheRegistration caches the registration unless a newer one is cached,
+// reporting whether it was
+func (relay *RelayUtils) cacheRegistration(registration database.BuilderEndpoints) bool {
+	builder := strings.ToLower(registration.BuilderWalletAddress)
+	relay.endpoints.Mu.Lock()
+	defer relay.endpoints.Mu.Unlock()
+
+	if existing, ok := relay.endpoints.Registrations[builder]; ok && registration.Timestamp <= existing.Timestamp {
+		return false
+	}
+	relay.endpoints.Registrations[builder] = registration
+	return true
+}
+
+// announceRegistration shares a stored registration with the other relay
+// instances. Instances missing it pick it up with the epoch reload.
+func (relay *RelayUtils) announceRegistration(ctx context.Context, registration database.BuilderEndpoints) {
+	announcement, err := json.Marshal(registration)
+	if err == nil {
+		err = relay.builderUtils.RedisInterface.Client.Publish(ctx, channelBuilderEndpoints, announcement).Err()
+	}
+	if err != nil {
+		relay.builderUtils.Log.WithError(err).WithField("builder", registration.BuilderWalletAddress).Warn("failed to announce builder endpoints to relay instances")
+	}
+}
+
+// RegistrationUpdate caches and probes the endpoints registered through any
+// relay instance, so bids of a newly registered builder are accepted everywhere
+func (relay *RelayUtils) RegistrationUpdate(ctx context.Context) {
+	pubsub := relay.builderUtils.RedisInterface.Client.Subscribe(ctx, channelBuilderEndpoints)
+	defer pubsub.Close()
+
+	announcements := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case announcement, ok := <-announcements:
+			if !ok {
+				return
+			}
+			var registration database.BuilderEndpoints
+			if err := json.Unmarshal([]byte(announcement.Payload), &registration); err != nil {
+				relay.builderUtils.Log.WithError(err).Warn("invalid builder endpoints announcement")
+				continue
+			}
+			// registrations of this instance are cached already
+			if relay.cacheRegistration(registration) {
+				relay.probeEndpoints(ctx, registration.BuilderWalletAddress, registration.Endpoints)
+			}
+		}
+	}
+}
+
+// BuilderEndpoints returns the builder's registration and the health of its endpoints
+func (relay *RelayUtils) BuilderEndpoints(builder string) (*database.BuilderEndpoints, map[string]EndpointHealth) {
+	relay.endpoints.Mu.RLock()
+	defer relay.endpoints.Mu.RUnlock()
+
+	registration, ok := relay.endpoints.Registrations[strings.ToLower(builder)]
+	if !ok {
+		return nil, nil
+	}
+	health := make(map[string]EndpointHealth, len(registration.Endpoints))
+	for _, endpoint := range registration.Endpoints {
+		health[endpoint] = relay.endpoints.Health[endpoint]
+	}
+	return &registration, health
+}
+
+// EndpointRejection returns why bids declaring these endpoints must be
+// rejected, empty if they are fine. Every endpoint has to be registered by
+// the builder and at least one of them has to be healthy.
+func (relay *RelayUtils) EndpointRejection(builder string, declared string) string {
+	if !relay.endpoints.Required {
+		return ""
+	}
+
+	relay.endpoints.Mu.RLock()
+	defer relay.endpoints.Mu.RUnlock()
+
+	registration, ok := relay.endpoints.Registrations[strings.ToLower(builder)]
+	if !ok {
+		return "Builder Has No Registered Endpoints"
+	}
+
+	endpoints := builderclient.Endpoints(declared)
+	if len(endpoints) == 0 {
+		return "Bid Has No Endpoint"
+	}
+	healthy := false
+	for _, endpoint := range endpoints {
+		if !contains(registration.Endpoints, endpoint) {
+			return fmt.Sprintf("Endpoint %s Not Registered", endpoint)
+		}
+		healthy = healthy || relay.endpoints.Health[endpoint].Healthy()
+	}
+	if !healthy {
+		return "No Healthy Builder Endpoint"
+	}
+	return ""
+}
+
+func (relay *RelayUtils) EndpointUpdate(ctx context.Context) {
+	for {
+		if err := relay.LoadBuilderEndpoints(ctx); err != nil {
+			relay.builderUtils.Log.WithError(err).Error("failed to load builder endpoints")
+		}
+		relay.ProbeBuilderEndpoints(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(EpochDuration):
+		}
+	}
+}
+
+// ProbeBuilderEndpoints probes every registered endpoint
+func (relay *RelayUtils) ProbeBuilderEndpoints(ctx context.Context) {
+	relay.endpoints.Mu.RLock()
+	registrations := make([]database.BuilderEndpoints, 0, len(relay.endpoints.Registrations))
+	for _, registration := range relay.endpoints.Registrations {
+		registrations = append(registrations, registration)
+	}
+	relay.endpoints.Mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, registration := range registrations {
+		wg.Add(1)
+		go func(registration database.BuilderEndpoints) {
+			defer wg.Done()
+			relay.probeEndpoints(ctx, registration.BuilderWalletAddress, registration.Endpoints)
+		}(registration)
+	}
+	wg.Wait()
+}
+
+func (relay *RelayUtils) probeEndpoints(ctx context.Context, builder string, endpoints []string) {
+	for _, endpoint := range endpoints {
+		err := relay.builderClient.Probe(ctx, builder, endpoint)
+
+		relay.endpoints.Mu.Lock()
+		health := relay.endpoints.Health[endpoint]
+		health.LastProbe = time.Now().UTC()
+		if err != nil {
+			health.Failures++
+			health.LastError = err.Error()
+		} else {
+			health.Failures = 0
+			health.LastError = ""
+		}
+		relay.endpoints.Health[endpoint] = health
+		relay.endpoints.Mu.Unlock()
+
+		if err != nil {
+			relay.builderUtils.Log.WithError(err).WithFields(logrus.Fields{
+				"builder":  builder,
+				"endpoint": endpoint,
+				"healthy":  health.Healthy(),
+			}).Warn("Builder Endpoint Probe Failed")
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
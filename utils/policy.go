@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pon-pbs/bbRelay/database"
+)
+
+// LoadBuilderPolicies replaces the cached builder policies with the ones
+// stored in the database
+func (relay *RelayUtils) LoadBuilderPolicies(ctx context.Context) error {
+	relay.policies.loadMu.Lock()
+	defer relay.policies.loadMu.Unlock()
+
+	policies, err := relay.db.GetBuilderPolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	cache := make(map[string]database.BuilderPolicy, len(policies))
+	for _, policy := range policies {
+		cache[strings.ToLower(policy.BuilderPubkey)] = policy
+	}
+
+	relay.policies.Mu.Lock()
+	relay.policies.Policies = cache
+	relay.policies.Mu.Unlock()
+	return nil
+}
+
+// SetBuilderPolicy stores the policy, recording who set it, and applies it
+// immediately
+func (relay *RelayUtils) SetBuilderPolicy(ctx context.Context, policy database.BuilderPolicy) error {
+	if policy.Policy != database.BuilderPolicyDeny && policy.Policy != database.BuilderPolicyAllow {
+		return fmt.Errorf("%w: %s", ErrInvalidBuilderPolicy, policy.Policy)
+	}
+	policy.BuilderPubkey = strings.ToLower(policy.BuilderPubkey)
+	policy.UpdatedAt = time.Now().UTC()
+
+	if err := relay.db.PutBuilderPolicy(ctx, policy); err != nil {
+		return err
+	}
+
+	relay.policies.Mu.Lock()
+	relay.policies.Policies[policy.BuilderPubkey] = policy
+	relay.policies.Mu.Unlock()
+	relay.notifyPolicyChange(ctx, policy.BuilderPubkey)
+	return nil
+}
+
+func (relay *RelayUtils) RemoveBuilderPolicy(ctx context.Context, builder string, reason string, actor string) error {
+	builder = strings.ToLower(builder)
+	if err := relay.db.DeleteBuilderPolicy(ctx, builder, reason, actor); err != nil {
+		return err
+	}
+
+	relay.policies.Mu.Lock()
+	delete(relay.policies.Policies, builder)
+	relay.policies.Mu.Unlock()
+	relay.notifyPolicyChange(ctx, builder)
+	return nil
+}
+
+// notifyPolicyChange makes the other relay instances reload the policies. The
+// change is already stored, instances missing the notification pick it up
+// with the epoch reload.
+func (relay *RelayUtils) notifyPolicyChange(ctx context.Context, builder string) {
+	if err := relay.builderUtils.RedisInterface.Client.Publish(ctx, channelBuilderPolicies, builder).Err(); err != nil {
+		relay.builderUtils.Log.WithError(err).WithField("builder", builder).Warn("failed to notify relay instances of builder policy change")
+	}
+}
+
+// PolicyUpdate reloads the builder policies whenever any relay instance
+// changes one, so a deny set through one admin API applies on every instance
+func (relay *RelayUtils) PolicyUpdate(ctx context.Context) {
+	pubsub := relay.builderUtils.RedisInterface.Client.Subscribe(ctx, channelBuilderPolicies)
+	defer pubsub.Close()
+
+	updates := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			if err := relay.LoadBuilderPolicies(ctx); err != nil {
+				relay.builderUtils.Log.WithError(err).WithField("builder", update.Payload).Error("failed to reload builder policies")
+			}
+		}
+	}
+}
+
+func (relay *RelayUtils) BuilderPolicies() []database.BuilderPolicy {
+	relay.policies.Mu.RLock()
+	defer relay.policies.Mu.RUnlock()
+
+	policies := make([]database.BuilderPolicy, 0, len(relay.policies.Policies))
+	for _, policy := range relay.policies.Policies {
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+func (relay *RelayUtils) BuilderPolicyAudit(ctx context.Context, builder string) ([]database.BuilderPolicyAudit, error) {
+	return relay.db.GetBuilderPolicyAudit(ctx, strings.ToLower(builder))
+}
+
+// builderPolicy returns the unexpired policy of the builder, if any
+func (relay *RelayUtils) builderPolicy(builder string) *database.BuilderPolicy {
+	relay.policies.Mu.RLock()
+	defer relay.policies.Mu.RUnlock()
+
+	policy, ok := relay.policies.Policies[strings.ToLower(builder)]
+	if !ok || (policy.ExpiresAt != nil && !policy.ExpiresAt.After(time.Now())) {
+		return nil
+	}
+	return &policy
+}
+
+// BuilderAllowlist reports whether only builders with an allow policy are accepted
+func (relay *RelayUtils) BuilderAllowlist() bool {
+	return relay.policies.Allowlist
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	beaconclient "github.com/pon-pbs/bbRelay/beaconinterface"
+	"github.com/pon-pbs/bbRelay/builderclient"
 	"github.com/pon-pbs/bbRelay/database"
 	ponpool "github.com/pon-pbs/bbRelay/ponPool"
 	"github.com/pon-pbs/bbRelay/redisPackage"
@@ -31,10 +32,15 @@ type RelayUtils struct {
 	builderUtils  *BuilderUtils
 	reporterUtils *ReporterUtils
 
+	policies  *BuilderPolicies
+	endpoints *BuilderEndpointRegistry
+
+	builderClient *builderclient.Client
+
 	Discord *DiscordConfig
 }
 
-func NewRelayUtils(db *database.DatabaseInterface, beaconClient *beaconclient.MultiBeaconClient, ponPool ponpool.PonRegistrySubgraph, redisInterface redisPackage.RedisInterface, discordWebhook string) *RelayUtils {
+func NewRelayUtils(db *database.DatabaseInterface, beaconClient *beaconclient.MultiBeaconClient, ponPool ponpool.PonRegistrySubgraph, redisInterface redisPackage.RedisInterface, discordWebhook string, builderAllowlist bool, builderClient *builderclient.Client, requireBuilderEndpoints bool) *RelayUtils {
 	proposerutils := &ProposerUtils{
 		ProposerStatus: ProposerUpdates{
 			Mu:             sync.Mutex{},
@@ -89,36 +95,64 @@ func NewRelayUtils(db *database.DatabaseInterface, beaconClient *beaconclient.Mu
 		proposerUtils: proposerutils,
 		builderUtils:  builderutils,
 		reporterUtils: reporterutils,
+		policies: &BuilderPolicies{
+			Policies:  make(map[string]database.BuilderPolicy),
+			Allowlist: builderAllowlist,
+		},
+		endpoints: &BuilderEndpointRegistry{
+			Registrations: make(map[string]database.BuilderEndpoints),
+			Health:        make(map[string]EndpointHealth),
+			Required:      requireBuilderEndpoints,
+		},
+		builderClient: builderClient,
 		Discord:       &discordInterface,
 	}
 }
 
-func (relayUtils *RelayUtils) StartUtils() (err error) {
+func (relayUtils *RelayUtils) StartUtils(ctx context.Context) (err error) {
 
-	go relayUtils.ProposerUpdate()
-	go relayUtils.BuilderUpdate()
-	go relayUtils.ReporterUpdate()
+	go relayUtils.ProposerUpdate(ctx)
+	go relayUtils.BuilderUpdate(ctx)
+	go relayUtils.PolicyUpdate(ctx)
+	go relayUtils.ReporterUpdate(ctx)
+	go relayUtils.EndpointUpdate(ctx)
+	go relayUtils.RegistrationUpdate(ctx)
 
 	return nil
 }
 
-func (relay *RelayUtils) ProposerUpdate() {
+func (relay *RelayUtils) ProposerUpdate(ctx context.Context) {
 	for {
 		relay.proposerUtils.GetValidators(*relay.ponPool, *relay.db)
-		time.Sleep(EpochDuration)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(EpochDuration):
+		}
 	}
 }
-func (relay *RelayUtils) BuilderUpdate() {
+func (relay *RelayUtils) BuilderUpdate(ctx context.Context) {
 	for {
 		relay.builderUtils.GetBuilders(*relay.ponPool, *relay.db)
-		time.Sleep(EpochDuration)
+		if err := relay.LoadBuilderPolicies(ctx); err != nil {
+			relay.builderUtils.Log.WithError(err).Error("failed to load builder policies")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(EpochDuration):
+		}
 	}
 }
 
-func (relay *RelayUtils) ReporterUpdate() {
+func (relay *RelayUtils) ReporterUpdate(ctx context.Context) {
 	for {
 		relay.reporterUtils.GetReporters(*relay.ponPool, *relay.db)
-		time.Sleep(EpochDuration)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(EpochDuration):
+		}
 	}
 }
 
@@ -131,6 +165,7 @@ func (proposer *ProposerUtils) GetValidators(ponPool ponpool.PonRegistrySubgraph
 
 	proposer.ProposerStatus.Mu.Lock()
 	defer proposer.ProposerStatus.Mu.Unlock()
+	proposer.ProposerStatus.LastSync = time.Now()
 
 	newProposers := []string{}
 	proposer.Log.Infof("Updating %d Validators in Redis...", len(validators))
@@ -167,7 +202,7 @@ func (proposer *ProposerUtils) ValidatorIndex(proposers []string) {
 	ValidatorGroups := chunkSlice(proposers, 10)
 
 	for _, validators := range ValidatorGroups {
-		go proposer.BeaconClient.GetValidatorIndex(validators, &proposer.Validators)
+		go proposer.BeaconClient.GetValidatorIndex(context.Background(), validators, &proposer.Validators)
 	}
 }
 
@@ -180,6 +215,7 @@ func (builderInterface *BuilderUtils) GetBuilders(ponPool ponpool.PonRegistrySub
 
 	builderInterface.Mu.Lock()
 	defer builderInterface.Mu.Unlock()
+	builderInterface.LastSync = time.Now()
 	builderInterface.Log.Infof("Updating %d block builders in Redis...", len(builders))
 	for _, builder := range builders {
 		if builder.Status == builderInterface.BuilderLast[builder.Builder.BuilderPubkey] {
@@ -207,6 +243,7 @@ func (reporterInterface *ReporterUtils) GetReporters(ponPool ponpool.PonRegistry
 
 	reporterInterface.Mu.Lock()
 	defer reporterInterface.Mu.Unlock()
+	reporterInterface.LastSync = time.Now()
 
 	for _, reporter := range reporters {
 		if reporter.Active == reporterInterface.ReporterLast[reporter.ReporterPubkey] {
@@ -226,6 +263,28 @@ func (reporterInterface *ReporterUtils) GetReporters(ponPool ponpool.PonRegistry
 	}
 }
 
+// LastPonPoolSync returns the oldest of the last successful validator, builder
+// and reporter fetches from the PON pool subgraph, zero if any never succeeded
+func (relay *RelayUtils) LastPonPoolSync() time.Time {
+	relay.proposerUtils.ProposerStatus.Mu.Lock()
+	lastSync := relay.proposerUtils.ProposerStatus.LastSync
+	relay.proposerUtils.ProposerStatus.Mu.Unlock()
+
+	relay.builderUtils.Mu.Lock()
+	if relay.builderUtils.LastSync.Before(lastSync) {
+		lastSync = relay.builderUtils.LastSync
+	}
+	relay.builderUtils.Mu.Unlock()
+
+	relay.reporterUtils.Mu.Lock()
+	if relay.reporterUtils.LastSync.Before(lastSync) {
+		lastSync = relay.reporterUtils.LastSync
+	}
+	relay.reporterUtils.Mu.Unlock()
+
+	return lastSync
+}
+
 func (proposerInterface *ProposerUtils) SetValidatorStatus(validator string, status string) error {
 	return proposerInterface.RedisInterface.Client.HSet(context.Background(), keyValidatorStatus, validator, status).Err()
 }
@@ -238,17 +297,44 @@ func (reporterInterface *ReporterUtils) SetReporterStatus(reporter string, statu
 	return reporterInterface.RedisInterface.Client.HSet(context.Background(), keyReporterrStatus, reporter, status).Err()
 }
 
-func (relay *RelayUtils) BuilderStatus(builder string) (BuilderStatus bool, err error) {
+// BuilderStatus reports whether the builder may submit bids and, if not, why.
+// The relay's own policy is consulted before the PON pool status, a deny
+// policy always rejects and an allow policy accepts regardless of stake.
+func (relay *RelayUtils) BuilderStatus(builder string) (BuilderStatus bool, reason string, err error) {
+	policy := relay.builderPolicy(builder)
+	if policy != nil && policy.Policy == database.BuilderPolicyDeny {
+		return false, policyRejection("Builder Denied By Relay", policy), nil
+	}
+	if policy != nil && policy.Policy == database.BuilderPolicyAllow {
+		return true, "", nil
+	}
+	if relay.policies.Allowlist {
+		return false, "Builder Not In Relay Allowlist", nil
+	}
+
 	res, err := relay.builderUtils.RedisInterface.Client.HGet(context.Background(), keyBuilderStatus, builder).Result()
 	if errors.Is(err, redis.Nil) {
-		return false, nil
+		return false, "Builder Not Active In PON", nil
 	}
 	status, err := strconv.ParseBool(res)
 	if err != nil {
-		return false, err
+		return false, "", err
+	}
+	if !status {
+		return false, "Builder Not Active In PON", nil
 	}
 
-	return status, err
+	return true, "", nil
+}
+
+func policyRejection(rejection string, policy *database.BuilderPolicy) string {
+	if policy.Reason != "" {
+		rejection = fmt.Sprintf("%s: %s", rejection, policy.Reason)
+	}
+	if policy.ExpiresAt != nil {
+		rejection = fmt.Sprintf("%s (until %s)", rejection, policy.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return rejection
 }
 
 func (relay *RelayUtils) ValidatorIndexToPubkey(index uint64, network uint64) (PublicKey, error) {
@@ -12,6 +12,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	beaconclient "github.com/pon-pbs/bbRelay/beaconinterface"
+	"github.com/pon-pbs/bbRelay/database"
 	"github.com/pon-pbs/bbRelay/redisPackage"
 )
 
@@ -21,6 +22,16 @@ var (
 	keyValidatorStatus = "validator-status"
 	keyBuilderStatus   = "builder-status"
 	keyReporterrStatus = "reporter-status"
+
+	// channelBuilderPolicies tells every relay instance a builder policy changed
+	channelBuilderPolicies = "builder-policy-updates"
+	// channelBuilderEndpoints shares new endpoint registrations between relay instances
+	channelBuilderEndpoints = "builder-endpoint-registrations"
+)
+
+var (
+	ErrInvalidBuilderPolicy      = errors.New("builder policy must be deny or allow")
+	ErrStaleEndpointRegistration = errors.New("endpoint registration older than the registered one")
 )
 
 type PublicKey [48]byte
@@ -55,18 +66,47 @@ type ProposerUtils struct {
 
 type ProposerUpdates struct {
 	ValidatorsLast map[string]string
+	LastSync       time.Time
 	Mu             sync.Mutex
 }
 
 type BuilderUtils struct {
 	BuilderLast    map[string]bool
+	LastSync       time.Time
 	Mu             sync.Mutex
 	Log            logrus.Entry
 	RedisInterface *redisPackage.RedisInterface
 }
 
+// BuilderPolicies caches the relay's own builder policies from the database.
+// In allowlist mode only builders with an allow policy are accepted.
+type BuilderPolicies struct {
+	Policies  map[string]database.BuilderPolicy
+	Allowlist bool
+	Mu        sync.RWMutex
+
+	// loadMu keeps a slow reload from replacing the result of a later one
+	loadMu sync.Mutex
+}
+
+// BuilderEndpointRegistry caches the registered builder endpoints and the
+// result of probing them. With Required set bids must use registered endpoints.
+type BuilderEndpointRegistry struct {
+	Registrations map[string]database.BuilderEndpoints
+	Health        map[string]EndpointHealth
+	Required      bool
+	Mu            sync.RWMutex
+}
+
+type EndpointHealth struct {
+	Failures  int       `json:"failures"`
+	LastProbe time.Time `json:"last_probe"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
 type ReporterUtils struct {
 	ReporterLast   map[string]bool
+	LastSync       time.Time
 	Mu             sync.Mutex
 	Log            logrus.Entry
 	RedisInterface *redisPackage.RedisInterface
@@ -88,6 +128,7 @@ type GetPayloadUtils struct {
 	Data                 *commonTypes.VersionedExecutionPayloadHeader
 	API                  string
 	BuilderWalletAddress string
+	PayoutTransaction    []byte
 }
 
 func chunkSlice(slice []string, chunkSize int) [][]string {